@@ -0,0 +1,35 @@
+package db
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// How values of binary columns (BLOB, BYTEA, VARBINARY, etc.) are stored in results
+type BinaryFormat int
+
+const (
+	// Bytes as is, which may not be valid text (i.e. garbled when displayed or exported as JSON)
+	BinaryRaw BinaryFormat = iota
+	// Hex literal, ex: 0x48656C6C6F
+	BinaryHex
+	// Standard base64 encoding, ex: SGVsbG8=
+	BinaryBase64
+)
+
+// Choose how binary column values are stored in results, BinaryRaw by default
+// Hex or base64 keep values intact through display and the CSV/JSON exporters
+func (db *DBClient) SetBinaryFormat(format BinaryFormat) {
+	db.binaryFormat = format
+}
+
+func (format BinaryFormat) encode(value string) string {
+	switch format {
+	case BinaryHex:
+		return fmt.Sprintf("0x%X", value)
+	case BinaryBase64:
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	default:
+		return value
+	}
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// How boolean values are displayed, see DBClient.SetBoolDisplay
+type BoolDisplay struct {
+	True  string
+	False string
+	// Also display MySQL TINYINT columns holding 0 or 1 as booleans
+	// MySQL stores BOOLEAN as TINYINT(1), but the driver reports every TINYINT the same way without the (1)
+	// so this is opt-in, other TINYINTs that happen to hold 0 or 1 are caught as well
+	MySQLTinyInt bool
+}
+
+var DefaultBoolDisplay = BoolDisplay{True: "true", False: "false"}
+
+// Display values of boolean columns (going by ColumnTypes) the same way, whether the database returned 1/0, t/f or true/false
+// DefaultBoolDisplay unless set, the zero BoolDisplay keeps values as returned
+// The original value is still available through NullString.RawValue, and is what TypedRows goes by
+func (db *DBClient) SetBoolDisplay(display BoolDisplay) {
+	db.boolDisplay = display
+}
+
+// Whether values of a column of this type go through boolDisplay
+func (opts scanOptions) displayedAsBool(databaseTypeName string) bool {
+	if opts.boolDisplay == (BoolDisplay{}) {
+		return false
+	}
+
+	return columnKindOf(databaseTypeName) == columnKindBool || (opts.tinyIntBools && strings.EqualFold(databaseTypeName, "TINYINT"))
+}
+
+// Replace value with its display form, keeping the original as raw, values that aren't booleans are left alone
+func (display BoolDisplay) apply(value *NullString) {
+	if !value.Valid {
+		return
+	}
+
+	boolValue, err := strconv.ParseBool(value.String)
+	if err != nil {
+		return
+	}
+
+	value.raw = value.String
+	if boolValue {
+		value.String = display.True
+	} else {
+		value.String = display.False
+	}
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Insert many rows with multi-row INSERT statements, in a single transaction so either all or none are inserted
+// Rows are split into batches that stay under the database's limit on bound parameters
+// table and columns are used as is, quote them with QuoteIdentifier if they come from user input
+// Returns how many rows were inserted, 0 in dry run mode where each batch is only validated
+func (db *DBClient) BulkInsert(table string, columns []string, rows [][]any) (int64, error) {
+	return db.BulkInsertContext(db.ctx, table, columns, rows)
+}
+
+// Same as BulkInsert, but the inserts can be cancelled or given a deadline through ctx
+// Cancelling rolls back every batch
+func (db *DBClient) BulkInsertContext(ctx context.Context, table string, columns []string, rows [][]any) (inserted int64, err error) {
+	if len(columns) == 0 {
+		return 0, errors.New("At least one column is required to insert rows")
+	}
+	for rowIdx, row := range rows {
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("Row %d has %d value(s), expected %d to match the columns", rowIdx+1, len(row), len(columns))
+		}
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	flavor := db.connManager.GetFlavor()
+	batchSize := bulkInsertBatchSize(flavor, len(columns))
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+
+		statement, args := buildBulkInsert(flavor, table, columns, batch)
+		result, err := tx.Exec(statement, args...)
+		if err != nil {
+			return 0, errors.Join(
+				fmt.Errorf("Failed to insert rows %d to %d", start+1, start+len(batch)),
+				err,
+			)
+		}
+
+		if !result.DryRun {
+			inserted += result.RowsAffected
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
+// How many rows fit in a single INSERT without going over the bound parameter limit
+func bulkInsertBatchSize(flavor DBFlavor, columnCount int) int {
+	var maxParams, maxRows int
+	switch flavor {
+	case MySQL, PostgreSQL:
+		maxParams = 65535
+	case SQLite:
+		// Default SQLITE_MAX_VARIABLE_NUMBER since 3.32
+		maxParams = 32766
+	case SQLServer:
+		{
+			// 2100 parameters per request, a couple kept free for the driver
+			maxParams = 2098
+			// Table value constructors are limited to 1000 rows
+			maxRows = 1000
+		}
+	default:
+		maxParams = 999
+	}
+
+	batchSize := max(maxParams/columnCount, 1)
+	if maxRows > 0 {
+		batchSize = min(batchSize, maxRows)
+	}
+
+	return batchSize
+}
+
+// ex: INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4)
+func buildBulkInsert(flavor DBFlavor, table string, columns []string, rows [][]any) (string, []any) {
+	var statement strings.Builder
+	args := make([]any, 0, len(rows)*len(columns))
+
+	fmt.Fprintf(&statement, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+	for rowIdx, row := range rows {
+		if rowIdx > 0 {
+			statement.WriteString(", ")
+		}
+
+		statement.WriteByte('(')
+		for valueIdx, value := range row {
+			if valueIdx > 0 {
+				statement.WriteString(", ")
+			}
+
+			args = append(args, value)
+			statement.WriteString(placeholder(flavor, len(args)))
+		}
+		statement.WriteByte(')')
+	}
+
+	return statement.String(), args
+}
+
+// Bind parameter syntax for the nth (starting at 1) argument
+func placeholder(flavor DBFlavor, n int) string {
+	switch flavor {
+	case PostgreSQL:
+		return fmt.Sprintf("$%d", n)
+	case SQLServer:
+		return fmt.Sprintf("@p%d", n)
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// Handle to cancel the query currently running on the DBClient
+type activeQuery struct {
+	cancel context.CancelCauseFunc
+}
+
+// Cancel the query that's currently running, i.e. from another goroutine when the user aborts
+// In pool mode, every query running is cancelled. Does nothing if no query is running
+func (db *DBClient) Cancel() {
+	db.activeQueryMu.Lock()
+	defer db.activeQueryMu.Unlock()
+
+	for query := range db.activeQueries {
+		query.cancel(ErrCancelled)
+	}
+}
+
+// Register the query so Cancel can reach it, call the returned func once the query is done
+// The connection is held until then, so the keepalive ping never runs alongside the query
+// In pool mode the query gets its own connection instead (see getPooledConnection), returned to the pool once done
+func (db *DBClient) trackActiveQuery(ctx context.Context) (context.Context, context.CancelFunc) {
+	var slot *pooledConnSlot
+	if db.pooled() {
+		db.connMu.RLock()
+		slot = &pooledConnSlot{}
+	} else {
+		db.connMu.Lock()
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	query := &activeQuery{cancel: cancel}
+	if slot != nil {
+		ctx = context.WithValue(ctx, pooledConnKey{}, slot)
+	}
+
+	db.activeQueryMu.Lock()
+	db.activeQueries[query] = struct{}{}
+	db.activeQueryMu.Unlock()
+
+	return ctx, func() {
+		db.activeQueryMu.Lock()
+		delete(db.activeQueries, query)
+		db.activeQueryMu.Unlock()
+
+		cancel(nil)
+		defer db.resetIdleClose()
+		if slot == nil {
+			db.connMu.Unlock()
+			return
+		}
+
+		if slot.conn != nil {
+			_ = slot.conn.Close()
+		}
+		db.connMu.RUnlock()
+	}
+}
+
+// If the query failed because Cancel was called, make that clear
+func explainCancelled(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(context.Cause(ctx), ErrCancelled) || errors.Is(err, ErrCancelled) {
+		return err
+	}
+
+	return errors.Join(ErrCancelled, err)
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tuning for the underlying connection pool
+// Zero values follow database/sql semantics, i.e. 0 MaxOpenConns means unlimited
+type DBClientOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// How many times to try reaching the database when creating the client, at least once
+	PingAttempts int
+	// Wait before the first retry, doubling after each subsequent failed attempt
+	PingBackoff time.Duration
+	// How long each attempt may take to connect, 0 waits as long as the OS does (can be minutes)
+	ConnectTimeout time.Duration
+	// How many prepared statements to keep around, least recently used are closed first
+	StatementCacheSize int
+	// Have the server abort statements running longer than this, 0 means no limit
+	// Set on every connection, so it's enforced even if the client can't reach the server to cancel
+	// MySQL (MAX_EXECUTION_TIME) only applies it to SELECT statements, only MySQL and PostgreSQL support it
+	StatementTimeout time.Duration
+	// Re-run a read query (SELECT, SHOW, EXPLAIN, DESCRIBE) once on a new connection if the connection
+	// broke while it ran, i.e. the server restarted. Never inside a transaction, or for other statements
+	// Off by default, a query with side effects (i.e. calling a function that writes) would run twice
+	RetryOnConnLoss bool
+	// Check out a connection from a pool of up to this many for each query, so queries can run concurrently
+	// Session init (safe mode, StatementTimeout, AddSessionInit) is applied to every connection as it's opened
+	// Overrides MaxOpenConns and MaxIdleConns. 0 holds on to a single connection for every query instead
+	// Session state set by a statement (i.e. USE, SET) only sticks to whichever connection ran it
+	PoolSize int
+}
+
+// Defaults used by CreateDBClient
+// By default we only ever want a single connection, which is kept alive for 5 mins
+// Databases that are still starting up (i.e. docker-compose) get a few tries to accept connections
+func DefaultDBClientOptions() DBClientOptions {
+	return DBClientOptions{
+		MaxOpenConns:       1,
+		MaxIdleConns:       1,
+		ConnMaxLifetime:    time.Minute * 5,
+		PingAttempts:       3,
+		PingBackoff:        time.Millisecond * 500,
+		ConnectTimeout:     time.Second * 5,
+		StatementCacheSize: 32,
+	}
+}
+
+// Session setting enforcing timeout server-side, empty when there's no timeout
+func statementTimeoutStatement(flavor DBFlavor, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return "", nil
+	}
+
+	// Both take milliseconds, where 0 would disable the timeout instead
+	milliseconds := max(timeout.Milliseconds(), 1)
+	switch flavor {
+	case MySQL:
+		return fmt.Sprintf("SET SESSION MAX_EXECUTION_TIME = %d", milliseconds), nil
+	case PostgreSQL:
+		return fmt.Sprintf("SET statement_timeout = %d", milliseconds), nil
+	default:
+		return "", fmt.Errorf("StatementTimeout is not supported for %s", flavor.DisplayName())
+	}
+}
@@ -0,0 +1,46 @@
+package db
+
+import "strings"
+
+// Broad category of a column's database type, to decide how values should be treated
+// DatabaseTypeName differs between drivers (ex: INT4 for PostgreSQL, INT for MySQL), so this normalizes them
+type columnKind int
+
+const (
+	columnKindText columnKind = iota
+	columnKindInteger
+	// Floating point and fixed precision (DECIMAL, NUMERIC) values
+	columnKindDecimal
+	columnKindBool
+	// Raw bytes, which may not be valid text
+	columnKindBinary
+	// Dates and timestamps, not times of day on their own
+	columnKindTime
+)
+
+func columnKindOf(databaseTypeName string) columnKind {
+	typeName := strings.TrimPrefix(strings.ToUpper(databaseTypeName), "UNSIGNED ")
+
+	switch typeName {
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "INT2", "INT4", "INT8", "YEAR":
+		return columnKindInteger
+	case "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL", "DECIMAL", "NUMERIC":
+		return columnKindDecimal
+	case "BOOL", "BOOLEAN":
+		return columnKindBool
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BYTEA", "IMAGE":
+		return columnKindBinary
+	case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET", "TIMESTAMP", "TIMESTAMPTZ":
+		return columnKindTime
+	default:
+		return columnKindText
+	}
+}
+
+func (queryResult *QueryResult) columnKind(columnIdx int) columnKind {
+	if columnIdx >= len(queryResult.ColumnTypes) {
+		return columnKindText
+	}
+
+	return columnKindOf(queryResult.ColumnTypes[columnIdx])
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Asked before running a statement that could wipe out data in safe mode, returns whether to go ahead
+type ConfirmFunc func(statement string) bool
+
+// In safe mode, check with fn before running DROP, TRUNCATE, ALTER, or an UPDATE/DELETE without a WHERE clause
+// i.e. to show an "are you sure?" dialog, declined statements fail with ErrNotConfirmed without being sent
+// Covers queries, transactions and prepared statements (each time they run) alike, nil fn (the default) never asks
+func (db *DBClient) SetConfirmFunc(fn ConfirmFunc) {
+	db.confirmFunc = fn
+}
+
+var whereRegExp = regexp.MustCompile(`(?i)\bWHERE\b`)
+
+// Whether statement is one SetConfirmFunc asks about: DROP, TRUNCATE, ALTER, or an UPDATE/DELETE without a WHERE clause
+// Each statement of a ; separated script is checked, a WHERE in a subquery doesn't count
+func (flavor DBFlavor) IsDestructive(statement string) bool {
+	for _, statement := range SplitStatements(statement, flavor) {
+		keyword := statementLeadingKeyword(statement)
+		if keyword == "WITH" {
+			keyword = statementKeywordAfterCTEs(statement)
+		}
+
+		switch keyword {
+		case "DROP", "TRUNCATE", "ALTER":
+			return true
+		case "UPDATE", "DELETE":
+			if !whereRegExp.MatchString(topLevelSQL(statement, flavor)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (db *DBClient) confirmStatement(statement string) error {
+	if db.confirmFunc == nil || !db.connManager.IsSafeMode() {
+		return nil
+	}
+
+	if db.connManager.GetFlavor().IsDestructive(statement) && !db.confirmFunc(statement) {
+		return fmt.Errorf("%w: %s", ErrNotConfirmed, statementLeadingKeyword(statement))
+	}
+
+	return nil
+}
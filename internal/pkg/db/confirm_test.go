@@ -0,0 +1,49 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDestructive(t *testing.T) {
+	t.Run("Destructive", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.True(db.MySQL.IsDestructive("DROP TABLE users"))
+		assert.True(db.MySQL.IsDestructive("truncate users"))
+		assert.True(db.PostgreSQL.IsDestructive("ALTER TABLE users DROP COLUMN name"))
+		assert.True(db.MySQL.IsDestructive("DELETE FROM users"))
+		assert.True(db.MySQL.IsDestructive("UPDATE users SET name = 'bob'"))
+		assert.True(db.MySQL.IsDestructive("-- cleanup\nDELETE FROM users;"))
+		assert.True(db.PostgreSQL.IsDestructive("WITH stale AS (SELECT id FROM users WHERE active = false) DELETE FROM users"))
+	})
+
+	t.Run("WHERE only counts at the top level", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.False(db.MySQL.IsDestructive("DELETE FROM users WHERE id = 1"))
+		assert.False(db.MySQL.IsDestructive("update users set name = 'bob' where id in (select id from admins)"))
+		assert.True(db.MySQL.IsDestructive("UPDATE users SET name = (SELECT name FROM admins WHERE id = 1)"))
+		assert.True(db.MySQL.IsDestructive("UPDATE users SET note = 'WHERE'"))
+		assert.True(db.MySQL.IsDestructive("DELETE FROM users -- WHERE id = 1"))
+		assert.True(db.MySQL.IsDestructive("DELETE FROM `where`"))
+	})
+
+	t.Run("Every statement of a script", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.True(db.MySQL.IsDestructive("SELECT 1; DROP TABLE users"))
+		assert.False(db.MySQL.IsDestructive("SELECT 1; INSERT INTO users (name) VALUES ('DROP TABLE users')"))
+	})
+
+	t.Run("Not destructive", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.False(db.MySQL.IsDestructive("SELECT * FROM users"))
+		assert.False(db.MySQL.IsDestructive("INSERT INTO users (name) VALUES ('alice')"))
+		assert.False(db.PostgreSQL.IsDestructive("CREATE TABLE users (id INT)"))
+		assert.False(db.MySQL.IsDestructive(""))
+	})
+}
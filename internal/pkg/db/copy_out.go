@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Stream the rows of a SELECT to w as CSV with a header row, NULL values are written as empty fields
+// For PostgreSQL this uses COPY ... TO STDOUT, which is much faster for large exports
+// Values are then formatted by PostgreSQL itself (i.e. bytea as \x hex), rather than through SetBinaryFormat
+// Other flavors stream rows as they're read, without buffering the whole result like Query
+// Display settings other than SetBinaryFormat (i.e. SetMaxColumnBytes, SetMaxRows, SetNullDisplay) don't apply
+func (db *DBClient) CopyOut(query string, w io.Writer) error {
+	return db.CopyOutContext(db.ctx, query, w)
+}
+
+// Same as CopyOut, but the export can be cancelled or given a deadline through ctx
+func (db *DBClient) CopyOutContext(ctx context.Context, query string, w io.Writer) (err error) {
+	defer db.logQuery(query, nil, time.Now(), &err)
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, db.explainQueryTimeout(ctx, err)) }()
+
+	if StatementType(query) != Select {
+		return errors.New("Only SELECT queries can be exported")
+	}
+
+	if db.connManager.GetFlavor() == PostgreSQL {
+		return db.copyOutPostgres(ctx, query, w)
+	}
+
+	return db.copyOutRows(ctx, query, w)
+}
+
+func (db *DBClient) copyOutPostgres(ctx context.Context, query string, w io.Writer) error {
+	if err := db.assertStatementAllowed(query); err != nil {
+		return err
+	}
+
+	conn, err := db.getConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Newline keeps a trailing -- comment in the query from swallowing the closing parenthesis
+	copyStatement := fmt.Sprintf("COPY (%s\n) TO STDOUT WITH (FORMAT csv, HEADER true)", trimStatement(query))
+
+	err = conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("Unexpected PostgreSQL driver connection %T", driverConn)
+		}
+
+		_, err := pgxConn.Conn().PgConn().CopyTo(ctx, w, copyStatement)
+		return err
+	})
+	if err != nil {
+		return errors.Join(
+			ErrQueryFailed,
+			asQueryError(err),
+		)
+	}
+
+	return nil
+}
+
+func (db *DBClient) copyOutRows(ctx context.Context, query string, w io.Writer) error {
+	rows, err := db.queryRows(ctx, query, nil)
+	if err != nil {
+		return err
+	} else if rows == nil {
+		return nil
+	}
+
+	// The header goes first, so column names are needed before scanning
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return errors.Join(
+			ErrColumnParse,
+			err,
+		)
+	}
+	columns = uniqueColumnNames(columns)
+
+	csvWriter := csv.NewWriter(w)
+	if err = csvWriter.Write(columns); err != nil {
+		rows.Close()
+		return err
+	}
+
+	record := make([]string, len(columns))
+	// Exports keep every row and full values, only binary columns need a display format to fit in CSV
+	opts := scanOptions{binaryFormat: db.binaryFormat}
+	_, _, err = scanRows(ctx, rows, opts, func(row map[string]*NullString) error {
+		for columnIdx, columnName := range columns {
+			// NullString.String is already empty for NULL values
+			record[columnIdx] = row[columnName].String
+		}
+
+		return csvWriter.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
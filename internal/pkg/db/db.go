@@ -2,190 +2,1225 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	_ "github.com/microsoft/go-mssqldb"
+	_ "modernc.org/sqlite"
 )
 
+// Safe for concurrent use, statements on the single connection take turns (see connMu) rather than interleaving
 type DBClient struct {
-	ctx         context.Context
-	sqlDB       *sqlx.DB
-	_conn       *sqlx.Conn
+	ctx   context.Context
+	sqlDB *sqlx.DB
+	_conn *sqlx.Conn
+	_tx   *Tx
+	// Single mode's OpenQuery, if one is reading rows off _conn, see QueryOpen
+	_openQuery  *OpenQuery
+	stmtCache   *stmtCache
+	resultCache *resultCache
 	connManager ConnManager
+	// What sqlDB was opened with, to reopen it for SwitchDatabase
+	clientOptions DBClientOptions
+	// Whether sqlDB was opened by the DBClient, rather than handed to NewDBClientFromDB, so it's closed on Destroy
+	ownsSQLDB bool
+	// Run on every new connection, safe mode statements first
+	sessionInit []string
+	readOnly    bool
+	// PostgreSQL only, applied after sessionInit, see SetSearchPath
+	searchPath []string
+	// Guards sessionInit, readOnly and searchPath in pool mode, where connections are opened concurrently
+	sessionMu sync.Mutex
+	// Bumped whenever session settings change, so a transaction's connection isn't returned to the pool stale
+	sessionGeneration int
+	dryRun            bool
+	// How binary column values are stored in results
+	binaryFormat BinaryFormat
+	// Applied to results, nil uses DefaultNullDisplay
+	nullDisplay *string
+	// See SetBoolDisplay, the zero value keeps booleans as returned
+	boolDisplay BoolDisplay
+	// Values longer than this are truncated while scanning, 0 means no limit
+	maxColumnBytes int
+	// Results stop after this many rows, 0 means no limit
+	maxRows int
+	// Optional, Query and Exec calls are recorded in it
+	queryHistory *QueryHistory
+	// See SetCollectWarnings, notices are only filled in for PostgreSQL
+	collectWarnings bool
+	notices         *noticeBuffer
+	// Registered with the pgx driver to hook up the notice handler, empty for other flavors
+	pgxConnConfigName string
+	// Registered with the MySQL driver for DBConnOptions.Dial, empty unless one is set
+	mysqlDialNetwork string
+	// 0 means no timeout
+	queryTimeout time.Duration
+	// Cached by ServerVersion, CurrentDatabase, CurrentUser and ServerHost
+	serverVersion   string
+	currentDatabase string
+	currentUser     string
+	serverHost      string
+	// When _conn was opened, it's replaced once older than connMaxLifetime
+	// Only set through SetConnMaxLifetime, so sessions aren't dropped unless asked for
+	connOpenedAt    time.Time
+	connMaxLifetime time.Duration
+	// Called with the reason whenever a dropped connection is replaced
+	onReconnect func(err error)
+	// See SetOnProgress
+	onProgress func(rowsScanned int)
+	// See SetConfirmFunc
+	confirmFunc ConfirmFunc
+	// See DBClientOptions.RetryOnConnLoss
+	retryOnConnLoss bool
+	// Forwards connections to the database, nil when connecting directly
+	sshTunnel *sshTunnel
+	// Optional, notified of every statement sent
+	logger Logger
+	// Queries that Cancel will abort, more than one only in pool mode
+	activeQueries map[*activeQuery]struct{}
+	activeQueryMu sync.Mutex
+	// Held while a statement is using _conn, see trackActiveQuery
+	// In pool mode queries share it (read lock), anything changing the session or pool takes it exclusively
+	connMu sync.RWMutex
+	// Guards the cached server info, which pooled queries may read and invalidate concurrently
+	serverInfoMu sync.Mutex
+	// Stops the loop started by StartKeepalive, nil when it isn't running
+	stopKeepalive func()
+	// See SetIdleClose, the timer is nil when it's disabled
+	idleCloseAfter time.Duration
+	idleCloseTimer *time.Timer
+	idleCloseMu    sync.Mutex
+	// Channels passed to Listen, each with a connection of its own
+	listeners  map[string]*channelListener
+	listenerMu sync.Mutex
 }
 
 // Instantiate a DBClient from a DSN
 func CreateDBClient(
 	dsnProducer ConnManager,
 ) (*DBClient, error) {
-	dataSourceName, err := dsnProducer.GetDSN()
-	if err != nil {
+	return CreateDBClientWithOptions(dsnProducer, DefaultDBClientOptions())
+}
+
+// Same as CreateDBClient, but connecting can be aborted or given a deadline through ctx
+// ctx is also used by every call that doesn't take one (i.e. Query rather than QueryContext)
+// so cancelling it later stops those as well
+func CreateDBClientContext(
+	ctx context.Context,
+	dsnProducer ConnManager,
+) (*DBClient, error) {
+	return CreateDBClientWithOptionsContext(ctx, dsnProducer, DefaultDBClientOptions())
+}
+
+// Instantiate a DBClient from a DSN, with custom connection pool settings
+func CreateDBClientWithOptions(
+	dsnProducer ConnManager,
+	opts DBClientOptions,
+) (*DBClient, error) {
+	return CreateDBClientWithOptionsContext(context.Background(), dsnProducer, opts)
+}
+
+// Same as CreateDBClientWithOptions, with ctx as in CreateDBClientContext
+func CreateDBClientWithOptionsContext(
+	ctx context.Context,
+	dsnProducer ConnManager,
+	opts DBClientOptions,
+) (*DBClient, error) {
+	// Fail on settings that could never connect, without waiting on the network
+	if err := dsnProducer.Validate(); err != nil {
 		return nil, errors.Join(
-			errors.New("Failed to create connection string"),
+			ErrInvalidConnOptions,
 			err,
 		)
 	}
 
-	sqlDB, err := sqlx.Open(string(dsnProducer.GetFlavor()), dataSourceName)
+	sessionInit := slices.Clone(dsnProducer.SafeModeStatements())
+	statementTimeout, err := statementTimeoutStatement(dsnProducer.GetFlavor(), opts.StatementTimeout)
 	if err != nil {
 		return nil, errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	} else if statementTimeout != "" {
+		sessionInit = append(sessionInit, statementTimeout)
+	}
+
+	tunnel, err := openSSHTunnelFor(dsnProducer, opts.ConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	db := newDBClient(dsnProducer, opts)
+	db.ctx = ctx
+	db.sessionInit = sessionInit
+	db.searchPath = searchPathFor(dsnProducer)
+	db.sshTunnel = tunnel
+	db.ownsSQLDB = true
+
+	db.sqlDB, db.pgxConnConfigName, err = db.openSQLDB(ctx, tunnel.connManager(dsnProducer))
+	if err != nil {
+		if tunnel != nil {
+			_ = tunnel.Close()
+		}
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// DBClient with nothing opened yet, sqlDB is left for the caller to set
+func newDBClient(dsnProducer ConnManager, opts DBClientOptions) *DBClient {
+	return &DBClient{
+		ctx:             context.Background(),
+		clientOptions:   opts,
+		stmtCache:       newStmtCache(opts.StatementCacheSize),
+		resultCache:     &resultCache{},
+		connManager:     dsnProducer,
+		notices:         &noticeBuffer{},
+		retryOnConnLoss: opts.RetryOnConnLoss,
+		activeQueries:   map[*activeQuery]struct{}{},
+		boolDisplay:     DefaultBoolDisplay,
+	}
+}
+
+// Open the connection pool for the DSN and make sure the database is reachable
+// For PostgreSQL, the returned name is the pgx config registered to hook up notices, unregister it once done
+// In pool mode, every connection opened gets the session init, including the one pinged here
+func (db *DBClient) openSQLDB(
+	ctx context.Context,
+	dsnProducer ConnManager,
+) (sqlDB *sqlx.DB, pgxConnConfigName string, err error) {
+	opts := db.clientOptions
+
+	dataSourceName, err := dsnProducer.GetDSN()
+	if err != nil {
+		return nil, "", errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	}
+
+	// Include where we tried to connect for debugging, without leaking credentials
+	// Errors from GetDSN on the other hand are never displayed alongside the connection string
+	redactedDSN, err := dsnProducer.GetRedactedDSN()
+	if err != nil {
+		return nil, "", errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	}
+	connectionDetails := fmt.Errorf("Connection string: %s", redactedDSN)
+
+	dial := dialFuncFor(dsnProducer)
+	if dial != nil && dsnProducer.GetFlavor() == MySQL {
+		dataSourceName, err = db.withMySQLDial(dataSourceName, dial)
+		if err != nil {
+			return nil, "", errors.Join(
+				errors.New("Failed to open database"),
+				err,
+				connectionDetails,
+			)
+		}
+	}
+
+	if dsnProducer.GetFlavor() == PostgreSQL {
+		pgxConnConfigName, err = registerPostgresNoticeHandler(dataSourceName, db.notices, dial)
+		if err != nil {
+			return nil, "", errors.Join(
+				errors.New("Failed to open database"),
+				err,
+				connectionDetails,
+			)
+		}
+		dataSourceName = pgxConnConfigName
+	}
+
+	if db.pooled() {
+		sqlDB, err = openWithConnInit(string(dsnProducer.GetFlavor()), dataSourceName, db.initPooledConn)
+	} else {
+		sqlDB, err = sqlx.Open(string(dsnProducer.GetFlavor()), dataSourceName)
+	}
+	if err != nil {
+		stdlib.UnregisterConnConfig(pgxConnConfigName)
+		return nil, "", errors.Join(
 			errors.New("Failed to open database"),
 			err,
+			connectionDetails,
 		)
 	}
 
-	err = sqlDB.Ping()
+	err = pingWithRetry(ctx, sqlDB, opts.PingAttempts, opts.PingBackoff, opts.ConnectTimeout)
 	if err != nil {
-		return nil, errors.Join(
-			errors.New("Failed to establish connection to database"),
+		sqlDB.Close()
+		stdlib.UnregisterConnConfig(pgxConnConfigName)
+		return nil, "", errors.Join(err, connectionDetails)
+	}
+
+	sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	if db.pooled() {
+		sqlDB.SetMaxOpenConns(opts.PoolSize)
+		sqlDB.SetMaxIdleConns(opts.PoolSize)
+	} else {
+		sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+
+	return sqlDB, pgxConnConfigName, nil
+}
+
+// Ping the database until it responds, with exponential backoff between attempts
+// Each attempt gives up after timeout (unless 0), rather than waiting on the OS to give up on unreachable hosts
+// The returned error includes the last ping failure so the real cause is visible
+func pingWithRetry(ctx context.Context, sqlDB *sqlx.DB, attempts int, backoff time.Duration, timeout time.Duration) error {
+	attempts = max(attempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = pingWithTimeout(ctx, sqlDB, timeout); err == nil {
+			return nil
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(
+				fmt.Errorf("%w, gave up after %d attempt(s)", ErrConnectionFailed, attempt),
+				ctx.Err(),
+				err,
+			)
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+
+	return errors.Join(
+		fmt.Errorf("%w after %d attempt(s)", ErrConnectionFailed, attempts),
+		err,
+	)
+}
+
+func pingWithTimeout(ctx context.Context, sqlDB *sqlx.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return sqlDB.PingContext(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := sqlDB.PingContext(attemptCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return errors.Join(
+			fmt.Errorf("Connection timed out after %s", timeout),
 			err,
 		)
 	}
 
-	// Keep connections alive for 5 mins
-	sqlDB.SetConnMaxLifetime(time.Minute * 5)
+	return err
+}
 
-	// Only should ever have a single connection
-	sqlDB.SetMaxOpenConns(1)
-	sqlDB.SetMaxIdleConns(1)
+// Check a DSN can connect, i.e. a "Test" button before saving connection settings
+// Opens a single connection, pings it and closes it again, without creating a DBClient
+// Connects the same way CreateDBClient would, so Dial and the SSH tunnel apply
+// timeout bounds the whole attempt, 0 means no timeout
+func TestConnection(dsnProducer ConnManager, timeout time.Duration) error {
+	if err := dsnProducer.Validate(); err != nil {
+		return errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	}
 
-	db := DBClient{
-		ctx:         context.Background(),
-		sqlDB:       sqlDB,
-		connManager: dsnProducer,
+	tunnel, err := openSSHTunnelFor(dsnProducer, timeout)
+	if err != nil {
+		return err
+	} else if tunnel != nil {
+		defer tunnel.Close()
 	}
 
-	return &db, nil
+	opts := DefaultDBClientOptions()
+	opts.PingAttempts = 1
+	opts.ConnectTimeout = timeout
+
+	db := newDBClient(dsnProducer, opts)
+	sqlDB, pgxConnConfigName, err := db.openSQLDB(context.Background(), tunnel.connManager(dsnProducer))
+	if err != nil {
+		return err
+	}
+	defer stdlib.UnregisterConnConfig(pgxConnConfigName)
+
+	return sqlDB.Close()
+}
+
+// Escape hatch to the underlying pool, for what DBClient doesn't cover (i.e. Get/Select into structs)
+// Connections from it bypass the client: no read-only or dry run checks, session init (including safe mode),
+// timeouts or history, and statements prepared there aren't cached
+// The client holds on to one connection after its first query, so with the default of a single open
+// connection, anything run through here blocks until Destroy. Raise MaxOpenConns to use both side by side
+// In pool mode connections from here do get the session init, but the client's checks still don't apply
+func (db *DBClient) DB() *sqlx.DB {
+	return db.sqlDB
 }
 
 // Cleanup database resources
 // Call before this struct drops out of scope
+// A query still running (i.e. from another goroutine) is cancelled, and the connection closed once it has stopped
 func (db *DBClient) Destroy() error {
-	// This only returns an error if the connection is already closed, safe to ignore
-	_ = db._conn.Close()
+	return db.DestroyContext(context.Background())
+}
+
+// Same as Destroy, but stops waiting once ctx is done, so a close that hangs can't block shutdown
+// Closing carries on in the background, a query still reading rows holds up closing the connection
+func (db *DBClient) DestroyContext(ctx context.Context) error {
+	if ctx.Done() == nil {
+		return db.destroy()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- db.destroy()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Join(
+			errors.New("Gave up waiting for the database to close"),
+			ctx.Err(),
+		)
+	}
+}
+
+func (db *DBClient) destroy() error {
+	db.StopKeepalive()
+	db.SetIdleClose(0)
+	db.unlistenAll()
+
+	// Cut a query in progress short, rather than waiting for it to finish on its own
+	db.Cancel()
+
+	// Don't leave any partial work behind
+	db.closeOpenQueryAndTx()
+
+	// Wait for queries to let go of the connection, closing it under one that's reading rows would break it
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	db.stmtCache.clear()
+
+	// The connection is only opened on the first query, there may not be one yet
+	// Close only returns an error if the connection is already closed, safe to ignore
+	if db._conn != nil {
+		_ = db._conn.Close()
+		db._conn = nil
+	}
+
+	var err error
+	if db.ownsSQLDB {
+		err = db.sqlDB.Close()
+	}
+	if db.pgxConnConfigName != "" {
+		stdlib.UnregisterConnConfig(db.pgxConnConfigName)
+	}
+	if db.mysqlDialNetwork != "" {
+		mysql.DeregisterDialContext(db.mysqlDialNetwork)
+	}
+	if db.sshTunnel != nil {
+		err = errors.Join(err, db.sshTunnel.Close())
+	}
+
+	return err
+}
+
+// Close the single mode OpenQuery and roll back the transaction, if either is in progress
+// Both are read under connMu, but closed outside of it since they take connMu themselves
+func (db *DBClient) closeOpenQueryAndTx() {
+	db.connMu.Lock()
+	openQuery, tx := db._openQuery, db._tx
+	db.connMu.Unlock()
+
+	if openQuery != nil {
+		_ = openQuery.Close()
+	}
+	if tx != nil {
+		_ = tx.Rollback()
+	}
+}
+
+// Cancel any running query and drop the connection, the next query opens a fresh session
+// Use to recover from a bad session state, i.e. an aborted transaction on PostgreSQL
+// Any transaction in progress is rolled back, session settings such as USE are lost
+func (db *DBClient) Reset() error {
+	db.Cancel()
+	db.closeOpenQueryAndTx()
+
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	db.stmtCache.clear()
+	db.resultCache.clear()
+	db.clearCachedCurrentDatabase()
+
+	// No query has a connection checked out while connMu is held
+	if db.pooled() {
+		db.closeIdleConns()
+		return nil
+	}
+
+	if db._conn == nil {
+		return nil
+	}
+
+	conn := db._conn
+	db._conn = nil
+
+	if err := discardConn(conn); err != nil {
+		return errors.Join(
+			errors.New("Failed to close connection"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Close the connection for good, along with its session
+// Closing would only return the connection to the pool with its session intact
+// Reporting it as bad makes database/sql discard it instead
+func discardConn(conn *sqlx.Conn) error {
+	err := conn.Raw(func(driverConn any) error {
+		return driver.ErrBadConn
+	})
+	if err != nil && !errors.Is(err, driver.ErrBadConn) && !errors.Is(err, sql.ErrConnDone) {
+		return err
+	}
+
+	return nil
+}
 
-	return db.sqlDB.Close()
+// Change how long a connection may be reused, i.e. lower it when a proxy kills connections sooner
+// Unlike DBClientOptions.ConnMaxLifetime, this also applies to the current connection, which is replaced
+// before the next query once it's too old (losing session state such as USE), 0 means reused forever
+func (db *DBClient) SetConnMaxLifetime(lifetime time.Duration) {
+	db.connMaxLifetime = lifetime
+	db.sqlDB.SetConnMaxLifetime(lifetime)
 }
 
 // Run a query and store the output in a displayable format
-// NOTE: results and error may both be nil if a query is succesful yet doesn't return any rows
-func (db *DBClient) Query(statement string) (results *QueryResult, err error) {
-	conn, err := db.getConnection()
+// Any args are bound by the driver to the statement's placeholders (? for MySQL/SQLite, $1 for PostgreSQL)
+// results is never nil when err is nil, see QueryResult.NoResultSet for statements that don't return rows
+func (db *DBClient) Query(statement string, args ...any) (results *QueryResult, err error) {
+	return db.QueryContext(db.ctx, statement, args...)
+}
+
+// Same as Query, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryContext(ctx context.Context, statement string, args ...any) (results *QueryResult, err error) {
+	opts := db.scanOptions()
+	if !db.resultCache.enabled() || db.dryRun || !cacheableQuery(statement, db.connManager.GetFlavor()) {
+		return db.query(ctx, opts, statement, args)
+	}
+
+	cacheKey := newResultCacheKey(statement, args, opts)
+	if cached, ok := db.resultCache.get(cacheKey); ok {
+		return cached, nil
+	}
+
+	results, err = db.query(ctx, opts, statement, args)
+	if err == nil && !results.NoResultSet {
+		db.resultCache.put(cacheKey, results)
+	}
+
+	return results, err
+}
+
+func (db *DBClient) query(ctx context.Context, opts scanOptions, statement string, args []any) (results *QueryResult, err error) {
+	defer db.recordHistory(statement, time.Now(), &err)
+	defer db.logQuery(statement, args, time.Now(), &err)
+	defer func() { results.setStmtType(statement) }()
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, db.explainQueryTimeout(ctx, err)) }()
+
+	if db.shouldDryRun(statement) {
+		rowsEstimate, err := db.dryRunStatement(ctx, statement, args)
+		if err != nil {
+			return nil, err
+		}
+		return newDryRunQueryResult(rowsEstimate), nil
+	}
+
+	db.prepareWarnings()
+
+	startedAt := time.Now()
+	rows, err := db.queryRows(ctx, statement, args)
+	if err != nil {
+		return nil, err
+	} else if rows == nil {
+		return newNoResultSetQueryResult(), nil
+	}
+	queryDuration := time.Since(startedAt)
+
+	results, err = scanQueryResult(ctx, rows, opts, db.onProgress)
+	if err != nil {
+		return nil, err
+	}
+	results.setTiming(queryDuration, time.Since(startedAt)-queryDuration)
+	results.Warnings = db.statementWarnings(ctx, db.activeConn(ctx))
+
+	return results, nil
+}
+
+// Run a query and hand each row to fn as soon as it's read, rather than buffering the whole result
+// Iteration stops at the first error returned from fn, which is then returned
+// Returns the selected column names, order preserved
+func (db *DBClient) QueryStream(
+	statement string,
+	fn func(row map[string]*NullString) error,
+	args ...any,
+) (columns []string, err error) {
+	return db.QueryStreamContext(db.ctx, statement, fn, args...)
+}
+
+// Same as QueryStream, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryStreamContext(
+	ctx context.Context,
+	statement string,
+	fn func(row map[string]*NullString) error,
+	args ...any,
+) (columns []string, err error) {
+	return db.queryStream(ctx, db.scanOptions(), statement, fn, args)
+}
+
+func (db *DBClient) queryStream(
+	ctx context.Context,
+	opts scanOptions,
+	statement string,
+	fn func(row map[string]*NullString) error,
+	args []any,
+) (columns []string, err error) {
+	defer db.logQuery(statement, args, time.Now(), &err)
+	// Stopped early on purpose (i.e. by QueryRow), rather than failing
+	defer func() {
+		if errors.Is(err, errStopIteration) {
+			err = nil
+		}
+	}()
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, db.explainQueryTimeout(ctx, err)) }()
+
+	if db.shouldDryRun(statement) {
+		rowsEstimate, err := db.dryRunStatement(ctx, statement, args)
+		if err != nil {
+			return nil, err
+		}
+
+		dryRunResult := newDryRunQueryResult(rowsEstimate)
+		return dryRunResult.Columns, fn(dryRunResult.Rows[0])
+	}
+
+	rows, err := db.queryRows(ctx, statement, args)
+	if err != nil || rows == nil {
+		return nil, err
+	}
+
+	columns, _, err = scanRows(ctx, rows, opts, fn)
+	return columns, err
+}
+
+// Execute the statement and get the raw rows iterator
+// rows may be nil if the statement doesn't produce any
+func (db *DBClient) queryRows(ctx context.Context, statement string, args []any) (rows *sqlx.Rows, err error) {
+	if err = db.assertStatementAllowed(statement); err != nil {
+		return nil, err
+	}
+	db.invalidateCachedServerInfo(statement)
+	db.resultCache.invalidateFor(statement)
+
+	conn, err := db.getConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	statementWithParams, err := db.transformStatement(statement)
+	statementWithParams, err := db.transformStatement(ctx, statement, args)
 	if err != nil {
 		return nil, errors.Join(
-			errors.New("Query Failed"),
-			err,
+			ErrQueryFailed,
+			asQueryError(err),
 		)
 	}
 
-	// Execute the statement and get the raw rows iterator
-	rows, err := conn.QueryxContext(
-		db.ctx,
+	rows, err = conn.QueryxContext(
+		ctx,
 		statementWithParams.statement,
 		statementWithParams.params...,
 	)
+	if err != nil && db.shouldRetryQuery(ctx, statement, err) {
+		// The dead connection fails its ping, so this reconnects
+		conn, err = db.getConnection(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err = conn.QueryxContext(
+			ctx,
+			statementWithParams.statement,
+			statementWithParams.params...,
+		)
+	}
 	if err != nil {
 		return nil, errors.Join(
-			errors.New("Query Failed"),
-			err,
+			ErrQueryFailed,
+			asQueryError(err),
 		)
-	} else if rows == nil {
-		return nil, nil
 	}
+
+	return rows, nil
+}
+
+// Read all rows from the iterator into a displayable QueryResult, closing the rows once done
+// onProgress is optional, see SetOnProgress
+func scanQueryResult(
+	ctx context.Context,
+	rows *sqlx.Rows,
+	opts scanOptions,
+	onProgress func(rowsScanned int),
+) (results *QueryResult, err error) {
+	collector := newRowCollector(opts)
+	collector.onProgress = onProgress
+
+	columns, columnTypes, err := scanRows(ctx, rows, opts, collector.add)
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return newNoResultSetQueryResult(), nil
+	}
+
+	return &QueryResult{
+		Rows:         collector.rows,
+		Columns:      columns,
+		ColumnTypes:  columnTypes,
+		MaxWidth:     collector.maxWidths(columns),
+		Truncated:    collector.truncated,
+		nullDisplay:  opts.nullDisplay,
+		binaryFormat: opts.binaryFormat,
+	}, nil
+}
+
+// Buffers scanned rows, stopping the scan with errStopIteration once past maxRows (unless 0)
+// Tracks the widest value in each column along the way, see QueryResult.MaxWidth
+type rowCollector struct {
+	maxRows     int
+	nullDisplay string
+	rows        []map[string]*NullString
+	truncated   bool
+	widths      map[string]int
+	// Called every progressInterval rows, nil when nobody is listening
+	onProgress func(rowsScanned int)
+}
+
+// How often rowCollector reports progress, often enough for a spinner without slowing the scan down
+const progressInterval = 1000
+
+func newRowCollector(opts scanOptions) *rowCollector {
+	collector := &rowCollector{
+		maxRows:     opts.maxRows,
+		nullDisplay: DefaultNullDisplay,
+		rows:        []map[string]*NullString{},
+		widths:      map[string]int{},
+	}
+	if opts.nullDisplay != nil {
+		collector.nullDisplay = *opts.nullDisplay
+	}
+
+	return collector
+}
+
+func (collector *rowCollector) add(row map[string]*NullString) error {
+	// Only stop once there's another row, so truncated means rows were actually left out
+	if collector.maxRows > 0 && len(collector.rows) == collector.maxRows {
+		collector.truncated = true
+		return errStopIteration
+	}
+
+	for column, value := range row {
+		collector.widths[column] = max(collector.widths[column], tableCellWidth(value.Display(collector.nullDisplay)))
+	}
+
+	collector.rows = append(collector.rows, row)
+	if collector.onProgress != nil && len(collector.rows)%progressInterval == 0 {
+		collector.onProgress(len(collector.rows))
+	}
+
+	return nil
+}
+
+// Widest value collected for each column, including its name
+func (collector *rowCollector) maxWidths(columns []string) []int {
+	widths := make([]int, len(columns))
+	for columnIdx, column := range columns {
+		widths[columnIdx] = max(collector.widths[column], tableCellWidth(column))
+	}
+
+	return widths
+}
+
+// Settings that apply while reading rows
+type scanOptions struct {
+	binaryFormat   BinaryFormat
+	nullDisplay    *string
+	maxColumnBytes int
+	maxRows        int
+	boolDisplay    BoolDisplay
+	// Display MySQL TINYINTs as booleans, see BoolDisplay.MySQLTinyInt
+	tinyIntBools bool
+}
+
+func (db *DBClient) scanOptions() scanOptions {
+	return scanOptions{
+		binaryFormat:   db.binaryFormat,
+		nullDisplay:    db.nullDisplay,
+		maxColumnBytes: db.maxColumnBytes,
+		maxRows:        db.maxRows,
+		boolDisplay:    db.boolDisplay,
+		tinyIntBools:   db.boolDisplay.MySQLTinyInt && db.connManager.GetFlavor() == MySQL,
+	}
+}
+
+// For the client's own lookups (i.e. ServerVersion, ListTables), which need every row and value in full
+// rather than cut for display
+func (db *DBClient) lookupScanOptions() scanOptions {
+	opts := db.scanOptions()
+	opts.maxColumnBytes = 0
+	opts.maxRows = 0
+
+	return opts
+}
+
+// Scan each row into a map of column -> value and pass it to fn, closing the rows once done
+func scanRows(
+	ctx context.Context,
+	rows *sqlx.Rows,
+	opts scanOptions,
+	fn func(row map[string]*NullString) error,
+) (columns []string, columnTypeNames []string, err error) {
 	defer func() {
-		err := rows.Close()
-		if err != nil {
-			panic("Failed to cleanup rows")
+		closeErr := rows.Close()
+		if closeErr == nil {
+			return
 		}
+
+		// Any earlier failure stays first, it's more likely to explain what went wrong
+		err = errors.Join(
+			err,
+			errors.New("Failed to cleanup rows"),
+			closeErr,
+		)
+		columns, columnTypeNames = nil, nil
 	}()
 
-	columnParsingError := errors.New("Could not determine columns")
+	return scanResultSet(ctx, rows, opts, fn)
+}
+
+// Same as scanRows for the current result set only, leaving rows open so the caller can move on to the next one
+func scanResultSet(
+	ctx context.Context,
+	rows *sqlx.Rows,
+	opts scanOptions,
+	fn func(row map[string]*NullString) error,
+) (columns []string, columnTypeNames []string, err error) {
+	scanner, err := newRowScanner(rows, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns, columnTypeNames = scanner.columns, scanner.columnTypeNames
+
+	for rows.Next() {
+		mappedRow, err := scanner.scan(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Columns are still useful to callers that stop early on purpose
+		if err = fn(mappedRow); err != nil {
+			return columns, columnTypeNames, err
+		}
+	}
+
+	if err = rowsEndErr(ctx, rows); err != nil {
+		return nil, nil, err
+	}
+
+	return columns, columnTypeNames, nil
+}
 
+// Why rows.Next returned false, nil if every row was read
+func rowsEndErr(ctx context.Context, rows *sqlx.Rows) error {
+	// The driver stops iterating once the context is done, make sure that isn't mistaken for the end of the rows
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return errors.Join(
+			ErrQueryCancelled,
+			ctxErr,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Join(
+			ErrRowScan,
+			asQueryError(err),
+		)
+	}
+
+	return nil
+}
+
+// Reads rows of a result set into maps of column -> value, applying scanOptions
+type rowScanner struct {
+	columns         []string
+	columnTypeNames []string
+	binaryColumns   []bool
+	// Displayed through opts.boolDisplay
+	boolColumns []bool
+	opts        scanOptions
+}
+
+func newRowScanner(rows *sqlx.Rows, opts scanOptions) (*rowScanner, error) {
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, errors.Join(
-			columnParsingError,
+			ErrColumnParse,
 			err,
 		)
 	}
+	// Rows are keyed by column name, a repeated name would overwrite the earlier value
+	columns = uniqueColumnNames(columns)
 
-	// Scan all the rows into a string format, since we're just selecting to display
-	rawRows := [][]NullString{}
-	for rows.Next() {
-		rawRow := make([]NullString, len(columns))
-		rawRowPtrs := make([]any, len(columns))
+	// Type info comes from the statement itself, so this is available even with zero rows
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, errors.Join(
+			ErrColumnParse,
+			err,
+		)
+	}
+
+	scanner := &rowScanner{
+		columns:         columns,
+		columnTypeNames: make([]string, len(columnTypes)),
+		binaryColumns:   make([]bool, len(columnTypes)),
+		boolColumns:     make([]bool, len(columnTypes)),
+		opts:            opts,
+	}
+	for i, columnType := range columnTypes {
+		typeName := columnType.DatabaseTypeName()
+		scanner.columnTypeNames[i] = typeName
+		scanner.binaryColumns[i] = columnKindOf(typeName) == columnKindBinary
+		scanner.boolColumns[i] = opts.displayedAsBool(typeName)
+	}
+
+	return scanner, nil
+}
+
+// Scan the row rows.Next moved to
+func (scanner *rowScanner) scan(rows *sqlx.Rows) (map[string]*NullString, error) {
+	columns := scanner.columns
+	rawRow := make([]NullString, len(columns))
+	rawRowPtrs := make([]any, len(columns))
+
+	for i := range rawRow {
+		rawRow[i] = NullString{}
+		rawRowPtrs[i] = &rawRow[i]
+	}
 
-		for i := range rawRow {
-			rawRow[i] = NullString{}
-			rawRowPtrs[i] = &rawRow[i]
+	if err := rows.Scan(rawRowPtrs...); err != nil {
+		return nil, errors.Join(
+			ErrRowScan,
+			err,
+		)
+	}
+
+	mappedRow := make(map[string]*NullString, len(rawRow))
+	for columnIdx := range rawRow {
+		if scanner.binaryColumns[columnIdx] && rawRow[columnIdx].Valid {
+			rawRow[columnIdx].String = scanner.opts.binaryFormat.encode(rawRow[columnIdx].String)
+		}
+		if scanner.boolColumns[columnIdx] {
+			scanner.opts.boolDisplay.apply(&rawRow[columnIdx])
 		}
+		truncateValue(&rawRow[columnIdx], scanner.opts.maxColumnBytes)
 
-		if err = rows.Scan(rawRowPtrs...); err != nil {
-			return nil, errors.Join(
-				errors.New("failed to read rows"),
-				err,
-			)
+		mappedRow[columns[columnIdx]] = &rawRow[columnIdx]
+	}
+
+	return mappedRow, nil
+}
+
+// Suffix repeated names with their occurrence, ex: a.id, b.id -> id, id_2
+// Skips suffixes that clash with another selected column
+func uniqueColumnNames(columns []string) []string {
+	taken := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		taken[column] = true
+	}
+
+	seen := make(map[string]int, len(columns))
+	unique := make([]string, len(columns))
+	for columnIdx, column := range columns {
+		seen[column]++
+		if seen[column] == 1 {
+			unique[columnIdx] = column
+			continue
 		}
 
-		rawRows = append(rawRows, rawRow)
+		occurrence := seen[column]
+		candidate := fmt.Sprintf("%s_%d", column, occurrence)
+		for taken[candidate] {
+			occurrence++
+			candidate = fmt.Sprintf("%s_%d", column, occurrence)
+		}
+
+		taken[candidate] = true
+		seen[column] = occurrence
+		unique[columnIdx] = candidate
+	}
+
+	return unique
+}
+
+// Run a statement that doesn't return rows (INSERT, UPDATE, DELETE, etc.) and report what it changed
+func (db *DBClient) Exec(statement string, args ...any) (result *ExecResult, err error) {
+	return db.ExecContext(db.ctx, statement, args...)
+}
+
+// Same as Exec, but the statement can be cancelled or given a deadline through ctx
+func (db *DBClient) ExecContext(ctx context.Context, statement string, args ...any) (result *ExecResult, err error) {
+	defer db.recordHistory(statement, time.Now(), &err)
+	defer db.logQuery(statement, args, time.Now(), &err)
+	if err = db.assertStatementAllowed(statement); err != nil {
+		return nil, err
 	}
 
-	// Transform each row into a map of column -> value
-	mappedRows := make([]map[string]*NullString, len(rawRows))
-	for rowIdx := range rawRows {
-		rawRow := rawRows[rowIdx]
-		mappedRow := make(map[string]*NullString, len(rawRow))
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, db.explainQueryTimeout(ctx, err)) }()
 
-		for columnIdx, columnValue := range rawRow {
-			columnName := columns[columnIdx]
-			mappedRow[columnName] = &columnValue
+	if db.shouldDryRun(statement) {
+		rowsEstimate, err := db.dryRunStatement(ctx, statement, args)
+		if err != nil {
+			return nil, err
 		}
+		return &ExecResult{RowsAffected: rowsEstimate, DryRun: true}, nil
+	}
 
-		mappedRows[rowIdx] = mappedRow
+	conn, err := db.getConnection(ctx)
+	if err != nil {
+		return nil, err
 	}
+	db.invalidateCachedServerInfo(statement)
+	db.resultCache.invalidateFor(statement)
+	db.prepareWarnings()
 
-	return &QueryResult{
-		Rows:    mappedRows,
-		Columns: columns,
-	}, err
+	sqlResult, err := conn.ExecContext(ctx, statement, args...)
+	if err != nil {
+		return nil, errors.Join(
+			ErrExecFailed,
+			asQueryError(err),
+		)
+	}
+
+	result, err = newExecResult(sqlResult)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = db.statementWarnings(ctx, conn)
+
+	return result, nil
+}
+
+func newExecResult(sqlResult sql.Result) (result *ExecResult, err error) {
+	rowsAffected, err := sqlResult.RowsAffected()
+	if err != nil {
+		return nil, errors.Join(
+			errors.New("Could not determine rows affected"),
+			err,
+		)
+	}
+
+	result = &ExecResult{
+		RowsAffected: rowsAffected,
+	}
+
+	// Not every driver supports this (i.e. PostgreSQL), only surface it when available
+	lastInsertId, err := sqlResult.LastInsertId()
+	if err == nil {
+		result.LastInsertId = &lastInsertId
+	}
+
+	return result, nil
+}
+
+// Stop reading results after maxRows, so a query without a LIMIT can't exhaust memory
+// Results that were cut short have Truncated set, 0 means no limit
+// Applies to Query, QueryMulti, prepared statements and transactions, QueryStream reads everything
+func (db *DBClient) SetMaxRows(maxRows int) {
+	db.maxRows = max(maxRows, 0)
+}
+
+// Render NULL values as display in results from this client, see QueryResult.SetNullDisplay
+func (db *DBClient) SetNullDisplay(display string) {
+	db.nullDisplay = &display
+}
+
+// Get notified whenever a dropped connection is discarded and a new one is opened, i.e. to show "reconnecting..."
+// fn receives why the connection was dropped (wrapping ErrConnectionLost), nil fn removes the hook
+func (db *DBClient) SetOnReconnect(fn func(err error)) {
+	db.onReconnect = fn
+}
+
+// Get told how many rows Query has read so far while it scans a large result, i.e. to show "scanned 50,000 rows..."
+// fn is called every 1000 rows, on the goroutine running the query and never after Query returns
+// It holds up the scan while it runs, so keep it quick, nil fn removes the hook
+func (db *DBClient) SetOnProgress(fn func(rowsScanned int)) {
+	db.onProgress = fn
+}
+
+// Check the database is still reachable, reconnecting if the connection was dropped
+// Returns nil when the database is reachable
+func (db *DBClient) Healthy(ctx context.Context) error {
+	if db.pooled() {
+		db.connMu.RLock()
+		defer db.connMu.RUnlock()
+
+		// Any connection it had to open gets the session init, same as for queries
+		if err := db.sqlDB.PingContext(ctx); err != nil {
+			return errors.Join(
+				ErrConnectionFailed,
+				err,
+			)
+		}
+		return nil
+	}
+
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	_, err := db.getConnection(ctx)
+	return err
 }
 
 // We try to use a single connection, instantiated when DBClient is instantiated
 // This will either return that existing connection, or create a new one if that got dropped
-func (db *DBClient) getConnection() (*sqlx.Conn, error) {
+// In pool mode, this is the connection checked out for the query running under ctx instead
+func (db *DBClient) getConnection(ctx context.Context) (*sqlx.Conn, error) {
+	if db.pooled() {
+		return db.getPooledConnection(ctx)
+	}
+	if err := db.assertNoOpenQuery(); err != nil {
+		return nil, err
+	}
+
+	// Why the previous connection was dropped, if it was
+	var connectionLost error
+
+	// database/sql only recycles connections returned to the pool, not the one we hold on to
+	// Never in the middle of a transaction though, it would be lost along with the connection
+	connExpired := db.connMaxLifetime > 0 && time.Since(db.connOpenedAt) >= db.connMaxLifetime
+	if db._conn != nil && db._tx == nil && connExpired {
+		db.stmtCache.clear()
+		_ = discardConn(db._conn)
+		db._conn = nil
+	}
+
 	if db._conn != nil {
 		// See if our existing connection is still alive
-		err := db._conn.PingContext(db.ctx)
+		err := db._conn.PingContext(ctx)
 		if err == nil {
 			return db._conn, nil
 		}
-		db._conn.Close()
+
+		// The caller giving up doesn't mean the connection is dead
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.Join(
+				ErrConnectionFailed,
+				ctxErr,
+			)
+		}
+
+		// Statements were prepared on the dropped connection, so they're no longer usable
+		db.stmtCache.clear()
+		// Closing would hand the dead connection back to the pool, and if reconnecting fails
+		// the next call would ping it again, reporting it as closed rather than why it dropped
+		_ = discardConn(db._conn)
+		db._conn = nil
+
+		connectionLost = errors.Join(ErrConnectionLost, err)
+		if db.onReconnect != nil {
+			db.onReconnect(connectionLost)
+		}
 	}
 
-	conn, err := db.sqlDB.Connx(db.ctx)
+	conn, err := db.sqlDB.Connx(ctx)
 
 	if err != nil {
+		// Include why we were reconnecting, i.e. to tell a network blip apart from changed credentials
 		return nil, errors.Join(
-			errors.New("Failed to get connection to database"),
+			ErrConnectionFailed,
 			err,
+			connectionLost,
 		)
 	}
 
-	if db.connManager.IsSafeMode() {
-		_, err = conn.ExecContext(db.ctx, "SET SQL_SAFE_UPDATES = 1")
+	for _, statement := range db.sessionInit {
+		startedAt := time.Now()
+		_, err = conn.ExecContext(ctx, statement)
+		db.logQuery(statement, nil, startedAt, &err)
 		if err != nil {
+			// Don't hand a half initialized session back to the pool
+			_ = discardConn(conn)
+			return nil, errors.Join(
+				fmt.Errorf("Failed to initialize session with %q", statement),
+				err,
+			)
+		}
+	}
+
+	if len(db.searchPath) > 0 {
+		if err = db.applySearchPath(ctx, conn, db.searchPath); err != nil {
+			_ = discardConn(conn)
 			return nil, err
 		}
 	}
 
+	if db.readOnly && db.connManager.GetFlavor() == PostgreSQL {
+		if err = db.applyReadOnlySession(ctx, conn); err != nil {
+			_ = discardConn(conn)
+			return nil, errors.Join(
+				errors.New("Failed to set session to read-only"),
+				err,
+			)
+		}
+	}
+
 	db._conn = conn
+	db.connOpenedAt = time.Now()
 	return db._conn, nil
 }
@@ -3,8 +3,12 @@ package db_test
 import (
 	"context"
 	"fmt"
+	"net"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/azvaliev/sql/internal/pkg/db"
 	"github.com/stretchr/testify/assert"
@@ -54,6 +58,28 @@ func TestDBMySQLConnOptions(t *testing.T) {
 				)
 			}
 
+			// Using ServerVersion
+			{
+				version, err := dbClient.ServerVersion()
+				assert.NoError(err)
+				assert.Regexp(regexp.MustCompile(fmt.Sprint(`^`, mySQLVersion, `\.\d+`)), version)
+			}
+
+			// Using connection metadata accessors
+			{
+				databaseName, err := dbClient.CurrentDatabase()
+				assert.NoError(err)
+				assert.Equal(connOptions.DatabaseName, databaseName)
+
+				user, err := dbClient.CurrentUser()
+				assert.NoError(err)
+				assert.Equal("user@%", user)
+
+				serverHost, err := dbClient.ServerHost()
+				assert.NoError(err)
+				assert.NotEmpty(serverHost)
+			}
+
 			// Check database name setting
 			{
 				result, err := dbClient.Query("SELECT DATABASE()")
@@ -79,6 +105,676 @@ func TestDBMySQLConnOptions(t *testing.T) {
 	}
 }
 
+func TestDBMySQLReconnect(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Reconnect", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+
+			reconnectReasons := []error{}
+			dbClient.SetOnReconnect(func(err error) {
+				reconnectReasons = append(reconnectReasons, err)
+			})
+
+			// Drop our own connection out from under the client
+			_, err = dbClient.Query("KILL CONNECTION_ID()")
+			assert.Error(err)
+
+			result, err := dbClient.Query("SELECT 1 AS one")
+			assert.NoError(err)
+			assert.Equal("1", result.Rows[0]["one"].ToString())
+
+			if assert.Len(reconnectReasons, 1) {
+				assert.ErrorIs(reconnectReasons[0], db.ErrConnectionLost)
+			}
+		})
+	}
+}
+
+func TestDBMySQLRetryOnConnLoss(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Retry On Connection Loss", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			admin, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer admin.Destroy()
+
+			// Kill the client's connection while its query is still running
+			runKilled := func(dbClient *db.DBClient, statement string) (*db.QueryResult, error) {
+				result, err := dbClient.Query("SELECT CONNECTION_ID() AS id")
+				assert.NoError(err)
+				connectionID := result.Rows[0]["id"].String
+
+				go func() {
+					time.Sleep(200 * time.Millisecond)
+					_, _ = admin.Exec(fmt.Sprint("KILL CONNECTION ", connectionID))
+				}()
+
+				return dbClient.Query(statement)
+			}
+
+			clientOptions := db.DefaultDBClientOptions()
+			clientOptions.RetryOnConnLoss = true
+			dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			result, err := runKilled(dbClient, "SELECT SLEEP(1) AS slept")
+			assert.NoError(err)
+			if assert.NotNil(result) {
+				assert.Equal("0", result.Rows[0]["slept"].String)
+			}
+
+			// Only read statements are re-run
+			_, err = runKilled(dbClient, "DO SLEEP(1)")
+			assert.ErrorIs(err, db.ErrQueryFailed)
+
+			// Nor when it's disabled
+			withoutRetry, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer withoutRetry.Destroy()
+
+			_, err = runKilled(withoutRetry, "SELECT SLEEP(1) AS slept")
+			assert.ErrorIs(err, db.ErrQueryFailed)
+
+			// Statement errors aren't mistaken for connection loss
+			_, err = dbClient.Query("SELECT * FROM missing_table")
+			assert.ErrorIs(err, db.ErrQueryFailed)
+			assert.ErrorContains(err, "missing_table")
+		})
+	}
+}
+
+func TestDBMySQLConcurrentQueries(t *testing.T) {
+	const concurrency = 50
+
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Concurrent Queries", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			_, err = dbClient.Exec("CREATE TABLE events (id INT AUTO_INCREMENT PRIMARY KEY, name TEXT)")
+			assert.NoError(err)
+
+			// Interleaved statements on the one connection would fail with "commands out of sync"
+			var wg sync.WaitGroup
+			for idx := range concurrency {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					if idx%2 == 0 {
+						_, err := dbClient.Exec("INSERT INTO events (name) VALUES (?)", fmt.Sprint("event ", idx))
+						assert.NoError(err)
+						return
+					}
+
+					result, err := dbClient.Query("SELECT ? AS idx, SLEEP(0.01) AS slept, name FROM events", idx)
+					if assert.NoError(err) {
+						for _, row := range result.Rows {
+							assert.Equal(fmt.Sprint(idx), row["idx"].String)
+							assert.True(strings.HasPrefix(row["name"].String, "event "))
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			result, err := dbClient.Query("SELECT COUNT(*) AS count FROM events")
+			assert.NoError(err)
+			assert.Equal(fmt.Sprint(concurrency/2), result.Rows[0]["count"].String)
+		})
+	}
+}
+
+// Doesn't need a container, the server never gets past accepting the connection
+func TestDBConnectTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+
+	// Accept connections but never send the handshake, like a server that's hung
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	connOptions := db.DBConnOptions{
+		Flavor: db.MySQL,
+		Host:   "127.0.0.1",
+		User:   "user",
+		Port:   uint(listener.Addr().(*net.TCPAddr).Port),
+	}
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.PingAttempts = 1
+	clientOptions.ConnectTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	_, err = db.CreateDBClientWithOptions(&connOptions, clientOptions)
+	elapsed := time.Since(start)
+
+	assert.ErrorIs(err, db.ErrConnectionFailed)
+	assert.ErrorContains(err, "Connection timed out after 100ms")
+	assert.Less(elapsed, 5*time.Second)
+
+	err = db.TestConnection(&connOptions, 100*time.Millisecond)
+	assert.ErrorIs(err, db.ErrConnectionFailed)
+	assert.ErrorContains(err, "Connection timed out after 100ms")
+}
+
+func TestDBCreateDBClientContext(t *testing.T) {
+	assert := assert.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+
+	// Accept connections but never send the handshake, so only cancelling ends the attempt
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	connOptions := db.DBConnOptions{
+		Flavor: db.MySQL,
+		Host:   "127.0.0.1",
+		User:   "user",
+		Port:   uint(listener.Addr().(*net.TCPAddr).Port),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = db.CreateDBClientContext(ctx, &connOptions)
+	elapsed := time.Since(start)
+
+	assert.ErrorIs(err, db.ErrConnectionFailed)
+	assert.ErrorIs(err, context.Canceled)
+	assert.Less(elapsed, 5*time.Second)
+}
+
+func TestDBMySQLWarnings(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Warnings", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			dbClient.SetCollectWarnings(true)
+
+			// Implicit conversion of a non-numeric string
+			result, err := dbClient.Query("SELECT CAST('12abc' AS SIGNED) AS converted")
+			assert.NoError(err)
+			if assert.Len(result.Warnings, 1) {
+				assert.Contains(result.Warnings[0], "Truncated incorrect INTEGER value")
+			}
+
+			result, err = dbClient.Query("SELECT 1 AS one")
+			assert.NoError(err)
+			assert.Empty(result.Warnings)
+		})
+	}
+}
+
+func TestDBMySQLQueryError(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Query Error", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			_, err = dbClient.Exec("CREATE TABLE users (email VARCHAR(255) PRIMARY KEY)")
+			assert.NoError(err)
+			_, err = dbClient.Exec("INSERT INTO users VALUES ('a@example.com'), ('a@example.com')")
+			assert.ErrorIs(err, db.ErrExecFailed)
+
+			var queryErr *db.QueryError
+			if assert.ErrorAs(err, &queryErr) {
+				assert.Equal("1062", queryErr.Code)
+				assert.Equal("23000", queryErr.SQLState)
+				assert.Contains(queryErr.Message, "Duplicate entry")
+			}
+		})
+	}
+}
+
+func TestDBMySQLStatementTimeout(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Statement Timeout", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			clientOptions := db.DefaultDBClientOptions()
+			clientOptions.StatementTimeout = 100 * time.Millisecond
+
+			dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			result, err := dbClient.Query("SELECT @@SESSION.max_execution_time AS timeout")
+			assert.NoError(err)
+			assert.Equal("100", result.Rows[0]["timeout"].String)
+		})
+	}
+}
+
+func TestDBMySQLListDatabases(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - List Databases", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			databases, err := dbClient.ListDatabases()
+			assert.NoError(err)
+			assert.Contains(databases, "test")
+			assert.Contains(databases, "information_schema")
+
+			_, err = dbClient.ListSchemas()
+			assert.ErrorContains(err, "Listing schemas not supported for MySQL")
+		})
+	}
+}
+
+func TestDBMySQLReadOnly(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+		AdditionalOptions: map[string]string{
+			"multiStatements": "true",
+		},
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Read Only", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			_, err = dbClient.Exec("CREATE TABLE users (name TEXT)")
+			assert.NoError(err)
+			_, err = dbClient.Exec("INSERT INTO users VALUES ('alice')")
+			assert.NoError(err)
+
+			assert.NoError(dbClient.SetReadOnly(true))
+
+			// Both would delete the row if sent
+			_, err = dbClient.Exec("SELECT 1; DELETE FROM users")
+			assert.EqualError(err, "read-only mode: refusing to run DELETE")
+			_, err = dbClient.Query("EXPLAIN ANALYZE DELETE FROM users")
+			assert.EqualError(err, "read-only mode: refusing to run EXPLAIN ANALYZE")
+
+			result, err := dbClient.Query("SELECT COUNT(*) AS count FROM users")
+			assert.NoError(err)
+			assert.Equal("1", result.Rows[0]["count"].String)
+		})
+	}
+}
+
+func TestDBMySQLSwitchDatabase(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Switch Database", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			assert.NoError(dbClient.SwitchDatabase("information_schema"))
+			assert.Equal("information_schema", connOptions.DatabaseName)
+
+			currentDatabase, err := dbClient.CurrentDatabase()
+			assert.NoError(err)
+			assert.Equal("information_schema", currentDatabase)
+
+			// Stays on the current database if the switch fails
+			err = dbClient.SwitchDatabase("missing")
+			assert.ErrorContains(err, `Failed to switch to database "missing"`)
+			assert.Equal("information_schema", connOptions.DatabaseName)
+
+			currentDatabase, err = dbClient.CurrentDatabase()
+			assert.NoError(err)
+			assert.Equal("information_schema", currentDatabase)
+		})
+	}
+}
+
+func TestDBMySQLQueryMulti(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Multiple Result Sets", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			_, err = dbClient.Exec(`
+				CREATE PROCEDURE report()
+				BEGIN
+					SELECT 2 AS total;
+					SELECT 'a' AS item UNION ALL SELECT 'b';
+				END
+			`)
+			assert.NoError(err)
+
+			results, err := dbClient.QueryMulti("CALL report()")
+			assert.NoError(err)
+			if assert.Len(results, 2) {
+				assert.Equal("2", results[0].Rows[0]["total"].String)
+				assert.Len(results[1].Rows, 2)
+				assert.Equal("b", results[1].Rows[1]["item"].String)
+				// Neither result set is the CALL itself
+				assert.Empty(results[0].StmtType)
+				assert.Empty(results[1].StmtType)
+			}
+
+			// Query still returns only the first
+			result, err := dbClient.Query("CALL report()")
+			assert.NoError(err)
+			assert.Equal([]string{"total"}, result.Columns)
+		})
+	}
+}
+
+func TestDBMySQLConfirmFunc(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "buser",
+		Password:     "password",
+		Port:         3306,
+		SafeMode:     true,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - ConfirmFunc", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			var asked []string
+			confirm := false
+			dbClient.SetConfirmFunc(func(statement string) bool {
+				asked = append(asked, statement)
+				return confirm
+			})
+
+			_, err = dbClient.Exec("CREATE TABLE users (id INT PRIMARY KEY, name TEXT)")
+			assert.NoError(err)
+			_, err = dbClient.Exec("INSERT INTO users VALUES (1, 'alice'), (2, 'bob')")
+			assert.NoError(err)
+			_, err = dbClient.Exec("DELETE FROM users WHERE id = 2")
+			assert.NoError(err)
+			assert.Empty(asked)
+
+			// Declined, so the table is left alone
+			_, err = dbClient.Exec("TRUNCATE users")
+			assert.ErrorIs(err, db.ErrNotConfirmed)
+			_, err = dbClient.Query("DROP TABLE users")
+			assert.ErrorIs(err, db.ErrNotConfirmed)
+			assert.Equal([]string{"TRUNCATE users", "DROP TABLE users"}, asked)
+
+			// Asked each time a prepared statement runs, preparing it doesn't count as confirming
+			confirm = true
+			deleteStmt, err := dbClient.Prepare("DELETE FROM users")
+			assert.NoError(err)
+			confirm = false
+			_, err = deleteStmt.Exec()
+			assert.ErrorIs(err, db.ErrNotConfirmed)
+
+			result, err := dbClient.Query("SELECT COUNT(*) AS total FROM users")
+			assert.NoError(err)
+			assert.Equal("1", result.Rows[0]["total"].String)
+
+			confirm = true
+			_, err = dbClient.Exec("TRUNCATE users")
+			assert.NoError(err)
+
+			result, err = dbClient.Query("SELECT COUNT(*) AS total FROM users")
+			assert.NoError(err)
+			assert.Equal("0", result.Rows[0]["total"].String)
+		})
+	}
+}
+
+func TestDBMySQLBoolDisplay(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "buser",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Bool display", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			_, err = dbClient.Exec("CREATE TABLE flags (id INT PRIMARY KEY, enabled BOOLEAN, level TINYINT)")
+			assert.NoError(err)
+			_, err = dbClient.Exec("INSERT INTO flags VALUES (1, true, 1), (2, false, 7)")
+			assert.NoError(err)
+
+			// BOOLEAN is reported as TINYINT, so it's left alone unless opted in
+			result, err := dbClient.Query("SELECT enabled, level FROM flags ORDER BY id")
+			assert.NoError(err)
+			assert.Equal("TINYINT", result.ColumnTypes[0])
+			assert.Equal("1", result.Rows[0]["enabled"].String)
+
+			dbClient.SetBoolDisplay(db.BoolDisplay{True: "true", False: "false", MySQLTinyInt: true})
+			result, err = dbClient.Query("SELECT enabled, level FROM flags ORDER BY id")
+			assert.NoError(err)
+			assert.Equal("true", result.Rows[0]["enabled"].String)
+			assert.Equal("1", result.Rows[0]["enabled"].RawValue())
+			assert.Equal("false", result.Rows[1]["enabled"].String)
+			assert.Equal("true", result.Rows[0]["level"].String)
+			// Not a 0 or 1, so clearly not a boolean
+			assert.Equal("7", result.Rows[1]["level"].String)
+		})
+	}
+}
+
 func TestDBMySQLDescribe(t *testing.T) {
 	connOptions := db.DBConnOptions{
 		Flavor:       db.MySQL,
@@ -171,3 +867,93 @@ func TestDBMySQLDescribe(t *testing.T) {
 		})
 	}
 }
+
+func TestDBMySQLCharset(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "buser",
+		Password:     "password",
+		Port:         3306,
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - Charset", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			row, err := dbClient.QueryRow("SELECT @@character_set_connection AS charset, @@collation_connection AS collation")
+			assert.NoError(err)
+			assert.Equal(db.DefaultMySQLCharset, row["charset"].String)
+			assert.Equal(db.DefaultMySQLCollation, row["collation"].String)
+
+			_, err = dbClient.Exec("CREATE TABLE notes (body TEXT) CHARACTER SET utf8mb4")
+			assert.NoError(err)
+			_, err = dbClient.Exec("INSERT INTO notes VALUES (?)", "Crème brûlée 🍮")
+			assert.NoError(err)
+
+			body, err := dbClient.QueryScalar("SELECT body FROM notes")
+			assert.NoError(err)
+			assert.Equal("Crème brûlée 🍮", body.String)
+
+			latin1Options := connOptions
+			latin1Options.Charset = "latin1"
+			latin1Client, err := db.CreateDBClient(&latin1Options)
+			assert.NoError(err)
+			defer latin1Client.Destroy()
+
+			charset, err := latin1Client.QueryScalar("SELECT @@character_set_connection")
+			assert.NoError(err)
+			assert.Equal("latin1", charset.String)
+		})
+	}
+}
+
+func TestDBMySQLAppName(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.MySQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "buser",
+		Password:     "password",
+		Port:         3306,
+		AppName:      "redline",
+	}
+
+	for _, mySQLVersion := range TESTED_MYSQL_VERSIONS {
+		t.Run(fmt.Sprintf("MySQL %s - App name", mySQLVersion), func(t *testing.T) {
+			mySQLVersion := mySQLVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initMySQLTestDB(&InitTestDBOptions{mySQLVersion, &connOptions}, ctx)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			programName, err := dbClient.QueryScalar(`
+				SELECT ATTR_VALUE FROM performance_schema.session_connect_attrs
+				WHERE PROCESSLIST_ID = CONNECTION_ID() AND ATTR_NAME = 'program_name'
+			`)
+			assert.NoError(err)
+			if assert.NotNil(programName) {
+				assert.Equal("redline", programName.String)
+			}
+		})
+	}
+}
@@ -2,9 +2,13 @@ package db_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/azvaliev/sql/internal/pkg/db"
 	"github.com/stretchr/testify/assert"
@@ -53,6 +57,28 @@ func TestDBPostgresConnOptions(t *testing.T) {
 				)
 			}
 
+			// Using ServerVersion
+			{
+				version, err := dbClient.ServerVersion()
+				assert.NoError(err)
+				assert.Regexp(regexp.MustCompile(fmt.Sprint(`^`, postgresVersion, `\.\d+`)), version)
+			}
+
+			// Using connection metadata accessors
+			{
+				databaseName, err := dbClient.CurrentDatabase()
+				assert.NoError(err)
+				assert.Equal(connOptions.DatabaseName, databaseName)
+
+				user, err := dbClient.CurrentUser()
+				assert.NoError(err)
+				assert.Equal("user", user)
+
+				serverHost, err := dbClient.ServerHost()
+				assert.NoError(err)
+				assert.NotEmpty(serverHost)
+			}
+
 			// Check database name setting
 			{
 				result, err := dbClient.Query("SELECT current_database()")
@@ -67,6 +93,628 @@ func TestDBPostgresConnOptions(t *testing.T) {
 	}
 }
 
+func TestDBPostgresSafeMode(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+		SafeMode:     true,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Safe Mode", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+
+			result, err := dbClient.Query("SHOW default_transaction_read_only")
+			assert.NoError(err)
+			assert.Len(result.Rows, 1)
+			assert.Equal("on", result.Rows[0]["default_transaction_read_only"].ToString())
+
+			_, err = dbClient.Query("CREATE TABLE test (id int)")
+			assert.ErrorContains(err, "read-only transaction")
+		})
+	}
+}
+
+func TestDBPostgresExplainJSON(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - EXPLAIN JSON", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+
+			result, err := dbClient.ExplainWithOptions(ctx, "SELECT 1", db.ExplainOptions{Analyze: true, JSON: true})
+			assert.NoError(err)
+
+			var plan []map[string]any
+			assert.NoError(json.Unmarshal([]byte(result.PlanJSON), &plan))
+			if assert.Len(plan, 1) {
+				assert.Contains(plan[0], "Plan")
+				assert.Contains(plan[0], "Execution Time")
+			}
+		})
+	}
+}
+
+func TestDBPostgresNotices(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Notices", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+			dbClient.SetCollectWarnings(true)
+
+			execResult, err := dbClient.Exec("DO $$ BEGIN RAISE NOTICE 'heads up'; END $$")
+			assert.NoError(err)
+			assert.Equal([]string{"NOTICE: heads up"}, execResult.Warnings)
+
+			// Notices from earlier statements aren't carried over
+			result, err := dbClient.Query("SELECT 1 AS one")
+			assert.NoError(err)
+			assert.Empty(result.Warnings)
+		})
+	}
+}
+
+func TestDBPostgresListen(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Listen", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			notifications, err := dbClient.Listen("Job Updates")
+			assert.NoError(err)
+
+			_, err = dbClient.Listen("Job Updates")
+			assert.ErrorContains(err, "Already listening")
+
+			// Queries aren't held up by the listener waiting
+			_, err = dbClient.Exec(`NOTIFY "Job Updates", 'job 1 done'`)
+			assert.NoError(err)
+
+			select {
+			case notification := <-notifications:
+				assert.Equal(db.Notification{Channel: "Job Updates", Payload: "job 1 done"}, notification)
+			case <-time.After(5 * time.Second):
+				assert.Fail("Notification wasn't delivered")
+			}
+
+			assert.NoError(dbClient.Unlisten("Job Updates"))
+			_, open := <-notifications
+			assert.False(open)
+			assert.Error(dbClient.Unlisten("Job Updates"))
+
+			// Cancelling the context stops notifications as well
+			listenCtx, cancel := context.WithCancel(ctx)
+			notifications, err = dbClient.ListenContext(listenCtx, "Job Updates")
+			assert.NoError(err)
+			cancel()
+
+			select {
+			case _, open := <-notifications:
+				assert.False(open)
+			case <-time.After(5 * time.Second):
+				assert.Fail("Notifications weren't closed")
+			}
+		})
+	}
+}
+
+func TestDBPostgresQueryError(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Query Error", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			_, err = dbClient.Exec("CREATE TABLE users (email TEXT PRIMARY KEY)")
+			assert.NoError(err)
+			_, err = dbClient.Exec("INSERT INTO users VALUES ('a@example.com'), ('a@example.com')")
+			assert.ErrorIs(err, db.ErrExecFailed)
+
+			var queryErr *db.QueryError
+			if assert.ErrorAs(err, &queryErr) {
+				assert.Equal("23505", queryErr.Code)
+				assert.Equal("23505", queryErr.SQLState)
+				assert.Contains(queryErr.Message, "duplicate key")
+			}
+
+			// Raised while reading rows rather than when the query is sent
+			_, err = dbClient.Query("SELECT 1 / (3 - x) AS y FROM generate_series(1, 3) AS x")
+			if assert.ErrorAs(err, &queryErr) {
+				assert.Equal("22012", queryErr.SQLState)
+			}
+		})
+	}
+}
+
+func TestDBPostgresStatementTimeout(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Statement Timeout", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			clientOptions := db.DefaultDBClientOptions()
+			clientOptions.StatementTimeout = 100 * time.Millisecond
+
+			dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			result, err := dbClient.Query("SHOW statement_timeout")
+			assert.NoError(err)
+			assert.Equal("100ms", result.Rows[0]["statement_timeout"].String)
+
+			_, err = dbClient.Query("SELECT pg_sleep(1)")
+			assert.ErrorIs(err, db.ErrQueryFailed)
+			assert.ErrorContains(err, "statement timeout")
+		})
+	}
+}
+
+func TestDBPostgresSwitchDatabase(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Switch Database", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			_, err = dbClient.Exec("CREATE DATABASE reporting")
+			assert.NoError(err)
+
+			assert.NoError(dbClient.SwitchDatabase("reporting"))
+			assert.Equal("reporting", connOptions.DatabaseName)
+
+			result, err := dbClient.Query("SELECT current_database() AS name")
+			assert.NoError(err)
+			assert.Equal("reporting", result.Rows[0]["name"].String)
+
+			err = dbClient.SwitchDatabase("missing")
+			assert.ErrorContains(err, `Failed to switch to database "missing"`)
+			assert.Equal("reporting", connOptions.DatabaseName)
+
+			result, err = dbClient.Query("SELECT current_database() AS name")
+			assert.NoError(err)
+			assert.Equal("reporting", result.Rows[0]["name"].String)
+		})
+	}
+}
+
+func TestDBPostgresSearchPath(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+		SearchPath:   []string{"tenant_42", "public"},
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Search Path", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			searchPath := func() string {
+				result, err := dbClient.Query("SHOW search_path")
+				assert.NoError(err)
+				return result.Rows[0]["search_path"].String
+			}
+			assert.Equal("tenant_42, public", searchPath())
+
+			for _, statement := range []string{
+				"CREATE SCHEMA tenant_42",
+				"CREATE TABLE tenant_42.accounts (name TEXT)",
+				"INSERT INTO tenant_42.accounts VALUES ('acme')",
+				`CREATE SCHEMA "Tenant B"`,
+				`CREATE TABLE "Tenant B".accounts (name TEXT)`,
+				`INSERT INTO "Tenant B".accounts VALUES ('globex')`,
+			} {
+				_, err = dbClient.Exec(statement)
+				assert.NoError(err)
+			}
+
+			result, err := dbClient.Query("SELECT name FROM accounts")
+			assert.NoError(err)
+			assert.Equal("acme", result.Rows[0]["name"].String)
+
+			// Drop our own connection, the new one should start with the same search_path
+			_, err = dbClient.Query("SELECT pg_terminate_backend(pg_backend_pid())")
+			assert.Error(err)
+			assert.Equal("tenant_42, public", searchPath())
+
+			assert.NoError(dbClient.SetSearchPath("Tenant B"))
+			assert.Equal([]string{"Tenant B"}, dbClient.SearchPath())
+			assert.Equal(`"Tenant B"`, searchPath())
+
+			result, err = dbClient.Query("SELECT name FROM accounts")
+			assert.NoError(err)
+			assert.Equal("globex", result.Rows[0]["name"].String)
+
+			assert.NoError(dbClient.SetSearchPath())
+			assert.Equal(`"$user", public`, searchPath())
+
+			assert.Error(dbClient.SetSearchPath(""))
+			assert.Empty(dbClient.SearchPath())
+		})
+	}
+}
+
+func TestDBPostgresAppName(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+		AppName:      "redline dev",
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - App Name", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			appName, err := dbClient.QueryScalar("SELECT application_name FROM pg_stat_activity WHERE pid = pg_backend_pid()")
+			assert.NoError(err)
+			assert.Equal("redline dev", appName.String)
+		})
+	}
+}
+
+func TestDBPostgresCopyOut(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - COPY Out", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+			defer dbClient.Destroy()
+
+			const exportQuery = `
+				SELECT id, CASE WHEN id = 2 THEN NULL ELSE 'row, ' || id END AS label
+				FROM generate_series(1, 3) AS id
+				ORDER BY id -- trailing comment
+			`
+
+			var copied strings.Builder
+			assert.NoError(dbClient.CopyOut(exportQuery, &copied))
+
+			// Same output as the generic export through Query
+			result, err := dbClient.Query(exportQuery)
+			assert.NoError(err)
+			var scanned strings.Builder
+			assert.NoError(result.WriteCSV(&scanned))
+
+			assert.Equal("id,label\n1,\"row, 1\"\n2,\n3,\"row, 3\"\n", copied.String())
+			assert.Equal(scanned.String(), copied.String())
+
+			err = dbClient.CopyOut("DELETE FROM users", &copied)
+			assert.ErrorContains(err, "Only SELECT queries can be exported")
+		})
+	}
+}
+
+// Compare COPY against scanning each row into a result, run with -bench CopyOut
+func BenchmarkDBPostgresCopyOut(b *testing.B) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	ctx := context.Background()
+	container, err := initPostgresTestDB(
+		&InitTestDBOptions{TESTED_POSTGRES_VERSIONS[len(TESTED_POSTGRES_VERSIONS)-1], &connOptions},
+		ctx,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer createTestDBCleanup(ctx, container)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	const exportQuery = "SELECT id, md5(id::text) AS hash, now() AS exported_at FROM generate_series(1, 100000) AS id"
+
+	b.Run("COPY", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := dbClient.CopyOut(exportQuery, io.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Query and WriteCSV", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			result, err := dbClient.Query(exportQuery)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := result.WriteCSV(io.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDBPostgresSchemaIntrospection(t *testing.T) {
+	connOptions := db.DBConnOptions{
+		Flavor:       db.PostgreSQL,
+		Host:         "localhost",
+		DatabaseName: "test",
+		User:         "user",
+		Password:     "password",
+		Port:         5432,
+	}
+
+	for _, postgresVersion := range TESTED_POSTGRES_VERSIONS {
+		t.Run(fmt.Sprintf("PostgreSQL %s - Schema Introspection", postgresVersion), func(t *testing.T) {
+			postgresVersion := postgresVersion
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			container, err := initPostgresTestDB(
+				&InitTestDBOptions{postgresVersion, &connOptions},
+				ctx,
+			)
+			assert.NoError(err)
+
+			defer createTestDBCleanup(ctx, container)
+
+			dbClient, err := db.CreateDBClient(&connOptions)
+			assert.NoError(err)
+
+			_, err = dbClient.RunScript(`
+				CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT NOT NULL UNIQUE);
+				CREATE SCHEMA other;
+				CREATE TABLE other.things (id INTEGER, name VARCHAR(32));
+			`)
+			assert.NoError(err)
+
+			// Schemas outside the search_path aren't merged in
+			tables, err := dbClient.ListTables()
+			assert.NoError(err)
+			assert.Equal([]string{"users"}, tables)
+
+			columns, err := dbClient.DescribeTable("users")
+			assert.NoError(err)
+			if assert.Len(columns, 2) {
+				assert.Equal("id", columns[0].Name)
+				assert.Equal("integer", columns[0].Type)
+				assert.Equal("PRI", columns[0].Key)
+				assert.False(columns[0].Nullable)
+				if assert.NotNil(columns[0].Default) {
+					assert.Equal("nextval('users_id_seq'::regclass)", *columns[0].Default)
+				}
+
+				assert.Equal("email", columns[1].Name)
+				assert.Equal("UNI", columns[1].Key)
+				assert.Nil(columns[1].Default)
+			}
+
+			// Qualified names reach other schemas, nullable is read regardless of how booleans are displayed
+			dbClient.SetBoolDisplay(db.BoolDisplay{True: "yes", False: "no"})
+			columns, err = dbClient.DescribeTable("other.things")
+			assert.NoError(err)
+			if assert.Len(columns, 2) {
+				assert.Equal("character varying(32)", columns[1].Type)
+				assert.True(columns[1].Nullable)
+			}
+
+			_, err = dbClient.Exec("SET search_path TO other, public")
+			assert.NoError(err)
+
+			tables, err = dbClient.ListTables()
+			assert.NoError(err)
+			assert.Equal([]string{"things", "users"}, tables)
+
+			// Templates can't be connected to, so they're left out
+			databases, err := dbClient.ListDatabases()
+			assert.NoError(err)
+			assert.Contains(databases, "test")
+			assert.NotContains(databases, "template0")
+
+			schemas, err := dbClient.ListSchemas()
+			assert.NoError(err)
+			assert.Equal([]string{"other", "public"}, schemas)
+		})
+	}
+}
+
 func TestDBPostgresDescribe(t *testing.T) {
 	connOptions := db.DBConnOptions{
 		Flavor:       db.PostgreSQL,
@@ -0,0 +1,3103 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// SQLite runs in process, so no container is needed, just a scratch file
+func newSQLiteConnOptions(t *testing.T) db.DBConnOptions {
+	return db.DBConnOptions{
+		Flavor:       db.SQLite,
+		DatabaseName: filepath.Join(t.TempDir(), "test.db"),
+		SafeMode:     true,
+	}
+}
+
+func TestDBSQLiteConnection(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+
+	result, err := dbClient.Query("SELECT 1 AS one")
+	assert.NoError(err)
+	assert.Equal([]string{"one"}, result.Columns)
+	assert.Len(result.Rows, 1)
+	assert.Equal("1", result.Rows[0]["one"].ToString())
+
+	assert.NoError(dbClient.Destroy())
+}
+
+func TestDBSQLiteDestroyBeforeQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+
+	assert.NotPanics(func() {
+		assert.NoError(dbClient.Destroy())
+	})
+}
+
+func TestDBSQLiteQueryParams(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Query("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(err)
+
+	_, err = dbClient.Query("INSERT INTO users (id, name) VALUES (?, ?), (?, ?)", 1, "alice", 2, "bob'; DROP TABLE users; --")
+	assert.NoError(err)
+
+	// Values should be bound, not interpolated
+	result, err := dbClient.Query("SELECT name FROM users WHERE id = ?", 2)
+	assert.NoError(err)
+	assert.Len(result.Rows, 1)
+	assert.Equal("bob'; DROP TABLE users; --", result.Rows[0]["name"].ToString())
+
+	// No args should behave like a plain query
+	result, err = dbClient.Query("SELECT COUNT(*) AS total FROM users")
+	assert.NoError(err)
+	assert.Equal("2", result.Rows[0]["total"].ToString())
+}
+
+func TestDBSQLiteNoResultSet(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	result, err := dbClient.Query("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(err)
+	if assert.NotNil(result) {
+		assert.True(result.NoResultSet)
+		assert.Empty(result.Columns)
+		assert.NotNil(result.Rows)
+	}
+
+	// Selecting nothing is still a result set
+	result, err = dbClient.Query("SELECT id, name FROM users")
+	assert.NoError(err)
+	if assert.NotNil(result) {
+		assert.False(result.NoResultSet)
+		assert.Equal([]string{"id", "name"}, result.Columns)
+		assert.NotNil(result.Rows)
+		assert.Empty(result.Rows)
+	}
+
+	tx, err := dbClient.BeginTx(context.Background())
+	assert.NoError(err)
+	result, err = tx.Query("INSERT INTO users (name) VALUES ('alice')")
+	assert.NoError(err)
+	if assert.NotNil(result) {
+		assert.True(result.NoResultSet)
+	}
+	assert.NoError(tx.Commit())
+}
+
+func TestDBSQLiteColumnTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Query("CREATE TABLE events (id INTEGER, name TEXT, happened_at DATETIME)")
+	assert.NoError(err)
+
+	// Types should be known even when nothing is returned
+	result, err := dbClient.Query("SELECT id, name, happened_at FROM events")
+	assert.NoError(err)
+	assert.Empty(result.Rows)
+	assert.Equal([]string{"id", "name", "happened_at"}, result.Columns)
+	assert.Equal([]string{"INTEGER", "TEXT", "DATETIME"}, result.ColumnTypes)
+}
+
+func TestDBSQLiteQueryTiming(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	result, err := dbClient.Query(`
+		WITH RECURSIVE numbers(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM numbers WHERE n < 1000)
+		SELECT n FROM numbers
+	`)
+	assert.NoError(err)
+	assert.Len(result.Rows, 1000)
+
+	assert.Positive(result.QueryDuration)
+	assert.Positive(result.ScanDuration)
+	assert.Equal(result.QueryDuration+result.ScanDuration, result.Elapsed)
+
+	// Transactions report timing the same way
+	tx, err := dbClient.BeginTx(context.Background())
+	assert.NoError(err)
+	defer tx.Rollback()
+
+	result, err = tx.Query("SELECT 1")
+	assert.NoError(err)
+	assert.Positive(result.Elapsed)
+}
+
+func TestDBSQLiteQueryContext(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	t.Run("Runaway query is cancelled", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		// Never ending sequence of rows
+		result, err := dbClient.QueryContext(ctx, `
+			WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter)
+			SELECT x FROM counter
+		`)
+		assert.Nil(result)
+		assert.True(errors.Is(err, context.DeadlineExceeded), err)
+	})
+
+	t.Run("Client is usable after a cancelled query", func(t *testing.T) {
+		assert := assert.New(t)
+
+		result, err := dbClient.QueryContext(context.Background(), "SELECT 1 AS one")
+		assert.NoError(err)
+		assert.Equal("1", result.Rows[0]["one"].ToString())
+	})
+}
+
+func TestDBSQLiteExec(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	assert.NoError(err)
+
+	result, err := dbClient.Exec("INSERT INTO users (name) VALUES (?), (?), (?)", "alice", "bob", "carol")
+	assert.NoError(err)
+	assert.Equal(int64(3), result.RowsAffected)
+	if assert.NotNil(result.LastInsertId) {
+		assert.Equal(int64(3), *result.LastInsertId)
+	}
+
+	result, err = dbClient.Exec("UPDATE users SET name = ? WHERE id > ?", "redacted", 1)
+	assert.NoError(err)
+	assert.Equal(int64(2), result.RowsAffected)
+
+	result, err = dbClient.Exec("DELETE FROM users WHERE id = ?", 100)
+	assert.NoError(err)
+	assert.Equal(int64(0), result.RowsAffected)
+
+	_, err = dbClient.Exec("DELETE FROM missing_table")
+	assert.Error(err)
+}
+
+func TestDBSQLiteClientOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.MaxOpenConns = 4
+	clientOptions.MaxIdleConns = 2
+	clientOptions.ConnMaxLifetime = time.Hour
+
+	dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// Repeated queries should keep working on the cached connection with a larger pool
+	for range 3 {
+		result, err := dbClient.Query("SELECT 1 AS one")
+		assert.NoError(err)
+		assert.Equal("1", result.Rows[0]["one"].ToString())
+	}
+}
+
+func TestDBSQLiteTransactions(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+
+	_, err = dbClient.Exec("CREATE TABLE accounts (id INTEGER PRIMARY KEY, balance INTEGER)")
+	assert.NoError(t, err)
+
+	countAccounts := func(dbClient *db.DBClient) string {
+		result, err := dbClient.Query("SELECT COUNT(*) AS total FROM accounts")
+		assert.NoError(t, err)
+
+		return result.Rows[0]["total"].ToString()
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tx, err := dbClient.BeginTx(context.Background())
+		assert.NoError(err)
+
+		_, err = dbClient.BeginTx(context.Background())
+		assert.Error(err, "only one transaction should be allowed at a time")
+
+		result, err := tx.Exec("INSERT INTO accounts (id, balance) VALUES (?, ?), (?, ?)", 1, 100, 2, 50)
+		assert.NoError(err)
+		assert.Equal(int64(2), result.RowsAffected)
+
+		queryResult, err := tx.Query("SELECT balance FROM accounts WHERE id = ?", 1)
+		assert.NoError(err)
+		assert.Equal("100", queryResult.Rows[0]["balance"].ToString())
+
+		assert.NoError(tx.Commit())
+		assert.Equal("2", countAccounts(dbClient))
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tx, err := dbClient.BeginTx(context.Background())
+		assert.NoError(err)
+
+		_, err = tx.Exec("DELETE FROM accounts")
+		assert.NoError(err)
+
+		assert.NoError(tx.Rollback())
+		assert.Equal("2", countAccounts(dbClient))
+	})
+
+	t.Run("Destroy rolls back open transaction", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tx, err := dbClient.BeginTx(context.Background())
+		assert.NoError(err)
+
+		_, err = tx.Exec("DELETE FROM accounts")
+		assert.NoError(err)
+
+		assert.NoError(dbClient.Destroy())
+
+		// Reopen the same file and make sure nothing was deleted
+		dbClient, err = db.CreateDBClient(&connOptions)
+		assert.NoError(err)
+		defer dbClient.Destroy()
+
+		assert.Equal("2", countAccounts(dbClient))
+	})
+}
+
+func TestDBSQLiteQueryStream(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	const sequenceQuery = `
+		WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter WHERE x < ?)
+		SELECT x FROM counter
+	`
+
+	t.Run("Reads every row", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var seen []string
+		columns, err := dbClient.QueryStream(sequenceQuery, func(row map[string]*db.NullString) error {
+			seen = append(seen, row["x"].ToString())
+			return nil
+		}, 5)
+
+		assert.NoError(err)
+		assert.Equal([]string{"x"}, columns)
+		assert.Equal([]string{"1", "2", "3", "4", "5"}, seen)
+	})
+
+	t.Run("Stops on callback error", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stopErr := errors.New("stop")
+		rowCount := 0
+		_, err := dbClient.QueryStream(sequenceQuery, func(row map[string]*db.NullString) error {
+			rowCount++
+			if rowCount == 3 {
+				return stopErr
+			}
+			return nil
+		}, 1000)
+
+		assert.ErrorIs(err, stopErr)
+		assert.Equal(3, rowCount)
+
+		// Rows should be closed so the connection is free again
+		result, err := dbClient.Query("SELECT 1 AS one")
+		assert.NoError(err)
+		assert.Equal("1", result.Rows[0]["one"].ToString())
+	})
+}
+
+func TestDBSQLiteQueryRow(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, nickname TEXT);
+		INSERT INTO users (name, nickname) VALUES ('alice', NULL), ('bob', 'bobby');
+	`)
+	assert.NoError(err)
+
+	row, err := dbClient.QueryRow("SELECT name, nickname FROM users ORDER BY id")
+	assert.NoError(err)
+	assert.Len(row, 2)
+	assert.Equal("alice", row["name"].ToString())
+	assert.True(row["nickname"].IsNull())
+
+	row, err = dbClient.QueryRow("SELECT name FROM users WHERE id = ?", 100)
+	assert.NoError(err)
+	assert.Nil(row)
+
+	count, err := dbClient.QueryScalar("SELECT COUNT(*) FROM users")
+	assert.NoError(err)
+	assert.Equal("2", count.ToString())
+
+	nickname, err := dbClient.QueryScalar("SELECT nickname FROM users WHERE name = ?", "alice")
+	assert.NoError(err)
+	assert.True(nickname.IsNull())
+
+	missing, err := dbClient.QueryScalar("SELECT nickname FROM users WHERE id = ?", 100)
+	assert.NoError(err)
+	assert.Nil(missing)
+
+	_, err = dbClient.QueryScalar("UPDATE users SET nickname = 'ally' WHERE name = 'alice'")
+	assert.ErrorContains(err, "Query returned no columns")
+}
+
+func TestDBSQLiteOnProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	var progress []int
+	dbClient.SetOnProgress(func(rowsScanned int) {
+		progress = append(progress, rowsScanned)
+	})
+
+	const selectSeries = `
+		WITH RECURSIVE series(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM series WHERE n < 2500)
+		SELECT n FROM series
+	`
+	result, err := dbClient.Query(selectSeries)
+	assert.NoError(err)
+	assert.Len(result.Rows, 2500)
+	assert.Equal([]int{1000, 2000}, progress)
+
+	// Small results finish before there's anything to report
+	progress = nil
+	_, err = dbClient.Query("SELECT 1")
+	assert.NoError(err)
+	assert.Empty(progress)
+
+	dbClient.SetOnProgress(nil)
+	_, err = dbClient.Query(selectSeries)
+	assert.NoError(err)
+	assert.Empty(progress)
+}
+
+func TestDBSQLiteQueryInto(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, nickname TEXT);
+		INSERT INTO users (name, nickname) VALUES ('alice', NULL), ('bob', 'bobby');
+	`)
+	assert.NoError(t, err)
+
+	type user struct {
+		ID       int64          `db:"id"`
+		Name     string         `db:"name"`
+		Nickname sql.NullString `db:"nickname"`
+	}
+
+	t.Run("Slice", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var users []user
+		assert.NoError(dbClient.QueryInto(&users, "SELECT id, name, nickname FROM users ORDER BY id"))
+		assert.Equal([]user{
+			{ID: 1, Name: "alice"},
+			{ID: 2, Name: "bob", Nickname: sql.NullString{String: "bobby", Valid: true}},
+		}, users)
+
+		var names []string
+		assert.NoError(dbClient.QueryInto(&names, "SELECT name FROM users WHERE id > ?", 1))
+		assert.Equal([]string{"bob"}, names)
+	})
+
+	t.Run("Struct", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var bob user
+		assert.NoError(dbClient.QueryInto(&bob, "SELECT id, name, nickname FROM users WHERE name = ?", "bob"))
+		assert.Equal(user{ID: 2, Name: "bob", Nickname: sql.NullString{String: "bobby", Valid: true}}, bob)
+
+		var missing user
+		err := dbClient.QueryInto(&missing, "SELECT id, name, nickname FROM users WHERE id = ?", 100)
+		assert.ErrorIs(err, db.ErrQueryFailed)
+		assert.ErrorIs(err, sql.ErrNoRows)
+	})
+
+	t.Run("Column without a field", func(t *testing.T) {
+		var users []user
+		err := dbClient.QueryInto(&users, "SELECT id, name, nickname, 1 AS extra FROM users")
+		assert.ErrorContains(t, err, "missing destination name extra")
+	})
+
+	t.Run("Invalid dest", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var count int
+		var users []user
+		for _, dest := range []any{users, &count, nil, (*user)(nil)} {
+			err := dbClient.QueryInto(dest, "SELECT id, name, nickname FROM users")
+			assert.ErrorContains(err, "QueryInto needs a pointer to a slice or struct")
+		}
+	})
+}
+
+func TestDBSQLiteBinaryFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE files (name TEXT, content BLOB);
+		INSERT INTO files VALUES ('hello', X'00FF48656C6C6F'), ('empty', NULL);
+	`)
+	assert.NoError(err)
+
+	const selectFiles = "SELECT name, content FROM files ORDER BY name DESC"
+
+	// Raw bytes by default
+	result, err := dbClient.Query(selectFiles)
+	assert.NoError(err)
+	assert.Equal("\x00\xffHello", result.Rows[0]["content"].ToString())
+
+	dbClient.SetBinaryFormat(db.BinaryHex)
+	result, err = dbClient.Query(selectFiles)
+	assert.NoError(err)
+	assert.Equal("0x00FF48656C6C6F", result.Rows[0]["content"].ToString())
+	// Text columns and NULLs are untouched
+	assert.Equal("hello", result.Rows[0]["name"].ToString())
+	assert.True(result.Rows[1]["content"].IsNull())
+
+	var csvOut strings.Builder
+	assert.NoError(result.WriteCSV(&csvOut))
+	assert.Equal("name,content\nhello,0x00FF48656C6C6F\nempty,\n", csvOut.String())
+
+	var jsonOut strings.Builder
+	assert.NoError(result.WriteJSON(&jsonOut))
+	assert.Equal(`[{"name":"hello","content":"0x00FF48656C6C6F"},{"name":"empty","content":null}]`, jsonOut.String())
+
+	dbClient.SetBinaryFormat(db.BinaryBase64)
+	value, err := dbClient.QueryScalar("SELECT content FROM files WHERE name = 'hello'")
+	assert.NoError(err)
+	assert.Equal("AP9IZWxsbw==", value.ToString())
+}
+
+func TestDBSQLiteTypedRows(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE readings (id INTEGER, value REAL, valid BOOLEAN, taken_at DATETIME, raw BLOB, note TEXT);
+		INSERT INTO readings VALUES (1, 2.5, 1, '2024-03-01 12:30:00', X'00FF', 'ok');
+		INSERT INTO readings VALUES (2, NULL, 0, NULL, NULL, NULL);
+	`)
+	assert.NoError(err)
+
+	// Binary values are decoded whichever format they were stored in
+	dbClient.SetBinaryFormat(db.BinaryHex)
+	result, err := dbClient.Query("SELECT * FROM readings ORDER BY id")
+	assert.NoError(err)
+
+	typedRows, err := result.TypedRows()
+	assert.NoError(err)
+	if assert.Len(typedRows, 2) {
+		assert.Equal(map[string]any{
+			"id":       int64(1),
+			"value":    2.5,
+			"valid":    true,
+			"taken_at": time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+			"raw":      []byte{0x00, 0xff},
+			"note":     "ok",
+		}, typedRows[0])
+		assert.Equal(map[string]any{
+			"id":       int64(2),
+			"value":    nil,
+			"valid":    false,
+			"taken_at": nil,
+			"raw":      nil,
+			"note":     nil,
+		}, typedRows[1])
+	}
+
+	// Display values are unchanged
+	assert.Equal("0x00FF", result.Rows[0]["raw"].String)
+}
+
+func TestDBSQLiteNullDisplay(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	const selectNull = "SELECT NULL AS actual_null, 'NULL' AS null_text"
+
+	result, err := dbClient.Query(selectNull)
+	assert.NoError(err)
+	assert.Equal(db.DefaultNullDisplay, result.NullDisplay())
+	assert.Equal("NULL", result.DisplayValue(result.Rows[0]["actual_null"]))
+
+	dbClient.SetNullDisplay("")
+	result, err = dbClient.Query(selectNull)
+	assert.NoError(err)
+	assert.Equal("", result.DisplayValue(result.Rows[0]["actual_null"]))
+	assert.Equal("NULL", result.DisplayValue(result.Rows[0]["null_text"]))
+	// The stored value still knows it's NULL
+	assert.True(result.Rows[0]["actual_null"].IsNull())
+}
+
+func TestDBSQLiteQueryHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	history := db.NewQueryHistory("")
+	dbClient.SetQueryHistory(history)
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER)")
+	assert.NoError(err)
+	_, err = dbClient.Query("SELECT missing FROM users")
+	assert.Error(err)
+
+	dbClient.SetQueryHistory(nil)
+	_, err = dbClient.Query("SELECT id FROM users")
+	assert.NoError(err)
+
+	recent := history.Recent(10)
+	if assert.Len(recent, 2) {
+		assert.Equal("CREATE TABLE users (id INTEGER)", recent[0].Query)
+		assert.Empty(recent[0].Error)
+		assert.Equal("SELECT missing FROM users", recent[1].Query)
+		assert.Contains(recent[1].Error, "no such column")
+	}
+}
+
+func TestDBSQLiteReset(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// Nothing to reset before the first query
+	assert.NoError(dbClient.Reset())
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER)")
+	assert.NoError(err)
+
+	// Session state from the old connection is gone
+	_, err = dbClient.Exec("CREATE TEMP TABLE scratch (id INTEGER)")
+	assert.NoError(err)
+
+	tx, err := dbClient.BeginTx(context.Background())
+	assert.NoError(err)
+	_, err = tx.Exec("INSERT INTO users VALUES (1)")
+	assert.NoError(err)
+
+	assert.NoError(dbClient.Reset())
+
+	_, err = dbClient.Query("SELECT * FROM scratch")
+	assert.ErrorContains(err, "no such table")
+
+	// The transaction was rolled back, and a new one can be started
+	result, err := dbClient.Query("SELECT COUNT(*) AS total FROM users")
+	assert.NoError(err)
+	assert.Equal("0", result.Rows[0]["total"].String)
+
+	tx, err = dbClient.BeginTx(context.Background())
+	assert.NoError(err)
+	assert.NoError(tx.Rollback())
+}
+
+func TestDBSQLiteResetDuringTransactions(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER)")
+	assert.NoError(err)
+
+	// Reset may roll any of these back, only racing on the transaction matters here
+	var done sync.WaitGroup
+	done.Add(1)
+	go func() {
+		defer done.Done()
+		for range 50 {
+			tx, err := dbClient.BeginTx(context.Background())
+			if err != nil {
+				continue
+			}
+			_, _ = tx.Exec("INSERT INTO users VALUES (1)")
+			_ = tx.Commit()
+		}
+	}()
+
+	for range 50 {
+		assert.NoError(dbClient.Reset())
+	}
+	done.Wait()
+
+	_, err = dbClient.Query("SELECT COUNT(*) FROM users")
+	assert.NoError(err)
+}
+
+func TestDBSQLiteMaxColumnBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	const selectValues = "SELECT 'short' AS short_text, 'ééééé' AS long_text, NULL AS missing"
+
+	dbClient.SetMaxColumnBytes(8)
+	result, err := dbClient.Query(selectValues)
+	assert.NoError(err)
+
+	row := result.Rows[0]
+	assert.Equal("short", row["short_text"].String)
+	assert.False(row["short_text"].Truncated)
+	// 10 bytes of text, cut on a character boundary to fit the ellipsis
+	assert.Equal("éé…", row["long_text"].String)
+	assert.True(row["long_text"].Truncated)
+	assert.True(row["missing"].IsNull())
+	assert.False(row["missing"].Truncated)
+
+	// Applies to single value lookups too
+	value, err := dbClient.QueryScalar("SELECT 'ééééé'")
+	assert.NoError(err)
+	assert.True(value.Truncated)
+
+	result, err = dbClient.QueryUntruncated(selectValues)
+	assert.NoError(err)
+	assert.Equal("ééééé", result.Rows[0]["long_text"].String)
+	assert.False(result.Rows[0]["long_text"].Truncated)
+
+	dbClient.SetMaxColumnBytes(0)
+	result, err = dbClient.Query(selectValues)
+	assert.NoError(err)
+	assert.Equal("ééééé", result.Rows[0]["long_text"].String)
+}
+
+func TestDBSQLiteWarnings(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// SQLite has no warnings to report, collecting them shouldn't get in the way
+	dbClient.SetCollectWarnings(true)
+
+	result, err := dbClient.Query("SELECT CAST('12abc' AS INTEGER) AS converted")
+	assert.NoError(err)
+	assert.Equal("12", result.Rows[0]["converted"].String)
+	assert.Nil(result.Warnings)
+
+	execResult, err := dbClient.Exec("CREATE TABLE users (id INTEGER)")
+	assert.NoError(err)
+	assert.Nil(execResult.Warnings)
+}
+
+func TestDBSQLiteBulkInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(err)
+
+	inserted, err := dbClient.BulkInsert("users", []string{"id", "name"}, [][]any{
+		{1, "Alice"},
+		{2, nil},
+	})
+	assert.NoError(err)
+	assert.Equal(int64(2), inserted)
+
+	result, err := dbClient.Query("SELECT id, name FROM users ORDER BY id")
+	assert.NoError(err)
+	assert.Equal("Alice", result.Rows[0]["name"].String)
+	assert.True(result.Rows[1]["name"].IsNull())
+
+	// More rows than fit in a single batch of parameters
+	rows := make([][]any, 20000)
+	for i := range rows {
+		rows[i] = []any{i + 3, fmt.Sprint("user ", i)}
+	}
+	inserted, err = dbClient.BulkInsert("users", []string{"id", "name"}, rows)
+	assert.NoError(err)
+	assert.Equal(int64(len(rows)), inserted)
+
+	// A failing batch rolls back the ones before it
+	rows = make([][]any, 20000)
+	for i := range rows {
+		rows[i] = []any{i + 100000, "new"}
+	}
+	rows[len(rows)-1] = []any{1, "duplicate"}
+	_, err = dbClient.BulkInsert("users", []string{"id", "name"}, rows)
+	assert.ErrorIs(err, db.ErrExecFailed)
+
+	total, err := dbClient.QueryScalar("SELECT COUNT(*) FROM users")
+	assert.NoError(err)
+	assert.Equal(fmt.Sprint(20002), total.String)
+
+	_, err = dbClient.BulkInsert("users", []string{"id", "name"}, [][]any{{1}})
+	assert.ErrorContains(err, "Row 1 has 1 value(s), expected 2")
+}
+
+func TestDBSQLiteConnMaxLifetime(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// Temp tables only live as long as the connection, so show when it was replaced
+	_, err = dbClient.Exec("CREATE TEMP TABLE scratch (id INTEGER)")
+	assert.NoError(err)
+
+	dbClient.SetConnMaxLifetime(time.Hour)
+	_, err = dbClient.Query("SELECT * FROM scratch")
+	assert.NoError(err)
+
+	dbClient.SetConnMaxLifetime(time.Nanosecond)
+	_, err = dbClient.Query("SELECT * FROM scratch")
+	assert.ErrorContains(err, "no such table")
+
+	// Not while a transaction depends on the connection
+	_, err = dbClient.Exec("CREATE TEMP TABLE scratch (id INTEGER)")
+	assert.NoError(err)
+	dbClient.SetConnMaxLifetime(time.Hour)
+	tx, err := dbClient.BeginTx(context.Background())
+	assert.NoError(err)
+
+	dbClient.SetConnMaxLifetime(time.Nanosecond)
+	_, err = tx.Exec("INSERT INTO scratch VALUES (1)")
+	assert.NoError(err)
+	_, err = dbClient.Query("SELECT * FROM scratch")
+	assert.NoError(err)
+	assert.NoError(tx.Commit())
+}
+
+type recordingLogger struct {
+	statements []string
+	errs       []error
+}
+
+func (logger *recordingLogger) LogQuery(sql string, args []any, elapsed time.Duration, err error) {
+	logger.statements = append(logger.statements, sql)
+	logger.errs = append(logger.errs, err)
+}
+
+func TestDBSQLiteLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	logger := &recordingLogger{}
+	dbClient.SetLogger(logger)
+
+	assert.NoError(dbClient.AddSessionInit("PRAGMA foreign_keys = ON"))
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER)")
+	assert.NoError(err)
+	_, err = dbClient.Query("SELECT * FROM missing")
+	assert.Error(err)
+
+	// Session setup is logged too, once the connection is opened by the first statement
+	assert.Equal([]string{"PRAGMA foreign_keys = ON", "CREATE TABLE users (id INTEGER)", "SELECT * FROM missing"}, logger.statements)
+	assert.NoError(logger.errs[1])
+	assert.ErrorIs(logger.errs[2], db.ErrQueryFailed)
+
+	// Only reading the first row isn't a failure
+	_, err = dbClient.QueryRow("SELECT * FROM users")
+	assert.NoError(err)
+	_, err = dbClient.ServerVersion()
+	assert.NoError(err)
+	assert.NoError(logger.errs[3])
+	assert.NoError(logger.errs[4])
+
+	dbClient.SetLogger(nil)
+	_, err = dbClient.Query("SELECT * FROM users")
+	assert.NoError(err)
+	assert.Len(logger.statements, 5)
+}
+
+func TestDBSQLiteDestroyContext(t *testing.T) {
+	t.Run("Closes", func(t *testing.T) {
+		assert := assert.New(t)
+
+		connOptions := newSQLiteConnOptions(t)
+
+		dbClient, err := db.CreateDBClient(&connOptions)
+		assert.NoError(err)
+
+		_, err = dbClient.Query("SELECT 1")
+		assert.NoError(err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(dbClient.DestroyContext(ctx))
+
+		_, err = dbClient.Query("SELECT 1")
+		assert.Error(err)
+	})
+
+	t.Run("Gives up on a hung close", func(t *testing.T) {
+		assert := assert.New(t)
+
+		connOptions := newSQLiteConnOptions(t)
+
+		dbClient, err := db.CreateDBClient(&connOptions)
+		assert.NoError(err)
+
+		// Open rows keep the connection from closing
+		reading := make(chan struct{})
+		unblock := make(chan struct{})
+		streamDone := make(chan struct{})
+		go func() {
+			defer close(streamDone)
+			_, _ = dbClient.QueryStream("SELECT 1 AS one", func(row map[string]*db.NullString) error {
+				close(reading)
+				<-unblock
+				return nil
+			})
+		}()
+		<-reading
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		err = dbClient.DestroyContext(ctx)
+		assert.ErrorContains(err, "Gave up waiting for the database to close")
+		assert.ErrorIs(err, context.DeadlineExceeded)
+
+		close(unblock)
+		<-streamDone
+	})
+}
+
+func TestDBSQLiteSwitchDatabase(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	err = dbClient.SwitchDatabase(filepath.Join(t.TempDir(), "other.db"))
+	assert.ErrorContains(err, "Switching databases not supported for SQLite")
+}
+
+func TestDBSQLiteListDatabases(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec(fmt.Sprintf("ATTACH DATABASE '%s' AS archive", filepath.Join(t.TempDir(), "archive.db")))
+	assert.NoError(err)
+
+	databases, err := dbClient.ListDatabases()
+	assert.NoError(err)
+	assert.Equal([]string{"main", "archive"}, databases)
+
+	_, err = dbClient.ListSchemas()
+	assert.ErrorContains(err, "Listing schemas not supported for SQLite")
+}
+
+func TestDBSQLiteTestConnection(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+	assert.NoError(db.TestConnection(&connOptions, time.Second))
+
+	connOptions.DatabaseName = filepath.Join(t.TempDir(), "missing", "test.db")
+	err := db.TestConnection(&connOptions, time.Second)
+	assert.ErrorIs(err, db.ErrConnectionFailed)
+	assert.ErrorContains(err, "Connection string: ")
+}
+
+func TestDBSQLiteStatementTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.StatementTimeout = time.Second
+
+	_, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+	assert.ErrorIs(err, db.ErrInvalidConnOptions)
+	assert.ErrorContains(err, "StatementTimeout is not supported for SQLite")
+}
+
+func TestDBSQLiteKeepalive(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// Nothing to ping before the first query
+	dbClient.StartKeepalive(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	// Pings in between queries keep using the same session
+	_, err = dbClient.Exec("CREATE TEMP TABLE scratch (id INTEGER)")
+	assert.NoError(err)
+	for i := 0; i < 20; i++ {
+		_, err = dbClient.Exec("INSERT INTO scratch VALUES (?)", i)
+		assert.NoError(err)
+		time.Sleep(time.Millisecond)
+	}
+
+	tx, err := dbClient.BeginTx(context.Background())
+	assert.NoError(err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = tx.Exec("DELETE FROM scratch WHERE id >= 10")
+	assert.NoError(err)
+	assert.NoError(tx.Commit())
+
+	// Restarting replaces the running loop
+	dbClient.StartKeepalive(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	result, err := dbClient.Query("SELECT COUNT(*) AS total FROM scratch")
+	assert.NoError(err)
+	assert.Equal("10", result.Rows[0]["total"].String)
+
+	dbClient.StopKeepalive()
+	dbClient.StopKeepalive()
+}
+
+func TestDBSQLiteDuplicateColumnNames(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE users (id INTEGER, name TEXT);
+		CREATE TABLE orders (id INTEGER, user_id INTEGER);
+		INSERT INTO users VALUES (1, 'Alice');
+		INSERT INTO orders VALUES (10, 1);
+	`)
+	assert.NoError(err)
+
+	result, err := dbClient.Query("SELECT u.id, o.id, u.name FROM users u JOIN orders o ON o.user_id = u.id")
+	assert.NoError(err)
+	assert.Equal([]string{"id", "id_2", "name"}, result.Columns)
+	assert.Equal("1", result.Rows[0]["id"].String)
+	assert.Equal("10", result.Rows[0]["id_2"].String)
+
+	// A generated name never replaces a column that was actually selected
+	result, err = dbClient.Query("SELECT 1 AS id, 2 AS id, 3 AS id_2")
+	assert.NoError(err)
+	assert.Equal([]string{"id", "id_3", "id_2"}, result.Columns)
+	assert.Equal("2", result.Rows[0]["id_3"].String)
+	assert.Equal("3", result.Rows[0]["id_2"].String)
+}
+
+func TestDBSQLiteCopyOut(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE users (id INTEGER, name TEXT);
+		INSERT INTO users VALUES (1, 'Smith, John'), (2, NULL);
+	`)
+	assert.NoError(err)
+
+	var out strings.Builder
+	assert.NoError(dbClient.CopyOut("SELECT id, name, id AS id FROM users ORDER BY id", &out))
+	assert.Equal("id,name,id_2\n1,\"Smith, John\",1\n2,,2\n", out.String())
+
+	// The header is still written without any rows
+	out.Reset()
+	assert.NoError(dbClient.CopyOut("SELECT id, name FROM users WHERE id > 100", &out))
+	assert.Equal("id,name\n", out.String())
+
+	err = dbClient.CopyOut("DELETE FROM users", &out)
+	assert.ErrorContains(err, "Only SELECT queries can be exported")
+
+	// Display settings are for Query results, exports keep the raw values
+	dbClient.SetMaxColumnBytes(4)
+	dbClient.SetMaxRows(1)
+	dbClient.SetNullDisplay("NULL")
+
+	out.Reset()
+	assert.NoError(dbClient.CopyOut("SELECT id, name FROM users ORDER BY id", &out))
+	assert.Equal("id,name\n1,\"Smith, John\"\n2,\n", out.String())
+}
+
+func TestDBSQLiteSessionInit(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// Nothing to replay for SQLite, it has no safe mode
+	assert.Empty(dbClient.SessionInit())
+
+	foreignKeysEnabled := func() string {
+		value, err := dbClient.QueryScalar("PRAGMA foreign_keys")
+		assert.NoError(err)
+		return value.String
+	}
+	assert.Equal("0", foreignKeysEnabled())
+
+	// Applies straight away
+	assert.NoError(dbClient.AddSessionInit("PRAGMA foreign_keys = ON"))
+	assert.Equal("1", foreignKeysEnabled())
+
+	// And to the next connection
+	assert.NoError(dbClient.Reset())
+	assert.Equal("1", foreignKeysEnabled())
+
+	err = dbClient.AddSessionInit("PRAGMA nonsense syntax here")
+	assert.ErrorContains(err, "Failed to initialize session")
+	assert.Equal([]string{"PRAGMA foreign_keys = ON"}, dbClient.SessionInit())
+}
+
+func TestDBSQLiteQueryMulti(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// SQLite only ever returns a single result set
+	results, err := dbClient.QueryMulti("SELECT 1 AS summary")
+	assert.NoError(err)
+	if assert.Len(results, 1) {
+		assert.Equal([]string{"summary"}, results[0].Columns)
+		assert.Equal("1", results[0].Rows[0]["summary"].String)
+		assert.Equal(db.Select, results[0].StmtType)
+	}
+
+	_, err = dbClient.QueryMulti("SELECT missing FROM nowhere")
+	assert.ErrorIs(err, db.ErrQueryFailed)
+}
+
+func TestDBSQLiteQuoteIdentifier(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	table := dbClient.QuoteIdentifier(`weird "table"; DROP`)
+	column := dbClient.QuoteIdentifier("select")
+
+	_, err = dbClient.Exec(fmt.Sprintf("CREATE TABLE %s (%s TEXT)", table, column))
+	assert.NoError(err)
+
+	inserted, err := dbClient.BulkInsert(table, []string{column}, [][]any{{"ok"}})
+	assert.NoError(err)
+	assert.Equal(int64(1), inserted)
+
+	tables, err := dbClient.ListTables()
+	assert.NoError(err)
+	assert.Equal([]string{`weird "table"; DROP`}, tables)
+}
+
+func TestDBSQLiteMaxRows(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	const countToFive = `
+		WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter WHERE x < 5)
+		SELECT x FROM counter
+	`
+
+	dbClient.SetMaxRows(3)
+	result, err := dbClient.Query(countToFive)
+	assert.NoError(err)
+	assert.Len(result.Rows, 3)
+	assert.Equal("3", result.Rows[2]["x"].String)
+	assert.True(result.Truncated)
+
+	// Exactly at the limit isn't truncated
+	dbClient.SetMaxRows(5)
+	result, err = dbClient.Query(countToFive)
+	assert.NoError(err)
+	assert.Len(result.Rows, 5)
+	assert.False(result.Truncated)
+
+	// Also stops a query that would never end
+	dbClient.SetMaxRows(10)
+	result, err = dbClient.Query(`
+		WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter)
+		SELECT x FROM counter
+	`)
+	assert.NoError(err)
+	assert.Len(result.Rows, 10)
+	assert.True(result.Truncated)
+
+	dbClient.SetMaxRows(0)
+	result, err = dbClient.Query(countToFive)
+	assert.NoError(err)
+	assert.Len(result.Rows, 5)
+	assert.False(result.Truncated)
+}
+
+func TestDBSQLiteUnderlyingDB(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.MaxOpenConns = 2
+
+	dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE users (id INTEGER, name TEXT);
+		INSERT INTO users VALUES (1, 'Alice'), (2, 'Bob');
+	`)
+	assert.NoError(err)
+
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var users []user
+	err = dbClient.DB().Select(&users, "SELECT id, name FROM users ORDER BY id")
+	assert.NoError(err)
+	assert.Equal([]user{{1, "Alice"}, {2, "Bob"}}, users)
+}
+
+func TestDBSQLiteNullDistinction(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	result, err := dbClient.Query("SELECT NULL AS actual_null, 'NULL' AS null_text, '' AS empty_text")
+	assert.NoError(err)
+	row := result.Rows[0]
+
+	assert.True(row["actual_null"].IsNull())
+	assert.Equal("NULL", row["actual_null"].ToString())
+
+	assert.False(row["null_text"].IsNull())
+	assert.Equal("NULL", row["null_text"].ToString())
+
+	assert.False(row["empty_text"].IsNull())
+	assert.Equal("", row["empty_text"].ToString())
+}
+
+func TestDBSQLiteReadOnly(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(t, err)
+
+	assert.NoError(t, dbClient.SetReadOnly(true))
+	assert.True(t, dbClient.IsReadOnly())
+
+	var allowedStatements = []string{
+		"SELECT * FROM users",
+		"  select 1",
+		"SELECT 1 UNION SELECT 2",
+		"-- comment\nSELECT 1",
+		"/* comment */ EXPLAIN SELECT 1",
+		"WITH named AS (SELECT name FROM users) SELECT name FROM named",
+		"SELECT 1; SELECT 2",
+	}
+	for _, statement := range allowedStatements {
+		t.Run(fmt.Sprintf("Allows %q", statement), func(t *testing.T) {
+			_, err := dbClient.Query(statement)
+			assert.NoError(t, err)
+		})
+	}
+
+	var refusedStatements = []struct {
+		Statement string
+		Keyword   string
+	}{
+		{"UPDATE users SET name = 'foo'", "UPDATE"},
+		{"/* sneaky */ -- comment\n  delete from users", "DELETE"},
+		{"DROP TABLE users", "DROP"},
+		{"INSERT INTO users (name) VALUES ('foo')", "INSERT"},
+		{"WITH stale AS (SELECT id FROM users) DELETE FROM users WHERE id IN (SELECT id FROM stale)", "DELETE"},
+		{"VALUES (1), (2)", "VALUES"},
+		{"SELECT 1; DROP TABLE users", "DROP"},
+		{"EXPLAIN ANALYZE DELETE FROM users", "EXPLAIN ANALYZE"},
+	}
+	for _, test := range refusedStatements {
+		t.Run(fmt.Sprintf("Refuses %q", test.Statement), func(t *testing.T) {
+			assert := assert.New(t)
+			expectedErr := fmt.Sprint("read-only mode: refusing to run ", test.Keyword)
+
+			_, err := dbClient.Query(test.Statement)
+			assert.EqualError(err, expectedErr)
+
+			_, err = dbClient.Exec(test.Statement)
+			assert.EqualError(err, expectedErr)
+		})
+	}
+
+	t.Run("Refused statements didn't run", func(t *testing.T) {
+		_, err := dbClient.Query("SELECT COUNT(*) FROM users")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Disabling allows writes again", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(dbClient.SetReadOnly(false))
+
+		_, err := dbClient.Exec("INSERT INTO users (name) VALUES ('foo')")
+		assert.NoError(err)
+	})
+}
+
+func TestDBSQLiteSearchPath(t *testing.T) {
+	assert := assert.New(t)
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	assert.EqualError(dbClient.SetSearchPath("main"), "search_path is only supported for PostgreSQL")
+	assert.Empty(dbClient.SearchPath())
+
+	connOptions.SearchPath = []string{"main"}
+	_, err = db.CreateDBClient(&connOptions)
+	assert.ErrorContains(err, "search_path is not supported for SQLite")
+}
+
+func TestDBSQLiteIdleClose(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	openConnections := func() int {
+		return dbClient.DB().Stats().OpenConnections
+	}
+
+	dbClient.SetIdleClose(50 * time.Millisecond)
+
+	t.Run("Closes after no queries", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := dbClient.Exec("CREATE TABLE users (name TEXT)")
+		assert.NoError(err)
+		assert.Equal(1, openConnections())
+
+		assert.Eventually(func() bool { return openConnections() == 0 }, time.Second, 10*time.Millisecond)
+
+		// Reconnects on the next query
+		result, err := dbClient.Query("SELECT COUNT(*) AS count FROM users")
+		assert.NoError(err)
+		assert.Equal("0", result.Rows[0]["count"].String)
+	})
+
+	t.Run("Each query restarts the wait", func(t *testing.T) {
+		assert := assert.New(t)
+
+		for range 6 {
+			_, err := dbClient.Query("SELECT 1")
+			assert.NoError(err)
+			time.Sleep(20 * time.Millisecond)
+		}
+		assert.Equal(1, openConnections())
+	})
+
+	t.Run("Kept open during a transaction", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tx, err := dbClient.BeginTx(context.Background())
+		assert.NoError(err)
+		_, err = tx.Exec("INSERT INTO users VALUES ('alice')")
+		assert.NoError(err)
+
+		time.Sleep(150 * time.Millisecond)
+		assert.NoError(tx.Commit())
+
+		result, err := dbClient.Query("SELECT COUNT(*) AS count FROM users")
+		assert.NoError(err)
+		assert.Equal("1", result.Rows[0]["count"].String)
+	})
+
+	t.Run("Disabled with 0", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dbClient.SetIdleClose(0)
+		_, err := dbClient.Query("SELECT 1")
+		assert.NoError(err)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(1, openConnections())
+	})
+
+	t.Run("Pool mode", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clientOptions := db.DefaultDBClientOptions()
+		clientOptions.PoolSize = 2
+		pooledClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+		if !assert.NoError(err) {
+			return
+		}
+		defer pooledClient.Destroy()
+
+		pooledClient.SetIdleClose(50 * time.Millisecond)
+
+		// Queries only share connMu, so one in progress doesn't hold off closing the idle connections
+		openQuery, err := pooledClient.QueryOpen("SELECT name FROM users")
+		if !assert.NoError(err) {
+			return
+		}
+		defer openQuery.Close()
+
+		_, err = pooledClient.Query("SELECT 1")
+		assert.NoError(err)
+
+		assert.Eventually(func() bool {
+			stats := pooledClient.DB().Stats()
+			return stats.Idle == 0 && stats.OpenConnections == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestDBSQLitePingRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	// Directory doesn't exist, so the file can never be opened
+	connOptions := db.DBConnOptions{
+		Flavor:       db.SQLite,
+		DatabaseName: filepath.Join(t.TempDir(), "missing", "test.db"),
+	}
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.PingAttempts = 3
+	clientOptions.PingBackoff = 20 * time.Millisecond
+
+	start := time.Now()
+	dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+	elapsed := time.Since(start)
+
+	assert.Nil(dbClient)
+	assert.ErrorContains(err, "after 3 attempt(s)")
+	// Underlying cause should still be visible
+	assert.ErrorContains(err, "unable to open database file")
+	// Waited 20ms, then 40ms between attempts
+	assert.GreaterOrEqual(elapsed, 60*time.Millisecond)
+}
+
+func TestDBSQLiteQueryTimeout(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	const runawayQuery = `
+		WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter)
+		SELECT x FROM counter
+	`
+
+	t.Run("Timeout cancels query", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dbClient.SetQueryTimeout(100 * time.Millisecond)
+		defer dbClient.SetQueryTimeout(0)
+
+		result, err := dbClient.Query(runawayQuery)
+		assert.Nil(result)
+		assert.ErrorContains(err, "Query cancelled due to exceeding the timeout of 100ms")
+		assert.ErrorIs(err, context.DeadlineExceeded)
+	})
+
+	t.Run("Caller cancellation isn't reported as a timeout", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dbClient.SetQueryTimeout(time.Minute)
+		defer dbClient.SetQueryTimeout(0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := dbClient.QueryContext(ctx, runawayQuery)
+		assert.ErrorIs(err, context.DeadlineExceeded)
+		assert.NotContains(err.Error(), "timeout")
+	})
+
+	t.Run("Fast queries are unaffected", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dbClient.SetQueryTimeout(time.Second)
+		defer dbClient.SetQueryTimeout(0)
+
+		result, err := dbClient.Query("SELECT 1 AS one")
+		assert.NoError(err)
+		assert.Equal("1", result.Rows[0]["one"].ToString())
+	})
+}
+
+func TestDBSQLiteCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// Nothing running, should be a no-op
+	dbClient.Cancel()
+
+	queryErr := make(chan error)
+	go func() {
+		_, err := dbClient.Query(`
+			WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter)
+			SELECT x FROM counter
+		`)
+		queryErr <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	dbClient.Cancel()
+
+	select {
+	case err := <-queryErr:
+		assert.ErrorIs(err, db.ErrCancelled)
+		assert.ErrorIs(err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		assert.Fail("Query was not cancelled")
+	}
+
+	// Cancelling only affects the query that was running
+	result, err := dbClient.Query("SELECT 1 AS one")
+	assert.NoError(err)
+	assert.Equal("1", result.Rows[0]["one"].ToString())
+}
+
+func TestDBSQLiteDestroyDuringQuery(t *testing.T) {
+	for _, poolSize := range []int{0, 2} {
+		t.Run(fmt.Sprintf("Pool size %d", poolSize), func(t *testing.T) {
+			assert := assert.New(t)
+
+			connOptions := newSQLiteConnOptions(t)
+			clientOptions := db.DefaultDBClientOptions()
+			clientOptions.PoolSize = poolSize
+
+			dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+			assert.NoError(err)
+
+			scanning := make(chan struct{})
+			var scanningOnce sync.Once
+			queryErr := make(chan error)
+			go func() {
+				_, err := dbClient.QueryStream(`
+					WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter)
+					SELECT x FROM counter
+				`, func(row map[string]*db.NullString) error {
+					scanningOnce.Do(func() { close(scanning) })
+					return nil
+				})
+				queryErr <- err
+			}()
+
+			<-scanning
+			assert.NoError(dbClient.Destroy())
+
+			select {
+			case err := <-queryErr:
+				assert.ErrorIs(err, db.ErrCancelled)
+			case <-time.After(5 * time.Second):
+				assert.Fail("Query was not cancelled")
+			}
+		})
+	}
+}
+
+func TestDBSQLitePrepare(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	// Small cache so eviction is exercised
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.StatementCacheSize = 1
+
+	dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(err)
+
+	insertStmt, err := dbClient.Prepare("INSERT INTO users (name) VALUES (?)")
+	assert.NoError(err)
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		result, err := insertStmt.Exec(name)
+		assert.NoError(err)
+		assert.Equal(int64(1), result.RowsAffected)
+	}
+
+	// Evicts the insert statement from the cache
+	selectStmt, err := dbClient.Prepare("SELECT name FROM users WHERE id = ?")
+	assert.NoError(err)
+
+	result, err := selectStmt.Query(2)
+	assert.NoError(err)
+	assert.Len(result.Rows, 1)
+	assert.Equal("bob", result.Rows[0]["name"].ToString())
+
+	// Evicted handles are prepared again on use
+	_, err = insertStmt.Exec("dave")
+	assert.NoError(err)
+
+	result, err = selectStmt.Query(4)
+	assert.NoError(err)
+	assert.Equal("dave", result.Rows[0]["name"].ToString())
+
+	// Read-only blocks preparing writes
+	{
+		assert.NoError(dbClient.SetReadOnly(true))
+
+		_, err := dbClient.Prepare("DELETE FROM users")
+		assert.ErrorIs(err, db.ErrReadOnly)
+
+		assert.NoError(dbClient.SetReadOnly(false))
+	}
+
+	// Checked again each time it runs, not only when prepared
+	{
+		deleteStmt, err := dbClient.Prepare("DELETE FROM users")
+		assert.NoError(err)
+
+		assert.NoError(dbClient.SetReadOnly(true))
+		_, err = deleteStmt.Exec()
+		assert.ErrorIs(err, db.ErrReadOnly)
+		_, err = deleteStmt.Query()
+		assert.ErrorIs(err, db.ErrReadOnly)
+		assert.NoError(dbClient.SetReadOnly(false))
+
+		total, err := dbClient.QueryScalar("SELECT COUNT(*) FROM users")
+		assert.NoError(err)
+		assert.Equal("4", total.String)
+	}
+}
+
+func TestDBSQLiteQueryPaged(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec(`
+		CREATE TABLE numbers AS
+		WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 25)
+		SELECT n FROM counter
+	`)
+	assert.NoError(err)
+
+	pageValues := func(result *db.QueryResult) []string {
+		values := []string{}
+		for _, row := range result.Rows {
+			values = append(values, row["n"].ToString())
+		}
+		return values
+	}
+
+	result, total, err := dbClient.QueryPaged("SELECT n FROM numbers ORDER BY n;", 2, 10)
+	assert.NoError(err)
+	assert.Equal(25, total)
+	assert.Equal([]string{"11", "12", "13", "14", "15", "16", "17", "18", "19", "20"}, pageValues(result))
+
+	// Last page is partial
+	result, total, err = dbClient.QueryPaged("SELECT n FROM numbers ORDER BY n -- trailing comment", 3, 10)
+	assert.NoError(err)
+	assert.Equal(25, total)
+	assert.Equal([]string{"21", "22", "23", "24", "25"}, pageValues(result))
+
+	// LIMIT within a subquery or a string isn't the query's own
+	result, total, err = dbClient.QueryPaged(`
+		SELECT n FROM numbers
+		WHERE n IN (SELECT n FROM numbers ORDER BY n DESC LIMIT 3) AND 'LIMIT' != ''
+		ORDER BY n
+	`, 1, 2)
+	assert.NoError(err)
+	assert.Equal(3, total)
+	assert.Equal([]string{"23", "24"}, pageValues(result))
+
+	_, _, err = dbClient.QueryPaged("SELECT n FROM numbers LIMIT 5", 1, 10)
+	assert.ErrorContains(err, "Query already contains LIMIT")
+
+	_, _, err = dbClient.QueryPaged("DELETE FROM numbers", 1, 10)
+	assert.ErrorContains(err, "Only SELECT queries can be paged")
+
+	_, _, err = dbClient.QueryPaged("SELECT n FROM numbers", 0, 10)
+	assert.ErrorContains(err, "Invalid page")
+}
+
+func TestDBSQLiteServerVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	// Only cuts values shown to the user, lookups still get them in full
+	dbClient.SetMaxColumnBytes(3)
+
+	version, err := dbClient.ServerVersion()
+	assert.NoError(err)
+	assert.Regexp(`^3\.\d+\.\d+$`, version)
+
+	// Served from cache
+	cachedVersion, err := dbClient.ServerVersion()
+	assert.NoError(err)
+	assert.Equal(version, cachedVersion)
+}
+
+func TestDBSQLiteConnectionMetadata(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+	dbClient.SetMaxColumnBytes(3)
+
+	databaseName, err := dbClient.CurrentDatabase()
+	assert.NoError(err)
+	assert.Equal(connOptions.DatabaseName, databaseName)
+
+	_, err = dbClient.CurrentUser()
+	assert.ErrorContains(err, "Looking up the current user is not supported for SQLite")
+
+	_, err = dbClient.ServerHost()
+	assert.ErrorContains(err, "Looking up the server host is not supported for SQLite")
+}
+
+func TestDBSQLiteExplain(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(err)
+
+	result, err := dbClient.Explain("SELECT name FROM users WHERE id = 1;", false)
+	assert.NoError(err)
+	assert.Contains(result.Columns, "detail")
+	if assert.NotEmpty(result.Rows) {
+		assert.Contains(result.Rows[0]["detail"].ToString(), "users")
+	}
+	assert.Empty(result.PlanJSON)
+
+	_, err = dbClient.Explain("SELECT name FROM users", true)
+	assert.ErrorContains(err, "EXPLAIN ANALYZE not supported for SQLite")
+
+	_, err = dbClient.ExplainWithOptions(context.Background(), "SELECT name FROM users", db.ExplainOptions{JSON: true})
+	assert.ErrorContains(err, "EXPLAIN as JSON not supported")
+
+	// Analyzing executes the statement, so read-only mode must still apply
+	assert.NoError(dbClient.SetReadOnly(true))
+	_, err = dbClient.Explain("DELETE FROM users", true)
+	assert.ErrorIs(err, db.ErrReadOnly)
+}
+
+func TestDBSQLiteCancelledPingKeepsConnection(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	reconnects := 0
+	dbClient.SetOnReconnect(func(error) { reconnects++ })
+
+	assert.NoError(dbClient.Healthy(context.Background()))
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(dbClient.Healthy(cancelledCtx), context.Canceled)
+
+	// The connection wasn't dropped just because the caller gave up
+	assert.NoError(dbClient.Healthy(context.Background()))
+	assert.Equal(0, reconnects)
+}
+
+func TestDBSQLiteSchemaIntrospection(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY,
+			email TEXT NOT NULL,
+			status TEXT DEFAULT 'active'
+		);
+		CREATE TABLE accounts (id INTEGER PRIMARY KEY AUTOINCREMENT);
+		CREATE VIEW active_users AS SELECT * FROM users WHERE status = 'active';
+	`)
+	assert.NoError(err)
+
+	// Only limits results shown to the user, lookups still see every row
+	dbClient.SetMaxRows(1)
+
+	tables, err := dbClient.ListTables()
+	assert.NoError(err)
+	// Internal tables (i.e. sqlite_sequence from AUTOINCREMENT) are left out
+	assert.Equal([]string{"accounts", "active_users", "users"}, tables)
+
+	columns, err := dbClient.DescribeTable("users")
+	assert.NoError(err)
+
+	activeDefault := "'active'"
+	assert.Equal(
+		[]db.ColumnInfo{
+			{Name: "id", Type: "INTEGER", Nullable: true, Key: "PRI"},
+			{Name: "email", Type: "TEXT", Nullable: false},
+			{Name: "status", Type: "TEXT", Nullable: true, Default: &activeDefault},
+		},
+		columns,
+	)
+
+	_, err = dbClient.DescribeTable("missing")
+	assert.ErrorContains(err, "Table missing does not exist")
+}
+
+func TestDBSQLiteDryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users (name) VALUES ('alice'), ('bob');
+	`)
+	assert.NoError(err)
+
+	dbClient.SetDryRun(true)
+	assert.True(dbClient.IsDryRun())
+
+	execResult, err := dbClient.Exec("DELETE FROM users WHERE name = ?", "alice")
+	assert.NoError(err)
+	assert.True(execResult.DryRun)
+	// SQLite doesn't estimate rows
+	assert.Equal(int64(-1), execResult.RowsAffected)
+
+	queryResult, err := dbClient.Query("DROP TABLE users")
+	assert.NoError(err)
+	assert.Equal([]string{db.DryRunColumn}, queryResult.Columns)
+	assert.Equal("Statement is valid, not executed", queryResult.Rows[0][db.DryRunColumn].ToString())
+
+	streamedRows := 0
+	_, err = dbClient.QueryStream("DELETE FROM users", func(row map[string]*db.NullString) error {
+		streamedRows++
+		return nil
+	})
+	assert.NoError(err)
+	assert.Equal(1, streamedRows)
+
+	// Invalid statements are still reported
+	_, err = dbClient.Exec("UPDATE missing_table SET name = 'x'")
+	assert.ErrorIs(err, db.ErrQueryFailed)
+
+	// A write after a read isn't let through with it
+	_, err = dbClient.Exec("SELECT 1; DROP TABLE users")
+	assert.EqualError(err, "Dry run only validates a single statement at a time")
+
+	// Reads run as usual, and nothing was changed
+	queryResult, err = dbClient.Query("SELECT COUNT(*) AS total FROM users")
+	assert.NoError(err)
+	assert.Equal("2", queryResult.Rows[0]["total"].ToString())
+
+	queryResult, err = dbClient.Query("WITH named AS (SELECT name FROM users) SELECT COUNT(*) AS total FROM named")
+	assert.NoError(err)
+	assert.Equal("2", queryResult.Rows[0]["total"].ToString())
+
+	// Through a transaction or prepared statement, i.e. BulkInsert, nothing is changed either
+	inserted, err := dbClient.BulkInsert("users", []string{"name"}, [][]any{{"carol"}, {"dave"}})
+	assert.NoError(err)
+	assert.Equal(int64(0), inserted)
+
+	tx, err := dbClient.BeginTx(context.Background())
+	if !assert.NoError(err) {
+		return
+	}
+	execResult, err = tx.Exec("DELETE FROM users")
+	assert.NoError(err)
+	assert.True(execResult.DryRun)
+	queryResult, err = tx.Query("DELETE FROM users RETURNING name")
+	assert.NoError(err)
+	assert.Equal([]string{db.DryRunColumn}, queryResult.Columns)
+	assert.NoError(tx.Commit())
+
+	stmt, err := dbClient.Prepare("DELETE FROM users WHERE name = ?")
+	if !assert.NoError(err) {
+		return
+	}
+	execResult, err = stmt.Exec("alice")
+	assert.NoError(err)
+	assert.True(execResult.DryRun)
+	queryResult, err = stmt.Query("bob")
+	assert.NoError(err)
+	assert.Equal([]string{db.DryRunColumn}, queryResult.Columns)
+
+	total, err := dbClient.QueryScalar("SELECT COUNT(*) FROM users")
+	assert.NoError(err)
+	assert.Equal("2", total.String)
+
+	dbClient.SetDryRun(false)
+
+	execResult, err = dbClient.Exec("DELETE FROM users WHERE name = ?", "alice")
+	assert.NoError(err)
+	assert.False(execResult.DryRun)
+	assert.Equal(int64(1), execResult.RowsAffected)
+}
+
+func TestDBSQLiteHealthy(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+
+	// Works before any query was run, as well as after
+	assert.NoError(dbClient.Healthy(context.Background()))
+
+	_, err = dbClient.Query("SELECT 1")
+	assert.NoError(err)
+	assert.NoError(dbClient.Healthy(context.Background()))
+
+	assert.NoError(dbClient.Destroy())
+	assert.Error(dbClient.Healthy(context.Background()))
+}
+
+func TestDBSQLiteErrorKinds(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	t.Run("Query syntax error", func(t *testing.T) {
+		_, err := dbClient.Query("SELEKT 1")
+		assert.ErrorIs(t, err, db.ErrQueryFailed)
+		assert.NotErrorIs(t, err, db.ErrConnectionFailed)
+	})
+
+	t.Run("Exec error", func(t *testing.T) {
+		_, err := dbClient.Exec("DELETE FROM missing_table")
+		assert.ErrorIs(t, err, db.ErrExecFailed)
+	})
+
+	t.Run("Database error code", func(t *testing.T) {
+		_, err := dbClient.Exec("CREATE TABLE users (email TEXT UNIQUE)")
+		assert.NoError(t, err)
+		_, err = dbClient.Exec("INSERT INTO users VALUES ('a@example.com'), ('a@example.com')")
+		assert.ErrorIs(t, err, db.ErrExecFailed)
+
+		var queryErr *db.QueryError
+		if assert.ErrorAs(t, err, &queryErr) {
+			// SQLITE_CONSTRAINT_UNIQUE
+			assert.Equal(t, "2067", queryErr.Code)
+			assert.Empty(t, queryErr.SQLState)
+			assert.Contains(t, queryErr.Message, "UNIQUE constraint failed")
+		}
+
+		_, err = dbClient.Query("SELECT * FROM missing_table")
+		assert.ErrorIs(t, err, db.ErrQueryFailed)
+		if assert.ErrorAs(t, err, &queryErr) {
+			assert.Equal(t, "1", queryErr.Code)
+		}
+	})
+
+	t.Run("Read-only refusal", func(t *testing.T) {
+		assert.NoError(t, dbClient.SetReadOnly(true))
+		defer dbClient.SetReadOnly(false)
+
+		_, err := dbClient.Exec("DELETE FROM missing_table")
+		assert.ErrorIs(t, err, db.ErrReadOnly)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		dbClient.SetQueryTimeout(50 * time.Millisecond)
+		defer dbClient.SetQueryTimeout(0)
+
+		_, err := dbClient.Query(`
+			WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter)
+			SELECT x FROM counter
+		`)
+		assert.ErrorIs(t, err, db.ErrQueryTimeout)
+	})
+
+	t.Run("Connection failure", func(t *testing.T) {
+		connOptions := db.DBConnOptions{
+			Flavor:       db.SQLite,
+			DatabaseName: filepath.Join(t.TempDir(), "missing", "test.db"),
+		}
+		clientOptions := db.DefaultDBClientOptions()
+		clientOptions.PingAttempts = 1
+
+		_, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+		assert.ErrorIs(t, err, db.ErrConnectionFailed)
+	})
+
+	t.Run("Invalid connection options", func(t *testing.T) {
+		_, err := db.CreateDBClient(&db.DBConnOptions{Flavor: db.SQLite})
+		assert.ErrorIs(t, err, db.ErrInvalidConnOptions)
+	})
+}
+
+func TestDBSQLiteRunScript(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(t, err)
+	defer dbClient.Destroy()
+
+	t.Run("Runs each statement", func(t *testing.T) {
+		assert := assert.New(t)
+
+		results, err := dbClient.RunScript(`
+			CREATE TABLE notes (body TEXT);
+			INSERT INTO notes VALUES ('first; note'), ('second');
+			SELECT body FROM notes ORDER BY body;
+		`)
+		assert.NoError(err)
+		assert.Len(results, 3)
+
+		assert.True(results[0].NoResultSet)
+		assert.True(results[1].NoResultSet)
+		assert.False(results[2].NoResultSet)
+		assert.Equal("first; note", results[2].Rows[0]["body"].ToString())
+		assert.Equal("second", results[2].Rows[1]["body"].ToString())
+	})
+
+	t.Run("Stops at first failure", func(t *testing.T) {
+		assert := assert.New(t)
+
+		results, err := dbClient.RunScript(`
+			SELECT 1 AS one;
+			SELECT * FROM missing_table;
+			INSERT INTO notes VALUES ('never inserted');
+		`)
+		assert.ErrorContains(err, "Script failed at statement 2 of 3")
+		assert.ErrorIs(err, db.ErrQueryFailed)
+		assert.Len(results, 1)
+
+		result, err := dbClient.Query("SELECT COUNT(*) AS total FROM notes")
+		assert.NoError(err)
+		assert.Equal("2", result.Rows[0]["total"].ToString())
+	})
+}
+
+func TestDBSQLiteStmtType(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	result, err := dbClient.Query("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(err)
+	assert.Equal(db.DDL, result.StmtType)
+
+	result, err = dbClient.Query("WITH names(name) AS (VALUES ('alice')) INSERT INTO users (name) SELECT name FROM names RETURNING id")
+	assert.NoError(err)
+	assert.Equal(db.Insert, result.StmtType)
+	assert.Len(result.Rows, 1)
+
+	result, err = dbClient.Query("/* active */ select name from users")
+	assert.NoError(err)
+	assert.Equal(db.Select, result.StmtType)
+
+	stmt, err := dbClient.Prepare("DELETE FROM users WHERE id = ?")
+	assert.NoError(err)
+	result, err = stmt.Query(1)
+	assert.NoError(err)
+	assert.Equal(db.Delete, result.StmtType)
+}
+
+func TestDBSQLiteRun(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	result, err := dbClient.Run("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(err)
+	assert.True(result.NoResultSet)
+	assert.Equal(db.DDL, result.StmtType)
+
+	result, err = dbClient.Run("INSERT INTO users (name) VALUES (?), (?)", "alice", "bob")
+	assert.NoError(err)
+	assert.True(result.NoResultSet)
+	assert.Equal(int64(2), result.RowsAffected)
+	assert.Equal(db.Insert, result.StmtType)
+
+	result, err = dbClient.Run("INSERT INTO users (name) VALUES ('carol') RETURNING id")
+	assert.NoError(err)
+	assert.False(result.NoResultSet)
+	assert.Equal([]string{"id"}, result.Columns)
+	assert.Equal("3", result.Rows[0]["id"].String)
+
+	// returning only appears in a literal, so there are no rows to read
+	result, err = dbClient.Run("UPDATE users SET name = 'returning' WHERE id > 1")
+	assert.NoError(err)
+	assert.True(result.NoResultSet)
+	assert.Equal(int64(2), result.RowsAffected)
+
+	result, err = dbClient.Run("SELECT name FROM users ORDER BY id")
+	assert.NoError(err)
+	assert.Equal(db.Select, result.StmtType)
+	assert.Len(result.Rows, 3)
+
+	_, err = dbClient.Run("DELETE FROM missing")
+	assert.ErrorIs(err, db.ErrExecFailed)
+}
+
+func TestDBSQLitePoolMode(t *testing.T) {
+	const poolSize = 4
+
+	connOptions := newSQLiteConnOptions(t)
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.PoolSize = poolSize
+
+	dbClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	// Every query holds on to its connection until all of them are running, so each needs a connection of its own
+	cacheSizes := func(assert *assert.Assertions) []string {
+		var started sync.WaitGroup
+		started.Add(poolSize)
+
+		results := make(chan string, poolSize)
+		for range poolSize {
+			go func() {
+				_, err := dbClient.QueryStream("PRAGMA cache_size", func(row map[string]*db.NullString) error {
+					started.Done()
+					started.Wait()
+					results <- row["cache_size"].String
+					return nil
+				})
+				assert.NoError(err)
+			}()
+		}
+
+		var cacheSizes []string
+		for range poolSize {
+			select {
+			case cacheSize := <-results:
+				cacheSizes = append(cacheSizes, cacheSize)
+			case <-time.After(5 * time.Second):
+				assert.Fail("Queries didn't run concurrently")
+				return cacheSizes
+			}
+		}
+		return cacheSizes
+	}
+
+	t.Run("Session init on every connection", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(dbClient.AddSessionInit("PRAGMA cache_size = -1234"))
+		assert.Equal([]string{"-1234", "-1234", "-1234", "-1234"}, cacheSizes(assert))
+
+		// Connections left idle by the last round are dropped, rather than handed out without it
+		assert.NoError(dbClient.AddSessionInit("PRAGMA cache_size = -4321"))
+		assert.Equal([]string{"-4321", "-4321", "-4321", "-4321"}, cacheSizes(assert))
+
+		assert.Error(dbClient.AddSessionInit("PRAGMA nonsense ="))
+		assert.Equal([]string{"PRAGMA cache_size = -1234", "PRAGMA cache_size = -4321"}, dbClient.SessionInit())
+	})
+
+	t.Run("Transaction alongside queries", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+		assert.NoError(err)
+
+		tx, err := dbClient.BeginTx(context.Background())
+		assert.NoError(err)
+		_, err = tx.Exec("INSERT INTO users (name) VALUES ('alice')")
+		assert.NoError(err)
+
+		// Runs on another connection, which can't see the uncommitted row
+		result, err := dbClient.Query("SELECT count(*) AS count FROM users")
+		assert.NoError(err)
+		assert.Equal("0", result.Rows[0]["count"].String)
+
+		assert.NoError(tx.Commit())
+
+		result, err = dbClient.Query("SELECT count(*) AS count FROM users")
+		assert.NoError(err)
+		assert.Equal("1", result.Rows[0]["count"].String)
+	})
+
+	t.Run("Prepared statements and cancel", func(t *testing.T) {
+		assert := assert.New(t)
+
+		stmt, err := dbClient.Prepare("SELECT name FROM users WHERE id = ?")
+		assert.NoError(err)
+		for range poolSize * 2 {
+			result, err := stmt.Query(1)
+			assert.NoError(err)
+			assert.Equal("alice", result.Rows[0]["name"].String)
+		}
+
+		queryErrs := make(chan error, 2)
+		for range 2 {
+			go func() {
+				_, err := dbClient.Query(`
+					WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter)
+					SELECT x FROM counter
+				`)
+				queryErrs <- err
+			}()
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		dbClient.Cancel()
+
+		for range 2 {
+			select {
+			case err := <-queryErrs:
+				assert.ErrorIs(err, db.ErrCancelled)
+			case <-time.After(5 * time.Second):
+				assert.Fail("Every query should be cancelled")
+			}
+		}
+
+		assert.NoError(dbClient.Healthy(context.Background()))
+		assert.NoError(dbClient.Reset())
+	})
+}
+
+func TestDBSQLiteConcurrentQueries(t *testing.T) {
+	const concurrency = 50
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE events (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Queries and Execs", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var wg sync.WaitGroup
+		for idx := range concurrency {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if idx%2 == 0 {
+					_, err := dbClient.Exec("INSERT INTO events (name) VALUES (?)", fmt.Sprint("event ", idx))
+					assert.NoError(err)
+					return
+				}
+
+				result, err := dbClient.Query("SELECT ? AS idx, COUNT(*) AS count FROM events", idx)
+				if assert.NoError(err) {
+					assert.Equal(fmt.Sprint(idx), result.Rows[0]["idx"].String)
+				}
+			}()
+		}
+		wg.Wait()
+
+		result, err := dbClient.Query("SELECT COUNT(*) AS count FROM events")
+		assert.NoError(err)
+		assert.Equal(fmt.Sprint(concurrency/2), result.Rows[0]["count"].String)
+	})
+
+	t.Run("Alongside a transaction", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tx, err := dbClient.BeginTx(context.Background())
+		if !assert.NoError(err) {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for idx := range concurrency {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if idx%2 == 0 {
+					_, err := tx.Exec("INSERT INTO events (name) VALUES (?)", fmt.Sprint("tx event ", idx))
+					assert.NoError(err)
+					return
+				}
+
+				_, err := dbClient.Query("SELECT COUNT(*) AS count FROM events")
+				assert.NoError(err)
+			}()
+		}
+		wg.Wait()
+
+		assert.NoError(tx.Commit())
+
+		result, err := dbClient.Query("SELECT COUNT(*) AS count FROM events WHERE name LIKE 'tx event %'")
+		assert.NoError(err)
+		assert.Equal(fmt.Sprint(concurrency/2), result.Rows[0]["count"].String)
+	})
+}
+
+func TestDBSQLiteListen(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Listen("events")
+	assert.ErrorContains(err, "LISTEN not supported for SQLite")
+	assert.Error(dbClient.Unlisten("events"))
+}
+
+func TestDBSQLiteResultCache(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dbClient.Exec("INSERT INTO users (name) VALUES ('alice')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := &recordingLogger{}
+	dbClient.SetLogger(logger)
+
+	// How many times the statement was actually sent to the database
+	ran := func(statement string) int {
+		count := 0
+		for _, logged := range logger.statements {
+			if logged == statement {
+				count++
+			}
+		}
+		return count
+	}
+
+	const selectUsers = "SELECT name FROM users WHERE id = ?"
+
+	t.Run("Off by default", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		_, err = dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal(2, ran(selectUsers))
+	})
+
+	dbClient.SetResultCacheTTL(time.Minute)
+
+	t.Run("Repeated select", func(t *testing.T) {
+		assert := assert.New(t)
+		logger.statements = nil
+
+		result, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal("alice", result.Rows[0]["name"].String)
+
+		// Changing the returned result doesn't change the cached one
+		result.Rows[0]["name"].String = "mallory"
+		result.Columns[0] = "changed"
+
+		result, err = dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal("alice", result.Rows[0]["name"].String)
+		assert.Equal([]string{"name"}, result.Columns)
+		assert.Equal(1, ran(selectUsers))
+
+		// Different args are a different result
+		result, err = dbClient.Query(selectUsers, 2)
+		assert.NoError(err)
+		assert.Empty(result.Rows)
+		assert.Equal(2, ran(selectUsers))
+	})
+
+	t.Run("Writes invalidate", func(t *testing.T) {
+		assert := assert.New(t)
+		logger.statements = nil
+
+		_, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+
+		_, err = dbClient.Exec("UPDATE users SET name = 'bob' WHERE id = 1")
+		assert.NoError(err)
+
+		result, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal("bob", result.Rows[0]["name"].String)
+		assert.Equal(1, ran(selectUsers))
+
+		dbClient.InvalidateCache()
+		_, err = dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal(2, ran(selectUsers))
+	})
+
+	t.Run("Prepared writes and session changes invalidate", func(t *testing.T) {
+		assert := assert.New(t)
+		logger.statements = nil
+
+		// Cached by the last subtest
+		_, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal(0, ran(selectUsers))
+
+		updateStmt, err := dbClient.Prepare("UPDATE users SET name = ? WHERE id = 1")
+		assert.NoError(err)
+		_, err = updateStmt.Exec("alice")
+		assert.NoError(err)
+
+		result, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal("alice", result.Rows[0]["name"].String)
+		assert.Equal(1, ran(selectUsers))
+
+		// Results may depend on the session, i.e. an unqualified name after changing search_path
+		assert.NoError(dbClient.AddSessionInit("PRAGMA case_sensitive_like = ON"))
+		_, err = dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal(2, ran(selectUsers))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		assert := assert.New(t)
+		logger.statements = nil
+		dbClient.SetResultCacheTTL(50 * time.Millisecond)
+		defer dbClient.SetResultCacheTTL(time.Minute)
+
+		_, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		time.Sleep(100 * time.Millisecond)
+		_, err = dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal(2, ran(selectUsers))
+	})
+
+	t.Run("Only plain selects", func(t *testing.T) {
+		assert := assert.New(t)
+		logger.statements = nil
+
+		const withQuery = "WITH named AS (SELECT name FROM users) SELECT name FROM named"
+		for range 2 {
+			_, err := dbClient.Query(withQuery)
+			assert.NoError(err)
+		}
+		assert.Equal(2, ran(withQuery))
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		assert := assert.New(t)
+		logger.statements = nil
+		dbClient.InvalidateCache()
+
+		result, err := dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		before := result.Rows[0]["name"].String
+
+		tx, err := dbClient.BeginTx(context.Background())
+		assert.NoError(err)
+		_, err = tx.Exec("UPDATE users SET name = 'carol' WHERE id = 1")
+		assert.NoError(err)
+
+		// The connection is shared with the transaction, so this sees its write rather than the cached result
+		result, err = dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal("carol", result.Rows[0]["name"].String)
+
+		assert.NoError(tx.Rollback())
+
+		// Nothing from inside the transaction was kept
+		result, err = dbClient.Query(selectUsers, 1)
+		assert.NoError(err)
+		assert.Equal(before, result.Rows[0]["name"].String)
+		assert.Equal(3, ran(selectUsers))
+	})
+
+	t.Run("Pointer args by value", func(t *testing.T) {
+		assert := assert.New(t)
+		logger.statements = nil
+		dbClient.InvalidateCache()
+
+		firstID, secondID := 1, 1
+		for _, id := range []*int{&firstID, &secondID} {
+			_, err := dbClient.Query(selectUsers, id)
+			assert.NoError(err)
+		}
+		assert.Equal(1, ran(selectUsers))
+
+		secondID = 2
+		result, err := dbClient.Query(selectUsers, &secondID)
+		assert.NoError(err)
+		assert.Empty(result.Rows)
+		assert.Equal(2, ran(selectUsers))
+	})
+}
+
+func TestDBSQLiteQueryIter(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dbClient.Exec("INSERT INTO users (name) VALUES ('alice'), (NULL), ('carol')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Every row", func(t *testing.T) {
+		assert := assert.New(t)
+
+		iter, err := dbClient.QueryIter("SELECT id, name FROM users WHERE id >= ? ORDER BY id", 1)
+		if !assert.NoError(err) {
+			return
+		}
+		defer iter.Close()
+		assert.Equal([]string{"id", "name"}, iter.Columns())
+
+		_, err = iter.Scan()
+		assert.ErrorContains(err, "Next must be called")
+
+		var rows []map[string]string
+		for iter.Next() {
+			row, err := iter.Scan()
+			assert.NoError(err)
+			rows = append(rows, row)
+		}
+		assert.NoError(iter.Err())
+		assert.Equal([]map[string]string{
+			{"id": "1", "name": "alice"},
+			{"id": "2", "name": db.DefaultNullDisplay},
+			{"id": "3", "name": "carol"},
+		}, rows)
+
+		// Reaching the end already closed it
+		assert.NoError(iter.Close())
+		assert.False(iter.Next())
+	})
+
+	t.Run("Stopping early", func(t *testing.T) {
+		assert := assert.New(t)
+
+		iter, err := dbClient.QueryIter("SELECT name FROM users ORDER BY id")
+		if !assert.NoError(err) {
+			return
+		}
+		assert.True(iter.Next())
+		assert.NoError(iter.Close())
+		assert.NoError(iter.Close())
+		assert.NoError(iter.Err())
+
+		assert.False(iter.Next())
+		_, err = iter.Scan()
+		assert.ErrorContains(err, "RowIter is closed")
+
+		// The connection was handed back
+		result, err := dbClient.Query("SELECT COUNT(*) AS count FROM users")
+		assert.NoError(err)
+		assert.Equal("3", result.Rows[0]["count"].String)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := dbClient.QueryIter("SELECT * FROM missing")
+		assert.ErrorIs(err, db.ErrQueryFailed)
+
+		// Still usable after a failed query
+		iter, err := dbClient.QueryIter("SELECT 1 AS one")
+		if !assert.NoError(err) {
+			return
+		}
+		defer iter.Close()
+		assert.True(iter.Next())
+	})
+}
+
+func TestDBSQLiteQueryNamed(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, role TEXT)")
+	assert.NoError(err)
+	_, err = dbClient.Exec("INSERT INTO users (name, role) VALUES ('alice', 'admin'), ('bob', 'user'), ('carol', 'admin')")
+	assert.NoError(err)
+
+	// A name used twice is bound twice
+	result, err := dbClient.QueryNamed(
+		"SELECT name FROM users WHERE role = :role AND (name = :name OR :name = '') ORDER BY id",
+		map[string]any{"role": "admin", "name": ""},
+	)
+	assert.NoError(err)
+	if assert.Len(result.Rows, 2) {
+		assert.Equal("alice", result.Rows[0]["name"].String)
+		assert.Equal("carol", result.Rows[1]["name"].String)
+	}
+
+	_, err = dbClient.QueryNamed("SELECT name FROM users WHERE id = :id", map[string]any{"name": "alice"})
+	assert.ErrorIs(err, db.ErrQueryFailed)
+	assert.ErrorContains(err, "Failed to bind named parameters")
+}
+
+func TestDBSQLiteMaxWidth(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	result, err := dbClient.Query(`
+		SELECT 'ab' AS short_header, '日本語' AS wide, NULL AS n, 'two' || char(10) || 'lines' AS multiline
+		UNION ALL
+		SELECT 'abcdefghijklmnop', 'x', NULL, ''
+	`)
+	assert.NoError(err)
+	// Wide characters take two cells, a line break one like in RenderTable
+	assert.Equal([]int{16, 6, len(db.DefaultNullDisplay), 9}, result.MaxWidth)
+
+	dbClient.SetNullDisplay("")
+	result, err = dbClient.Query("SELECT NULL AS n")
+	assert.NoError(err)
+	assert.Equal([]int{1}, result.MaxWidth)
+}
+
+func TestDBSQLiteFromDB(t *testing.T) {
+	assert := assert.New(t)
+
+	sqlDB, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	dbClient := db.NewDBClientFromDB(sqlDB, db.SQLite)
+
+	_, err = dbClient.Exec("CREATE TABLE users (name TEXT)")
+	assert.NoError(err)
+	_, err = dbClient.Exec("INSERT INTO users VALUES ('alice')")
+	assert.NoError(err)
+
+	result, err := dbClient.Query("SELECT name FROM users")
+	assert.NoError(err)
+	assert.Equal("alice", result.Rows[0]["name"].String)
+
+	// The caller still owns the database
+	assert.NoError(dbClient.Destroy())
+	assert.NoError(sqlDB.Ping())
+
+	var count int
+	assert.NoError(sqlDB.QueryRow("SELECT COUNT(*) FROM users").Scan(&count))
+	assert.Equal(1, count)
+}
+
+func TestDBSQLiteCreateDBClientContext(t *testing.T) {
+	assert := assert.New(t)
+
+	connOptions := newSQLiteConnOptions(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dbClient, err := db.CreateDBClientContext(ctx, &connOptions)
+	assert.NoError(err)
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Query("SELECT 1")
+	assert.NoError(err)
+
+	// Calls without a context of their own use the one the client was created with
+	cancel()
+	_, err = dbClient.Query("SELECT 1")
+	assert.ErrorIs(err, context.Canceled)
+
+	_, err = dbClient.QueryContext(context.Background(), "SELECT 1")
+	assert.NoError(err)
+}
+
+func TestDBSQLiteQueryOpen(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	_, err = dbClient.Exec("CREATE TABLE events (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dbClient.Exec("INSERT INTO events (name) VALUES ('a'), ('b'), ('c'), ('d'), (NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(result *db.QueryResult) []string {
+		var names []string
+		for _, row := range result.Rows {
+			names = append(names, row["name"].Display(db.DefaultNullDisplay))
+		}
+		return names
+	}
+
+	t.Run("Fetch in batches", func(t *testing.T) {
+		assert := assert.New(t)
+
+		openQuery, err := dbClient.QueryOpen("SELECT id, name FROM events WHERE id >= ? ORDER BY id", 1)
+		if !assert.NoError(err) {
+			return
+		}
+		defer openQuery.Close()
+		assert.Equal([]string{"id", "name"}, openQuery.Columns())
+
+		result, more, err := openQuery.Fetch(2)
+		assert.NoError(err)
+		assert.True(more)
+		assert.Equal([]string{"a", "b"}, names(result))
+		assert.Equal([]string{"id", "name"}, result.Columns)
+		assert.Equal(db.Select, result.StmtType)
+
+		// The rows are still open on the connection
+		_, err = dbClient.Query("SELECT 1")
+		assert.ErrorIs(err, db.ErrQueryOpen)
+		_, err = dbClient.Exec("DELETE FROM events")
+		assert.ErrorIs(err, db.ErrQueryOpen)
+		_, err = dbClient.BeginTx(context.Background())
+		assert.ErrorIs(err, db.ErrQueryOpen)
+		_, err = dbClient.QueryOpen("SELECT 1")
+		assert.ErrorIs(err, db.ErrQueryOpen)
+
+		result, more, err = openQuery.Fetch(2)
+		assert.NoError(err)
+		assert.True(more)
+		assert.Equal([]string{"c", "d"}, names(result))
+
+		result, more, err = openQuery.Fetch(2)
+		assert.NoError(err)
+		assert.False(more)
+		assert.Equal([]string{db.DefaultNullDisplay}, names(result))
+
+		// Reaching the end closes the rows
+		result, err = dbClient.Query("SELECT COUNT(*) AS total FROM events")
+		assert.NoError(err)
+		assert.Equal("5", result.Rows[0]["total"].String)
+
+		result, more, err = openQuery.Fetch(2)
+		assert.NoError(err)
+		assert.False(more)
+		assert.Empty(result.Rows)
+	})
+
+	t.Run("Exactly the last row", func(t *testing.T) {
+		assert := assert.New(t)
+
+		openQuery, err := dbClient.QueryOpen("SELECT name FROM events WHERE id <= 2 ORDER BY id")
+		if !assert.NoError(err) {
+			return
+		}
+		defer openQuery.Close()
+
+		result, more, err := openQuery.Fetch(2)
+		assert.NoError(err)
+		assert.False(more)
+		assert.Equal([]string{"a", "b"}, names(result))
+
+		_, err = dbClient.Query("SELECT 1")
+		assert.NoError(err)
+	})
+
+	t.Run("Close early", func(t *testing.T) {
+		assert := assert.New(t)
+
+		openQuery, err := dbClient.QueryOpen("SELECT name FROM events ORDER BY id")
+		if !assert.NoError(err) {
+			return
+		}
+
+		_, more, err := openQuery.Fetch(1)
+		assert.NoError(err)
+		assert.True(more)
+
+		assert.NoError(openQuery.Close())
+		assert.NoError(openQuery.Close())
+
+		_, _, err = openQuery.Fetch(1)
+		assert.ErrorContains(err, "closed")
+
+		_, err = dbClient.Query("SELECT 1")
+		assert.NoError(err)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := dbClient.QueryOpen("SELECT * FROM missing")
+		assert.ErrorIs(err, db.ErrQueryFailed)
+
+		_, err = dbClient.Query("SELECT 1")
+		assert.NoError(err)
+	})
+
+	t.Run("Pool mode", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clientOptions := db.DefaultDBClientOptions()
+		clientOptions.PoolSize = 2
+		pooledClient, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+		if !assert.NoError(err) {
+			return
+		}
+		defer pooledClient.Destroy()
+
+		openQuery, err := pooledClient.QueryOpen("SELECT name FROM events ORDER BY id")
+		if !assert.NoError(err) {
+			return
+		}
+		defer openQuery.Close()
+
+		result, more, err := openQuery.Fetch(3)
+		assert.NoError(err)
+		assert.True(more)
+		assert.Equal([]string{"a", "b", "c"}, names(result))
+
+		// The open query has a connection to itself
+		result, err = pooledClient.Query("SELECT COUNT(*) AS total FROM events")
+		assert.NoError(err)
+		assert.Equal("5", result.Rows[0]["total"].String)
+
+		result, more, err = openQuery.Fetch(3)
+		assert.NoError(err)
+		assert.False(more)
+		assert.Equal([]string{"d", db.DefaultNullDisplay}, names(result))
+	})
+}
+
+func TestDBSQLiteExecTemplate(t *testing.T) {
+	assert := assert.New(t)
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	idents := map[string]string{"table": `audit "log"; DROP TABLE x`, "column": "message"}
+
+	_, err = dbClient.ExecTemplate("CREATE TABLE {{.table}} ({{.column}} TEXT)", idents)
+	assert.NoError(err)
+
+	result, err := dbClient.ExecTemplate("INSERT INTO {{.table}} ({{.column}}) VALUES (?), (?)", idents, "first", "second")
+	assert.NoError(err)
+	assert.Equal(int64(2), result.RowsAffected)
+
+	rows, err := dbClient.Query(`SELECT message FROM "audit ""log""; DROP TABLE x" ORDER BY message`)
+	assert.NoError(err)
+	assert.Len(rows.Rows, 2)
+	assert.Equal("first", rows.Rows[0]["message"].String)
+
+	_, err = dbClient.ExecTemplate("DELETE FROM {{.missing}}", idents)
+	assert.ErrorIs(err, db.ErrExecFailed)
+	assert.ErrorContains(err, "missing")
+
+	_, err = dbClient.ExecTemplate("DELETE FROM {{.table", idents)
+	assert.ErrorIs(err, db.ErrExecFailed)
+	assert.ErrorContains(err, "Invalid statement template")
+}
+
+func TestDBSQLiteQueryStreamNDJSON(t *testing.T) {
+	assert := assert.New(t)
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	var out strings.Builder
+	_, err = dbClient.QueryStream("SELECT 1 AS id, NULL AS name UNION ALL SELECT 2, 'bob'", db.NDJSONRowWriter(&out))
+	assert.NoError(err)
+	assert.Equal(
+		`{"id":"1","name":null}`+"\n"+
+			`{"id":"2","name":"bob"}`+"\n",
+		out.String(),
+	)
+}
+
+func TestDBSQLiteBoolDisplay(t *testing.T) {
+	connOptions := newSQLiteConnOptions(t)
+
+	dbClient, err := db.CreateDBClient(&connOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbClient.Destroy()
+
+	_, err = dbClient.RunScript(`
+		CREATE TABLE flags (id INTEGER, enabled BOOLEAN, level TINYINT);
+		INSERT INTO flags VALUES (1, 1, 1), (2, 0, 0), (3, NULL, 5);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	displayed := func(assert *assert.Assertions, column string) []string {
+		result, err := dbClient.Query("SELECT * FROM flags ORDER BY id")
+		assert.NoError(err)
+
+		var values []string
+		for _, row := range result.Rows {
+			values = append(values, result.DisplayValue(row[column]))
+		}
+		return values
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal([]string{"true", "false", db.DefaultNullDisplay}, displayed(assert, "enabled"))
+		// Not a boolean column
+		assert.Equal([]string{"1", "0", "5"}, displayed(assert, "level"))
+
+		result, err := dbClient.Query("SELECT enabled FROM flags WHERE id = 1")
+		assert.NoError(err)
+		assert.Equal("1", result.Rows[0]["enabled"].RawValue())
+
+		typedRows, err := result.TypedRows()
+		assert.NoError(err)
+		assert.Equal([]map[string]any{{"enabled": true}}, typedRows)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		assert := assert.New(t)
+
+		// Only MySQL reports booleans as TINYINT
+		dbClient.SetBoolDisplay(db.BoolDisplay{True: "yes", False: "no", MySQLTinyInt: true})
+		defer dbClient.SetBoolDisplay(db.DefaultBoolDisplay)
+
+		assert.Equal([]string{"yes", "no", db.DefaultNullDisplay}, displayed(assert, "enabled"))
+		assert.Equal([]string{"1", "0", "5"}, displayed(assert, "level"))
+
+		result, err := dbClient.Query("SELECT enabled FROM flags WHERE id = 2")
+		assert.NoError(err)
+
+		var out strings.Builder
+		assert.NoError(result.WriteJSONWithOptions(&out, db.JSONOptions{CoerceTypes: true}))
+		assert.Equal(`[{"enabled":false}]`, out.String())
+	})
+
+	t.Run("As returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dbClient.SetBoolDisplay(db.BoolDisplay{})
+		defer dbClient.SetBoolDisplay(db.DefaultBoolDisplay)
+
+		assert.Equal([]string{"1", "0", db.DefaultNullDisplay}, displayed(assert, "enabled"))
+	})
+}
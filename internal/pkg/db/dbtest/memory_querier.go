@@ -0,0 +1,169 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+)
+
+// A db.Querier returning canned results by statement text, without a database
+// Statements without a registered result fail, so tests notice unexpected queries
+type MemoryQuerier struct {
+	mu          sync.Mutex
+	results     map[string]*db.QueryResult
+	execResults map[string]*db.ExecResult
+	errs        map[string]error
+	statements  []string
+	destroyed   bool
+}
+
+var _ db.Querier = (*MemoryQuerier)(nil)
+
+func NewMemoryQuerier() *MemoryQuerier {
+	return &MemoryQuerier{
+		results:     map[string]*db.QueryResult{},
+		execResults: map[string]*db.ExecResult{},
+		errs:        map[string]error{},
+	}
+}
+
+// Return result whenever statement is queried, nil stands for a statement without a result set (i.e. DDL)
+func (querier *MemoryQuerier) SetResult(statement string, result *db.QueryResult) *MemoryQuerier {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	querier.results[statement] = result
+	return querier
+}
+
+// Return result whenever statement is executed
+func (querier *MemoryQuerier) SetExecResult(statement string, result *db.ExecResult) *MemoryQuerier {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	querier.execResults[statement] = result
+	return querier
+}
+
+// Fail with err whenever statement is queried or executed, takes precedence over any result
+func (querier *MemoryQuerier) SetError(statement string, err error) *MemoryQuerier {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	querier.errs[statement] = err
+	return querier
+}
+
+// Every statement queried or executed so far, in order
+func (querier *MemoryQuerier) Statements() []string {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	return append([]string(nil), querier.statements...)
+}
+
+func (querier *MemoryQuerier) IsDestroyed() bool {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	return querier.destroyed
+}
+
+func (querier *MemoryQuerier) Query(statement string, args ...any) (*db.QueryResult, error) {
+	return querier.QueryContext(context.Background(), statement, args...)
+}
+
+func (querier *MemoryQuerier) QueryContext(ctx context.Context, statement string, args ...any) (*db.QueryResult, error) {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	if err := querier.record(ctx, statement); err != nil {
+		return nil, err
+	}
+
+	result, ok := querier.results[statement]
+	if !ok {
+		return nil, errors.Join(db.ErrQueryFailed, fmt.Errorf("No result registered for query %q", statement))
+	} else if result == nil {
+		return &db.QueryResult{
+			Rows:        []map[string]*db.NullString{},
+			Columns:     []string{},
+			ColumnTypes: []string{},
+			NoResultSet: true,
+		}, nil
+	}
+
+	return result, nil
+}
+
+func (querier *MemoryQuerier) Exec(statement string, args ...any) (*db.ExecResult, error) {
+	return querier.ExecContext(context.Background(), statement, args...)
+}
+
+func (querier *MemoryQuerier) ExecContext(ctx context.Context, statement string, args ...any) (*db.ExecResult, error) {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	if err := querier.record(ctx, statement); err != nil {
+		return nil, err
+	}
+
+	result, ok := querier.execResults[statement]
+	if !ok {
+		return nil, errors.Join(db.ErrExecFailed, fmt.Errorf("No result registered for statement %q", statement))
+	}
+
+	return result, nil
+}
+
+// Nothing is ever running, queries return immediately
+func (querier *MemoryQuerier) Cancel() {}
+
+func (querier *MemoryQuerier) Destroy() error {
+	querier.mu.Lock()
+	defer querier.mu.Unlock()
+
+	querier.destroyed = true
+	return nil
+}
+
+// Must be called with mu held
+func (querier *MemoryQuerier) record(ctx context.Context, statement string) error {
+	if querier.destroyed {
+		return errors.New("Querier was destroyed")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	querier.statements = append(querier.statements, statement)
+	return querier.errs[statement]
+}
+
+// Build a result from plain values, nil becomes NULL and anything else is formatted with fmt.Sprint
+// ex: NewQueryResult([]string{"id", "name"}, []any{1, "Alice"}, []any{2, nil})
+func NewQueryResult(columns []string, rows ...[]any) *db.QueryResult {
+	result := &db.QueryResult{
+		Columns:     columns,
+		ColumnTypes: make([]string, len(columns)),
+		Rows:        make([]map[string]*db.NullString, 0, len(rows)),
+	}
+
+	for _, values := range rows {
+		row := make(map[string]*db.NullString, len(columns))
+		for columnIdx, column := range columns {
+			value := &db.NullString{}
+			if columnIdx < len(values) && values[columnIdx] != nil {
+				value.NullString = sql.NullString{String: fmt.Sprint(values[columnIdx]), Valid: true}
+			}
+			row[column] = value
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result
+}
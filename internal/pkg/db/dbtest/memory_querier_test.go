@@ -0,0 +1,61 @@
+package dbtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/azvaliev/sql/internal/pkg/db/dbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryQuerier(t *testing.T) {
+	assert := assert.New(t)
+
+	var querier db.Querier = dbtest.NewMemoryQuerier().
+		SetResult("SELECT * FROM users", dbtest.NewQueryResult(
+			[]string{"id", "name"},
+			[]any{1, "Alice"},
+			[]any{2, nil},
+		)).
+		SetResult("CREATE TABLE posts (id INT)", nil).
+		SetExecResult("DELETE FROM users", &db.ExecResult{RowsAffected: 2}).
+		SetError("SELECT broken", errors.New("syntax error"))
+
+	result, err := querier.Query("SELECT * FROM users")
+	assert.NoError(err)
+	assert.Equal([]string{"id", "name"}, result.Columns)
+	assert.Equal("Alice", result.Rows[0]["name"].ToString())
+	assert.True(result.Rows[1]["name"].IsNull())
+
+	result, err = querier.Query("CREATE TABLE posts (id INT)")
+	assert.NoError(err)
+	assert.True(result.NoResultSet)
+
+	execResult, err := querier.Exec("DELETE FROM users")
+	assert.NoError(err)
+	assert.Equal(int64(2), execResult.RowsAffected)
+
+	_, err = querier.Query("SELECT broken")
+	assert.EqualError(err, "syntax error")
+
+	_, err = querier.Query("SELECT unexpected")
+	assert.ErrorIs(err, db.ErrQueryFailed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = querier.QueryContext(ctx, "SELECT * FROM users")
+	assert.ErrorIs(err, context.Canceled)
+
+	memoryQuerier := querier.(*dbtest.MemoryQuerier)
+	assert.Equal(
+		[]string{"SELECT * FROM users", "CREATE TABLE posts (id INT)", "DELETE FROM users", "SELECT broken", "SELECT unexpected"},
+		memoryQuerier.Statements(),
+	)
+
+	assert.NoError(querier.Destroy())
+	assert.True(memoryQuerier.IsDestroyed())
+	_, err = querier.Query("SELECT * FROM users")
+	assert.Error(err)
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Opens a network connection to addr, same signature as net.Dialer.DialContext
+// network is tcp, or unix when connecting through a socket
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// The custom dialer set on the connection options, nil dials with the driver's default
+// Only DBConnOptions can carry one, same as the SSH tunnel
+func dialFuncFor(dsnProducer ConnManager) DialFunc {
+	connOptions, ok := dsnProducer.(*DBConnOptions)
+	if !ok {
+		return nil
+	}
+
+	return connOptions.Dial
+}
+
+// Dial registrations need a unique network name, one is taken per DBClient
+var mysqlDialNetworkCount atomic.Uint64
+
+// The MySQL driver only takes a custom dialer registered under a network name, which the DSN then refers to
+// The name is registered once per DBClient and reused, i.e. when SwitchDatabase reopens the database
+func (db *DBClient) withMySQLDial(dataSourceName string, dial DialFunc) (string, error) {
+	config, err := mysql.ParseDSN(dataSourceName)
+	if err != nil {
+		return "", err
+	}
+
+	if db.mysqlDialNetwork == "" {
+		network := config.Net
+		db.mysqlDialNetwork = fmt.Sprint("dial-", mysqlDialNetworkCount.Add(1))
+		mysql.RegisterDialContext(db.mysqlDialNetwork, func(ctx context.Context, addr string) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		})
+	}
+	config.Net = db.mysqlDialNetwork
+
+	return config.FormatDSN(), nil
+}
+
+// pgx looks up the host and dials each address itself, leave the lookup to dial so it gets the host name like for MySQL
+func setPostgresDial(config *pgconn.Config, dial DialFunc) {
+	if dial == nil {
+		return
+	}
+
+	config.DialFunc = pgconn.DialFunc(dial)
+	config.LookupFunc = func(_ context.Context, host string) ([]string, error) {
+		return []string{host}, nil
+	}
+}
@@ -0,0 +1,106 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// Records what it was asked to dial, then refuses, so no server is needed
+type recordingDialer struct {
+	mu    sync.Mutex
+	dials []string
+}
+
+var errDialRefused = errors.New("dial refused by test")
+
+func (dialer *recordingDialer) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+
+	dialer.dials = append(dialer.dials, network+" "+addr)
+	return nil, errDialRefused
+}
+
+func (dialer *recordingDialer) Dialed() []string {
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+
+	return dialer.dials
+}
+
+func TestDBCustomDial(t *testing.T) {
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.PingAttempts = 1
+	clientOptions.ConnectTimeout = time.Second
+
+	t.Run("MySQL", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dialer := &recordingDialer{}
+		connOptions := db.DBConnOptions{
+			Flavor: db.MySQL,
+			Host:   "db.internal",
+			User:   "user",
+			Port:   3306,
+			Dial:   dialer.Dial,
+		}
+
+		_, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+		assert.ErrorIs(err, db.ErrConnectionFailed)
+		assert.Contains(dialer.Dialed(), "tcp db.internal:3306")
+
+		// Testing the connection dials the same way
+		dialer = &recordingDialer{}
+		connOptions.Dial = dialer.Dial
+		err = db.TestConnection(&connOptions, time.Second)
+		assert.ErrorIs(err, db.ErrConnectionFailed)
+		assert.Contains(dialer.Dialed(), "tcp db.internal:3306")
+	})
+
+	t.Run("PostgreSQL", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dialer := &recordingDialer{}
+		connOptions := db.DBConnOptions{
+			Flavor:       db.PostgreSQL,
+			Host:         "db.internal",
+			DatabaseName: "test",
+			User:         "user",
+			Port:         5432,
+			Dial:         dialer.Dial,
+		}
+
+		_, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+		assert.ErrorIs(err, db.ErrConnectionFailed)
+		assert.Contains(dialer.Dialed(), "tcp db.internal:5432")
+
+		// Testing the connection dials the same way
+		dialer = &recordingDialer{}
+		connOptions.Dial = dialer.Dial
+		err = db.TestConnection(&connOptions, time.Second)
+		assert.ErrorIs(err, db.ErrConnectionFailed)
+		assert.Contains(dialer.Dialed(), "tcp db.internal:5432")
+	})
+
+	t.Run("Unsupported flavor", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dialer := &recordingDialer{}
+		connOptions := db.DBConnOptions{
+			Flavor:       db.SQLite,
+			DatabaseName: "test.db",
+			Dial:         dialer.Dial,
+		}
+
+		_, err := db.CreateDBClient(&connOptions)
+		assert.ErrorIs(err, db.ErrInvalidConnOptions)
+		assert.ErrorContains(err, "Custom dialing is not supported for SQLite")
+	})
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// What changed between two runs of the same query, rows are matched by a key column
+type ResultDiff struct {
+	// Column order of the newer result
+	Columns []string
+	// Rows only in the newer result, in its order
+	Added []map[string]*NullString
+	// Rows only in the older result, in its order
+	Removed []map[string]*NullString
+	// Rows in both with at least one differing value, in the newer result's order
+	Changed []RowChange
+}
+
+type RowChange struct {
+	Key    *NullString
+	Before map[string]*NullString
+	After  map[string]*NullString
+	// Which columns differ, in column order
+	ChangedColumns []string
+}
+
+func (diff *ResultDiff) IsEmpty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// Compare before and after, matching rows by the value of keyColumn (i.e. a primary key)
+// Both results must have the same columns, in any order, and unique values in keyColumn
+func Diff(before *QueryResult, after *QueryResult, keyColumn string) (*ResultDiff, error) {
+	if before == nil || after == nil {
+		return nil, errors.New("Cannot diff a missing result")
+	}
+
+	if !sameColumns(before.Columns, after.Columns) {
+		return nil, fmt.Errorf("Results have different columns: %v and %v", before.Columns, after.Columns)
+	}
+	if !slices.Contains(after.Columns, keyColumn) {
+		return nil, fmt.Errorf("Key column %s is not in the results", keyColumn)
+	}
+
+	beforeByKey, err := rowsByKey(before.Rows, keyColumn)
+	if err != nil {
+		return nil, errors.Join(errors.New("Failed to diff the older result"), err)
+	}
+	afterByKey, err := rowsByKey(after.Rows, keyColumn)
+	if err != nil {
+		return nil, errors.Join(errors.New("Failed to diff the newer result"), err)
+	}
+
+	diff := &ResultDiff{
+		Columns: after.Columns,
+	}
+
+	for _, afterRow := range after.Rows {
+		key := rowKeyOf(afterRow[keyColumn])
+
+		beforeRow, ok := beforeByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, afterRow)
+			continue
+		}
+
+		var changedColumns []string
+		for _, column := range after.Columns {
+			if !sameValue(beforeRow[column], afterRow[column]) {
+				changedColumns = append(changedColumns, column)
+			}
+		}
+		if len(changedColumns) > 0 {
+			diff.Changed = append(diff.Changed, RowChange{
+				Key:            afterRow[keyColumn],
+				Before:         beforeRow,
+				After:          afterRow,
+				ChangedColumns: changedColumns,
+			})
+		}
+	}
+
+	for _, beforeRow := range before.Rows {
+		if _, ok := afterByKey[rowKeyOf(beforeRow[keyColumn])]; !ok {
+			diff.Removed = append(diff.Removed, beforeRow)
+		}
+	}
+
+	return diff, nil
+}
+
+// Keeps a NULL key apart from the text "NULL"
+type rowKey struct {
+	valid bool
+	value string
+}
+
+func rowKeyOf(value *NullString) rowKey {
+	if value == nil || !value.Valid {
+		return rowKey{}
+	}
+
+	return rowKey{valid: true, value: value.String}
+}
+
+func rowsByKey(rows []map[string]*NullString, keyColumn string) (map[rowKey]map[string]*NullString, error) {
+	byKey := make(map[rowKey]map[string]*NullString, len(rows))
+	for _, row := range rows {
+		key := rowKeyOf(row[keyColumn])
+		if _, ok := byKey[key]; ok {
+			return nil, fmt.Errorf("Key column %s has duplicate value %s", keyColumn, row[keyColumn].ToString())
+		}
+
+		byKey[key] = row
+	}
+
+	return byKey, nil
+}
+
+func sameColumns(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA, sortedB := slices.Clone(a), slices.Clone(b)
+	slices.Sort(sortedA)
+	slices.Sort(sortedB)
+	return slices.Equal(sortedA, sortedB)
+}
+
+func sameValue(a *NullString, b *NullString) bool {
+	return rowKeyOf(a) == rowKeyOf(b)
+}
@@ -0,0 +1,93 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	newResult := func(columns []string, rows ...map[string]*db.NullString) *db.QueryResult {
+		return &db.QueryResult{Columns: columns, Rows: rows}
+	}
+
+	t.Run("Added Removed And Changed", func(t *testing.T) {
+		assert := assert.New(t)
+
+		before := newResult(
+			[]string{"id", "status"},
+			map[string]*db.NullString{"id": newTestNullString("1"), "status": newTestNullString("pending")},
+			map[string]*db.NullString{"id": newTestNullString("2"), "status": newTestNullString("pending")},
+			map[string]*db.NullString{"id": newTestNullString("3"), "status": newTestNullString("done")},
+		)
+		// Column order doesn't matter
+		after := newResult(
+			[]string{"status", "id"},
+			map[string]*db.NullString{"id": newTestNullString("1"), "status": newTestNullString("done")},
+			map[string]*db.NullString{"id": newTestNullString("3"), "status": newTestNullString("done")},
+			map[string]*db.NullString{"id": newTestNullString("4"), "status": newTestNull()},
+		)
+
+		diff, err := db.Diff(before, after, "id")
+		assert.NoError(err)
+		assert.False(diff.IsEmpty())
+		assert.Equal([]string{"status", "id"}, diff.Columns)
+
+		if assert.Len(diff.Added, 1) {
+			assert.Equal("4", diff.Added[0]["id"].String)
+		}
+		if assert.Len(diff.Removed, 1) {
+			assert.Equal("2", diff.Removed[0]["id"].String)
+		}
+		if assert.Len(diff.Changed, 1) {
+			change := diff.Changed[0]
+			assert.Equal("1", change.Key.String)
+			assert.Equal([]string{"status"}, change.ChangedColumns)
+			assert.Equal("pending", change.Before["status"].String)
+			assert.Equal("done", change.After["status"].String)
+		}
+	})
+
+	t.Run("NULL Is Not The Text NULL", func(t *testing.T) {
+		assert := assert.New(t)
+
+		before := newResult(
+			[]string{"id", "note"},
+			map[string]*db.NullString{"id": newTestNullString("1"), "note": newTestNull()},
+		)
+		after := newResult(
+			[]string{"id", "note"},
+			map[string]*db.NullString{"id": newTestNullString("1"), "note": newTestNullString("NULL")},
+		)
+
+		diff, err := db.Diff(before, after, "id")
+		assert.NoError(err)
+		assert.Len(diff.Changed, 1)
+
+		diff, err = db.Diff(before, before, "id")
+		assert.NoError(err)
+		assert.True(diff.IsEmpty())
+	})
+
+	t.Run("Invalid Inputs", func(t *testing.T) {
+		assert := assert.New(t)
+
+		idOnly := newResult([]string{"id"}, map[string]*db.NullString{"id": newTestNullString("1")})
+		withName := newResult([]string{"id", "name"})
+
+		_, err := db.Diff(idOnly, withName, "id")
+		assert.ErrorContains(err, "Results have different columns")
+
+		_, err = db.Diff(idOnly, idOnly, "missing")
+		assert.ErrorContains(err, "Key column missing is not in the results")
+
+		duplicates := newResult(
+			[]string{"id"},
+			map[string]*db.NullString{"id": newTestNullString("1")},
+			map[string]*db.NullString{"id": newTestNullString("1")},
+		)
+		_, err = db.Diff(duplicates, idOnly, "id")
+		assert.ErrorContains(err, "Key column id has duplicate value 1")
+	})
+}
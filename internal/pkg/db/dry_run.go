@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// When enabled, statements that could modify data are validated rather than executed
+// through EXPLAIN where the database supports it, otherwise by preparing them
+// SELECT/SHOW/EXPLAIN/DESCRIBE still run as usual
+func (db *DBClient) SetDryRun(dryRun bool) {
+	db.dryRun = dryRun
+}
+
+func (db *DBClient) IsDryRun() bool {
+	return db.dryRun
+}
+
+const DryRunColumn = "Dry Run"
+
+func (db *DBClient) shouldDryRun(statement string) bool {
+	return db.dryRun && !isReadOnlyStatement(statement, db.connManager.GetFlavor())
+}
+
+// Result shown in place of running the statement
+func newDryRunQueryResult(rowsEstimate int64) *QueryResult {
+	message := "Statement is valid, not executed"
+	if rowsEstimate >= 0 {
+		message = fmt.Sprintf("Statement is valid, not executed. Would affect ~%d row(s)", rowsEstimate)
+	}
+
+	return &QueryResult{
+		Columns:     []string{DryRunColumn},
+		ColumnTypes: []string{""},
+		MaxWidth:    []int{max(tableCellWidth(DryRunColumn), tableCellWidth(message))},
+		Rows: []map[string]*NullString{
+			{DryRunColumn: &NullString{NullString: sql.NullString{String: message, Valid: true}}},
+		},
+	}
+}
+
+// Check the statement is valid without running it
+// Returns the estimated number of rows it would affect, or -1 if the database can't tell
+func (db *DBClient) dryRunStatement(ctx context.Context, statement string, args []any) (rowsEstimate int64, err error) {
+	conn, err := db.getConnection(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	return db.dryRunStatementOn(ctx, conn, statement, args)
+}
+
+// The connection, or a transaction to validate the statement within
+type dryRunner interface {
+	PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error)
+	QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error)
+}
+
+// Same as dryRunStatement, validating the statement through runner
+func (db *DBClient) dryRunStatementOn(ctx context.Context, runner dryRunner, statement string, args []any) (rowsEstimate int64, err error) {
+	// Nothing is run, so there's nothing to confirm in safe mode
+	if err = db.assertNotReadOnly(statement); err != nil {
+		return -1, err
+	}
+	// Validating the first could still run the rest, i.e. SQLite goes on to the DROP in EXPLAIN SELECT 1; DROP TABLE users
+	if len(SplitStatements(statement, db.connManager.GetFlavor())) > 1 {
+		return -1, errors.New("Dry run only validates a single statement at a time")
+	}
+
+	flavor := db.connManager.GetFlavor()
+	if !canExplainStatement(flavor, statement) {
+		sqlStmt, err := runner.PreparexContext(ctx, statement)
+		if err != nil {
+			return -1, errors.Join(
+				ErrQueryFailed,
+				asQueryError(err),
+			)
+		}
+
+		return -1, sqlStmt.Close()
+	}
+
+	rows, err := runner.QueryxContext(ctx, fmt.Sprint("EXPLAIN ", statement), args...)
+	if err != nil {
+		return -1, errors.Join(
+			ErrQueryFailed,
+			asQueryError(err),
+		)
+	}
+
+	plan, err := scanQueryResult(ctx, rows, db.lookupScanOptions(), nil)
+	if err != nil {
+		return -1, err
+	}
+
+	return estimateAffectedRows(flavor, plan), nil
+}
+
+func canExplainStatement(flavor DBFlavor, statement string) bool {
+	switch flavor {
+	case MySQL:
+		return slices.Contains([]string{"INSERT", "UPDATE", "DELETE", "REPLACE"}, statementLeadingKeyword(statement))
+	case PostgreSQL:
+		return slices.Contains([]string{"INSERT", "UPDATE", "DELETE", "MERGE"}, statementLeadingKeyword(statement))
+	case SQLite:
+		// Compiles any statement to bytecode without running it
+		return true
+	default:
+		return false
+	}
+}
+
+// Matches the estimate in a PostgreSQL plan node, ex: Seq Scan on users  (cost=0.00..35.50 rows=2550 width=6)
+var postgresPlanRowsRegExp = regexp.MustCompile(`rows=(\d+)`)
+
+// The planner's estimate of rows touched, or -1 if the plan doesn't include one
+func estimateAffectedRows(flavor DBFlavor, plan *QueryResult) int64 {
+	if plan == nil || len(plan.Rows) == 0 {
+		return -1
+	}
+
+	switch flavor {
+	case MySQL:
+		{
+			rows, ok := plan.Rows[0]["rows"]
+			if !ok || rows.IsNull() {
+				return -1
+			}
+
+			estimate, err := strconv.ParseInt(rows.String, 10, 64)
+			if err != nil {
+				return -1
+			}
+			return estimate
+		}
+	case PostgreSQL:
+		{
+			for _, row := range plan.Rows {
+				planLine := strings.TrimSpace(row["QUERY PLAN"].String)
+
+				// The top Insert/Update/Delete node reports the rows it returns (0 without RETURNING), not those it touches
+				isModifyNode := slices.ContainsFunc([]string{"Insert on", "Update on", "Delete on", "Merge on"}, func(prefix string) bool {
+					return strings.HasPrefix(planLine, prefix)
+				})
+				if isModifyNode {
+					continue
+				}
+
+				matches := postgresPlanRowsRegExp.FindStringSubmatch(planLine)
+				if len(matches) != 2 {
+					continue
+				}
+
+				estimate, err := strconv.ParseInt(matches[1], 10, 64)
+				if err != nil {
+					return -1
+				}
+				return estimate
+			}
+
+			return -1
+		}
+	default:
+		return -1
+	}
+}
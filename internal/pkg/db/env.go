@@ -0,0 +1,158 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Build connection options from the environment variables the database's own clients read
+// DATABASE_URL takes precedence when set, otherwise:
+//   - MySQL: MYSQL_HOST or MYSQL_UNIX_PORT (socket), MYSQL_TCP_PORT, MYSQL_USER, MYSQL_PWD, MYSQL_DATABASE
+//   - PostgreSQL: PGHOST (a directory means a socket), PGPORT, PGUSER, PGPASSWORD, PGDATABASE,
+//     PGSSLMODE, PGSSLROOTCERT, PGSSLCERT, PGSSLKEY, PGAPPNAME
+//   - SQL Server: SQLCMDSERVER, SQLCMDUSER, SQLCMDPASSWORD, SQLCMDDBNAME
+//   - SQLite: only DATABASE_URL
+func ConnManagerFromEnv(flavor DBFlavor) (ConnManager, error) {
+	if !flavor.IsValid() {
+		return nil, fmt.Errorf("Unknown database type %q", flavor)
+	}
+
+	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
+		connOptions, err := ParseDSN(databaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("%w from DATABASE_URL", err)
+		}
+		if connOptions.Flavor != flavor {
+			return nil, fmt.Errorf("DATABASE_URL is for %s, expected %s", connOptions.Flavor.DisplayName(), flavor.DisplayName())
+		}
+
+		return connOptions, nil
+	}
+
+	connOptions := DBConnOptions{
+		Flavor: flavor,
+	}
+
+	var err error
+	switch flavor {
+	case MySQL:
+		{
+			connOptions.Host = os.Getenv("MYSQL_HOST")
+			connOptions.Socket = os.Getenv("MYSQL_UNIX_PORT")
+			connOptions.User = os.Getenv("MYSQL_USER")
+			connOptions.Password = os.Getenv("MYSQL_PWD")
+			connOptions.DatabaseName = os.Getenv("MYSQL_DATABASE")
+			connOptions.Port, err = portFromEnv("MYSQL_TCP_PORT", 3306)
+			if err != nil {
+				return nil, err
+			}
+
+			if connOptions.Host == "" && connOptions.Socket == "" {
+				return nil, missingEnvError("MYSQL_HOST or MYSQL_UNIX_PORT")
+			}
+			if connOptions.Socket != "" {
+				connOptions.Host = ""
+				connOptions.Port = 0
+			}
+			if connOptions.User == "" {
+				return nil, missingEnvError("MYSQL_USER")
+			}
+		}
+	case PostgreSQL:
+		{
+			connOptions.Host = os.Getenv("PGHOST")
+			connOptions.User = os.Getenv("PGUSER")
+			connOptions.Password = os.Getenv("PGPASSWORD")
+			connOptions.DatabaseName = os.Getenv("PGDATABASE")
+			connOptions.Port, err = portFromEnv("PGPORT", 5432)
+			if err != nil {
+				return nil, err
+			}
+
+			if connOptions.Host == "" {
+				return nil, missingEnvError("PGHOST")
+			}
+			// Like libpq, a host starting with a slash is the directory of the socket
+			if strings.HasPrefix(connOptions.Host, "/") {
+				connOptions.Socket = fmt.Sprintf("%s/.s.PGSQL.%d", strings.TrimRight(connOptions.Host, "/"), connOptions.Port)
+				connOptions.Host = ""
+				connOptions.Port = 0
+			}
+			if connOptions.User == "" {
+				return nil, missingEnvError("PGUSER")
+			}
+
+			additionalOptions := map[string]string{}
+			switch sslMode := TLSMode(os.Getenv("PGSSLMODE")); sslMode {
+			case "":
+				break
+			case TLSDisable, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+				connOptions.TLS.Mode = sslMode
+			default:
+				// i.e. prefer or allow, which the driver understands as is
+				additionalOptions["sslmode"] = string(sslMode)
+			}
+			connOptions.TLS.CACert = os.Getenv("PGSSLROOTCERT")
+			connOptions.TLS.ClientCert = os.Getenv("PGSSLCERT")
+			connOptions.TLS.ClientKey = os.Getenv("PGSSLKEY")
+
+			if appName := os.Getenv("PGAPPNAME"); appName != "" {
+				additionalOptions["application_name"] = appName
+			}
+			if len(additionalOptions) > 0 {
+				connOptions.AdditionalOptions = additionalOptions
+			}
+		}
+	case SQLServer:
+		{
+			// Either host or host,port like sqlcmd accepts
+			server := os.Getenv("SQLCMDSERVER")
+			if server == "" {
+				return nil, missingEnvError("SQLCMDSERVER")
+			}
+
+			connOptions.Port = 1433
+			if host, rawPort, ok := strings.Cut(server, ","); ok {
+				port, err := strconv.ParseUint(strings.TrimSpace(rawPort), 10, 16)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid port %q in SQLCMDSERVER", rawPort)
+				}
+				server = host
+				connOptions.Port = uint(port)
+			}
+
+			connOptions.Host = strings.TrimSpace(server)
+			connOptions.User = os.Getenv("SQLCMDUSER")
+			connOptions.Password = os.Getenv("SQLCMDPASSWORD")
+			connOptions.DatabaseName = os.Getenv("SQLCMDDBNAME")
+
+			if connOptions.User == "" {
+				return nil, missingEnvError("SQLCMDUSER")
+			}
+		}
+	case SQLite:
+		return nil, missingEnvError("DATABASE_URL")
+	}
+
+	return &connOptions, nil
+}
+
+func missingEnvError(variable string) error {
+	return fmt.Errorf("Environment variable %s must be set", variable)
+}
+
+func portFromEnv(variable string, defaultPort uint) (uint, error) {
+	rawPort := os.Getenv(variable)
+	if rawPort == "" {
+		return defaultPort, nil
+	}
+
+	port, err := strconv.ParseUint(rawPort, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid port %q in %s", rawPort, variable)
+	}
+
+	return uint(port), nil
+}
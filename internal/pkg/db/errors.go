@@ -0,0 +1,21 @@
+package db
+
+import "errors"
+
+// Use errors.Is to check which kind of failure occurred, i.e. to decide whether it's worth retrying
+var (
+	ErrInvalidConnOptions = errors.New("Failed to create connection string")
+	ErrConnectionFailed   = errors.New("Failed to establish connection to database")
+	ErrConnectionLost     = errors.New("Lost connection to database")
+	ErrQueryFailed        = errors.New("Query Failed")
+	ErrExecFailed         = errors.New("Exec Failed")
+	ErrColumnParse        = errors.New("Could not determine columns")
+	ErrRowScan            = errors.New("failed to read rows")
+	ErrQueryCancelled     = errors.New("Query cancelled while reading rows")
+	ErrQueryTimeout       = errors.New("Query cancelled due to exceeding the timeout")
+	ErrCancelled          = errors.New("Query cancelled")
+	ErrReadOnly           = errors.New("read-only mode")
+	ErrInterrupted        = errors.New("Interrupted")
+	ErrQueryOpen          = errors.New("Another query is still open, Close it first")
+	ErrNotConfirmed       = errors.New("Statement was not confirmed")
+)
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type ExplainOptions struct {
+	// Actually run the query to report real timings, not just the estimated plan
+	// Be careful with statements that modify data, they are executed too
+	Analyze bool
+	// PostgreSQL only, return the plan as JSON in QueryResult.PlanJSON as well
+	JSON bool
+}
+
+// Get the query plan for a query, displayable like any other result
+func (db *DBClient) Explain(query string, analyze bool) (*QueryResult, error) {
+	return db.ExplainWithOptions(db.ctx, query, ExplainOptions{Analyze: analyze})
+}
+
+func (db *DBClient) ExplainWithOptions(ctx context.Context, query string, opts ExplainOptions) (*QueryResult, error) {
+	// EXPLAIN itself is always allowed in read-only mode, but with ANALYZE the query is executed
+	if opts.Analyze {
+		if err := db.assertStatementAllowed(query); err != nil {
+			return nil, err
+		}
+	}
+
+	explainStatement, err := buildExplainStatement(db.connManager.GetFlavor(), trimStatement(query), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.QueryContext(ctx, explainStatement)
+	if err != nil {
+		return nil, err
+	}
+	if result.NoResultSet {
+		return nil, errors.New("EXPLAIN returned no plan")
+	}
+
+	if opts.JSON {
+		if len(result.Rows) != 1 || len(result.Columns) != 1 {
+			return nil, errors.New("Expected EXPLAIN to return the plan as a single JSON value")
+		}
+		result.PlanJSON = result.Rows[0][result.Columns[0]].String
+	}
+
+	return result, nil
+}
+
+func buildExplainStatement(flavor DBFlavor, query string, opts ExplainOptions) (string, error) {
+	if opts.JSON && flavor != PostgreSQL {
+		return "", fmt.Errorf("EXPLAIN as JSON not supported for %s", flavor.DisplayName())
+	}
+
+	switch flavor {
+	case PostgreSQL:
+		{
+			switch {
+			case opts.Analyze && opts.JSON:
+				return fmt.Sprint("EXPLAIN (ANALYZE, FORMAT JSON) ", query), nil
+			case opts.JSON:
+				return fmt.Sprint("EXPLAIN (FORMAT JSON) ", query), nil
+			case opts.Analyze:
+				return fmt.Sprint("EXPLAIN ANALYZE ", query), nil
+			default:
+				return fmt.Sprint("EXPLAIN ", query), nil
+			}
+		}
+	case MySQL:
+		{
+			// EXPLAIN ANALYZE requires MySQL 8.0.18+
+			if opts.Analyze {
+				return fmt.Sprint("EXPLAIN ANALYZE ", query), nil
+			}
+			return fmt.Sprint("EXPLAIN ", query), nil
+		}
+	case SQLite:
+		{
+			if opts.Analyze {
+				return "", fmt.Errorf("EXPLAIN ANALYZE not supported for %s", flavor.DisplayName())
+			}
+			// Plain EXPLAIN lists the bytecode, the query plan is far more useful
+			return fmt.Sprint("EXPLAIN QUERY PLAN ", query), nil
+		}
+	default:
+		{
+			return "", fmt.Errorf("EXPLAIN not supported for %s", flavor.DisplayName())
+		}
+	}
+}
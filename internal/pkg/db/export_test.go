@@ -0,0 +1,25 @@
+package db
+
+import (
+	"io"
+	"time"
+)
+
+var PostgresDSNValue = postgresDSNValue
+
+// Stand-ins for what RunInteractive goes by, see interactiveConfig
+type InteractiveTestConfig struct {
+	ExitWindow       time.Duration
+	Now              func() time.Time
+	PromptReady      func()
+	StatementStarted func()
+}
+
+func (db *DBClient) RunInteractiveWithTestConfig(in io.Reader, out io.Writer, config InteractiveTestConfig) error {
+	return db.runInteractive(in, out, interactiveConfig{
+		exitWindow:       config.ExitWindow,
+		now:              config.Now,
+		promptReady:      config.PromptReady,
+		statementStarted: config.StatementStarted,
+	})
+}
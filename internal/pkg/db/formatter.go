@@ -0,0 +1,162 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Writes results in some output format, see FormatterByName to pick one from a flag (ex: --format=markdown)
+type Formatter interface {
+	Format(w io.Writer, queryResult *QueryResult) error
+}
+
+// Aligned text table, see QueryResult.RenderTable
+type TableFormatter struct {
+	TableOptions
+}
+
+func (formatter TableFormatter) Format(w io.Writer, queryResult *QueryResult) error {
+	return queryResult.RenderTable(w, formatter.TableOptions)
+}
+
+// A block of column: value lines per row, see QueryResult.RenderVertical
+type VerticalFormatter struct{}
+
+func (VerticalFormatter) Format(w io.Writer, queryResult *QueryResult) error {
+	return queryResult.RenderVertical(w)
+}
+
+// CSV with a header row, see QueryResult.WriteCSVWithOptions
+type CSVFormatter struct {
+	CSVOptions
+}
+
+func (formatter CSVFormatter) Format(w io.Writer, queryResult *QueryResult) error {
+	return queryResult.WriteCSVWithOptions(w, formatter.CSVOptions)
+}
+
+// JSON array of objects keyed by column name, see QueryResult.WriteJSONWithOptions
+type JSONFormatter struct {
+	JSONOptions
+}
+
+func (formatter JSONFormatter) Format(w io.Writer, queryResult *QueryResult) error {
+	return queryResult.WriteJSONWithOptions(w, formatter.JSONOptions)
+}
+
+// One JSON object per line, see QueryResult.WriteNDJSONWithOptions
+type NDJSONFormatter struct {
+	JSONOptions
+}
+
+func (formatter NDJSONFormatter) Format(w io.Writer, queryResult *QueryResult) error {
+	return queryResult.WriteNDJSONWithOptions(w, formatter.JSONOptions)
+}
+
+// Tab separated values with a header row, NULL values are written using NullDisplay
+// Tabs, newlines and backslashes within values are escaped as \t, \n and \\ so each row stays on one line
+type TSVFormatter struct{}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func (TSVFormatter) Format(w io.Writer, queryResult *QueryResult) error {
+	bufferedWriter := bufio.NewWriter(w)
+
+	writeRow := func(values []string) {
+		for valueIdx, value := range values {
+			if valueIdx > 0 {
+				bufferedWriter.WriteByte('\t')
+			}
+			bufferedWriter.WriteString(tsvEscaper.Replace(value))
+		}
+		bufferedWriter.WriteByte('\n')
+	}
+
+	writeRow(queryResult.Columns)
+	for _, row := range queryResult.Rows {
+		writeRow(queryResult.displayRow(row))
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// GitHub flavored Markdown table, numeric columns (based on ColumnTypes) are right-aligned
+// Pipes within values are escaped, and newlines replaced with <br> so each row stays on one line
+type MarkdownFormatter struct{}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func (MarkdownFormatter) Format(w io.Writer, queryResult *QueryResult) error {
+	// A table needs at least one column
+	if len(queryResult.Columns) == 0 {
+		return nil
+	}
+
+	bufferedWriter := bufio.NewWriter(w)
+
+	writeRow := func(values []string) {
+		bufferedWriter.WriteByte('|')
+		for _, value := range values {
+			bufferedWriter.WriteByte(' ')
+			bufferedWriter.WriteString(markdownEscaper.Replace(value))
+			bufferedWriter.WriteString(" |")
+		}
+		bufferedWriter.WriteByte('\n')
+	}
+
+	writeRow(queryResult.Columns)
+
+	bufferedWriter.WriteByte('|')
+	for columnIdx := range queryResult.Columns {
+		kind := queryResult.columnKind(columnIdx)
+		if kind == columnKindInteger || kind == columnKindDecimal {
+			bufferedWriter.WriteString(" ---: |")
+		} else {
+			bufferedWriter.WriteString(" --- |")
+		}
+	}
+	bufferedWriter.WriteByte('\n')
+
+	for _, row := range queryResult.Rows {
+		writeRow(queryResult.displayRow(row))
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// Displayable values of the row, following column order
+func (queryResult *QueryResult) displayRow(row map[string]*NullString) []string {
+	values := make([]string, len(queryResult.Columns))
+	for columnIdx, columnName := range queryResult.Columns {
+		values[columnIdx] = queryResult.DisplayValue(row[columnName])
+	}
+
+	return values
+}
+
+// Names accepted by FormatterByName
+var FormatterNames = []string{"table", "vertical", "csv", "tsv", "json", "ndjson", "markdown"}
+
+// Formatter with default options by name (case-insensitive), one of FormatterNames
+func FormatterByName(name string) (Formatter, error) {
+	switch strings.ToLower(name) {
+	case "table":
+		return TableFormatter{}, nil
+	case "vertical":
+		return VerticalFormatter{}, nil
+	case "csv":
+		return CSVFormatter{}, nil
+	case "tsv":
+		return TSVFormatter{}, nil
+	case "json":
+		return JSONFormatter{}, nil
+	case "ndjson", "jsonl":
+		return NDJSONFormatter{}, nil
+	case "markdown", "md":
+		return MarkdownFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("Unknown format %q, expected one of %s", name, strings.Join(FormatterNames, ", "))
+	}
+}
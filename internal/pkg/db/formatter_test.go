@@ -0,0 +1,96 @@
+package db_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatter(t *testing.T) {
+	format := func(formatter db.Formatter, queryResult *db.QueryResult) string {
+		var out strings.Builder
+		if err := formatter.Format(&out, queryResult); err != nil {
+			t.Fatal(err)
+		}
+
+		return out.String()
+	}
+
+	// Values which need escaping in one format or another
+	awkwardResult := &db.QueryResult{
+		Columns:     []string{"id", "a|b"},
+		ColumnTypes: []string{"INTEGER", "TEXT"},
+		Rows: []map[string]*db.NullString{
+			{"id": newTestNullString("1"), "a|b": newTestNullString("x|y\tz\nw \\ ")},
+			{"id": newTestNullString("2"), "a|b": newTestNull()},
+		},
+	}
+
+	t.Run("Markdown", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(
+			"| id | name | note |\n"+
+				"| ---: | --- | --- |\n"+
+				"| 1 | Smith, John | NULL |\n"+
+				"| 2 | say \"hi\" | NULL |\n",
+			format(db.MarkdownFormatter{}, newTestQueryResult()),
+		)
+		assert.Equal(
+			"| id | a\\|b |\n"+
+				"| ---: | --- |\n"+
+				"| 1 | x\\|y\tz<br>w \\\\  |\n"+
+				"| 2 | NULL |\n",
+			format(db.MarkdownFormatter{}, awkwardResult),
+		)
+		assert.Empty(format(db.MarkdownFormatter{}, &db.QueryResult{NoResultSet: true}))
+	})
+
+	t.Run("TSV", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(
+			"id\tname\tnote\n1\tSmith, John\tNULL\n2\tsay \"hi\"\tNULL\n",
+			format(db.TSVFormatter{}, newTestQueryResult()),
+		)
+		assert.Equal(
+			"id\ta|b\n1\tx|y\\tz\\nw \\\\ \n2\tNULL\n",
+			format(db.TSVFormatter{}, awkwardResult),
+		)
+	})
+
+	t.Run("Matches the Write methods", func(t *testing.T) {
+		assert := assert.New(t)
+		queryResult := newTestQueryResult()
+
+		var csvOut, jsonOut, ndjsonOut, tableOut strings.Builder
+		assert.NoError(queryResult.WriteCSV(&csvOut))
+		assert.NoError(queryResult.WriteJSONWithOptions(&jsonOut, db.JSONOptions{CoerceTypes: true}))
+		assert.NoError(queryResult.WriteNDJSON(&ndjsonOut))
+		assert.NoError(queryResult.RenderTable(&tableOut, db.TableOptions{Unicode: true}))
+
+		assert.Equal(csvOut.String(), format(db.CSVFormatter{}, queryResult))
+		assert.Equal(jsonOut.String(), format(db.JSONFormatter{JSONOptions: db.JSONOptions{CoerceTypes: true}}, queryResult))
+		assert.Equal(ndjsonOut.String(), format(db.NDJSONFormatter{}, queryResult))
+		assert.Equal(tableOut.String(), format(db.TableFormatter{TableOptions: db.TableOptions{Unicode: true}}, queryResult))
+	})
+
+	t.Run("By name", func(t *testing.T) {
+		assert := assert.New(t)
+
+		for _, name := range db.FormatterNames {
+			formatter, err := db.FormatterByName(name)
+			assert.NoError(err)
+			assert.NotNil(formatter)
+		}
+
+		formatter, err := db.FormatterByName("Markdown")
+		assert.NoError(err)
+		assert.Equal(db.MarkdownFormatter{}, formatter)
+
+		_, err = db.FormatterByName("xml")
+		assert.EqualError(err, `Unknown format "xml", expected one of table, vertical, csv, tsv, json, ndjson, markdown`)
+	})
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Wrap a database the caller already opened (i.e. a pool shared with the rest of the app), rather than connecting from a DSN
+// Nothing is checked until the first query. Destroy leaves sqlDB open, closing it is up to the caller
+// Without a DSN, SwitchDatabase and Listen aren't available, nor are PostgreSQL notices for SetCollectWarnings
+func NewDBClientFromDB(sqlDB *sql.DB, flavor DBFlavor) *DBClient {
+	db := newDBClient(&existingDBConnManager{flavor: flavor}, DefaultDBClientOptions())
+	db.sqlDB = sqlx.NewDb(sqlDB, string(flavor))
+
+	return db
+}
+
+// Stands in for the connection options of a database handed to NewDBClientFromDB, it has no DSN to give out
+type existingDBConnManager struct {
+	flavor       DBFlavor
+	databaseName string
+}
+
+func (connManager *existingDBConnManager) GetDSN() (string, error) {
+	return "", fmt.Errorf("No connection string for a %s database opened elsewhere", connManager.flavor.DisplayName())
+}
+
+func (connManager *existingDBConnManager) GetRedactedDSN() (string, error) {
+	return connManager.GetDSN()
+}
+
+func (connManager *existingDBConnManager) IsSafeMode() bool {
+	return false
+}
+
+func (connManager *existingDBConnManager) SafeModeStatements() []string {
+	return nil
+}
+
+// Nothing to check, the caller connected already
+func (connManager *existingDBConnManager) Validate() error {
+	return nil
+}
+
+func (connManager *existingDBConnManager) GetFlavor() DBFlavor {
+	return connManager.flavor
+}
+
+func (connManager *existingDBConnManager) GetDatabaseName() string {
+	return connManager.databaseName
+}
+
+func (connManager *existingDBConnManager) SetDatabaseName(name string) {
+	connManager.databaseName = name
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+)
+
+// Written ahead of each value, so NULL can't collide with any text (i.e. "NULL" or "")
+const (
+	hashNull byte = iota
+	hashValue
+	hashTruncatedValue
+)
+
+// Stable fingerprint of Columns and Rows (in row order), to cheaply tell if a repeated query returned the same data
+// Timing, warnings and display settings aren't included, ORDER BY the query if row order isn't otherwise stable
+func (queryResult *QueryResult) Hash() string {
+	hasher := sha256.New()
+
+	writeHashUint(hasher, uint64(len(queryResult.Columns)))
+	for _, columnName := range queryResult.Columns {
+		writeHashString(hasher, columnName)
+	}
+
+	writeHashUint(hasher, uint64(len(queryResult.Rows)))
+	for _, row := range queryResult.Rows {
+		for _, columnName := range queryResult.Columns {
+			value := row[columnName]
+
+			switch {
+			case value == nil || value.IsNull():
+				hasher.Write([]byte{hashNull})
+			case value.Truncated:
+				hasher.Write([]byte{hashTruncatedValue})
+				writeHashString(hasher, value.String)
+			default:
+				hasher.Write([]byte{hashValue})
+				writeHashString(hasher, value.String)
+			}
+		}
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func writeHashUint(hasher hash.Hash, n uint64) {
+	hasher.Write(binary.BigEndian.AppendUint64(nil, n))
+}
+
+// Length prefixed, so values can't shift into one another (i.e. "ab", "c" vs "a", "bc")
+func writeHashString(hasher hash.Hash, value string) {
+	writeHashUint(hasher, uint64(len(value)))
+	hasher.Write([]byte(value))
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type HistoryEntry struct {
+	Query      string        `json:"query"`
+	ExecutedAt time.Time     `json:"executed_at"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	// Message of the error the query failed with, empty if it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// Executed queries, optionally persisted to a file with one JSON entry per line
+// Entries are appended to the file as they're recorded, so history survives a crash
+type QueryHistory struct {
+	// Empty keeps the history in memory only
+	path    string
+	entries []HistoryEntry
+	mu      sync.Mutex
+}
+
+func NewQueryHistory(path string) *QueryHistory {
+	return &QueryHistory{
+		path: path,
+	}
+}
+
+// Replace the entries in memory with those from the history file
+// A missing file is treated as empty history, lines that can't be parsed (i.e. cut off by a crash) are skipped
+func (history *QueryHistory) Load() error {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	history.entries = nil
+	if history.path == "" {
+		return nil
+	}
+
+	file, err := os.Open(history.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return errors.Join(
+			errors.New("Failed to open query history"),
+			err,
+		)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// Queries can be long, the default limit of 64KB per line isn't enough for big scripts
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		history.entries = append(history.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Join(
+			errors.New("Failed to read query history"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Rewrite the history file with the entries in memory, i.e. to drop unparseable lines
+// The file is replaced atomically, so a crash midway leaves the previous history intact
+func (history *QueryHistory) Save() error {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	if history.path == "" {
+		return nil
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(history.path), filepath.Base(history.path)+".*.tmp")
+	if err != nil {
+		return errors.Join(
+			errors.New("Failed to save query history"),
+			err,
+		)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	bufferedWriter := bufio.NewWriter(tmpFile)
+	for _, entry := range history.entries {
+		line, err := marshalHistoryEntry(entry)
+		if err != nil {
+			tmpFile.Close()
+			return err
+		}
+		bufferedWriter.Write(line)
+	}
+
+	err = errors.Join(bufferedWriter.Flush(), tmpFile.Sync(), tmpFile.Close())
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), history.path)
+	}
+	if err != nil {
+		return errors.Join(
+			errors.New("Failed to save query history"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Record an executed query, queryErr being what it failed with if anything
+// When backed by a file, the entry is appended and synced to it immediately
+func (history *QueryHistory) Append(query string, queryErr error, elapsed time.Duration) error {
+	entry := HistoryEntry{
+		Query:      query,
+		ExecutedAt: time.Now(),
+		Elapsed:    elapsed,
+	}
+	if queryErr != nil {
+		entry.Error = queryErr.Error()
+	}
+
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	history.entries = append(history.entries, entry)
+	if history.path == "" {
+		return nil
+	}
+
+	line, err := marshalHistoryEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	// Queries may contain credentials, keep the file private to the user
+	file, err := os.OpenFile(history.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return errors.Join(
+			errors.New("Failed to open query history"),
+			err,
+		)
+	}
+
+	// A crash midway through an entry leaves the file without a trailing newline
+	// Start on a fresh line, so only the truncated entry is lost rather than this one along with it
+	if truncated, err := endsMidLine(file); err != nil {
+		file.Close()
+		return errors.Join(
+			errors.New("Failed to read query history"),
+			err,
+		)
+	} else if truncated {
+		line = append([]byte{'\n'}, line...)
+	}
+
+	// A single write per entry, so concurrent sessions appending to the same file don't interleave lines
+	_, err = file.Write(line)
+	err = errors.Join(err, file.Sync(), file.Close())
+	if err != nil {
+		return errors.Join(
+			errors.New("Failed to write query history"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// The last n entries, oldest first
+func (history *QueryHistory) Recent(n int) []HistoryEntry {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	start := max(len(history.entries)-max(n, 0), 0)
+	recent := make([]HistoryEntry, len(history.entries)-start)
+	copy(recent, history.entries[start:])
+
+	return recent
+}
+
+func endsMidLine(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+
+	lastByte := make([]byte, 1)
+	if _, err := file.ReadAt(lastByte, info.Size()-1); err != nil {
+		return false, err
+	}
+
+	return lastByte[0] != '\n', nil
+}
+
+func marshalHistoryEntry(entry HistoryEntry) ([]byte, error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, errors.Join(
+			errors.New("Failed to encode query history entry"),
+			err,
+		)
+	}
+
+	return append(line, '\n'), nil
+}
+
+// Record queries run through Query and Exec in history, nil stops recording
+func (db *DBClient) SetQueryHistory(history *QueryHistory) {
+	db.queryHistory = history
+}
+
+// Deferred with a pointer to the named error, so the final error of the call is recorded
+func (db *DBClient) recordHistory(statement string, startedAt time.Time, err *error) {
+	if db.queryHistory == nil {
+		return
+	}
+
+	// History is best-effort, failing to write it shouldn't fail the query
+	_ = db.queryHistory.Append(statement, *err, time.Since(startedAt))
+}
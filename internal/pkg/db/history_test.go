@@ -0,0 +1,110 @@
+package db_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryHistory(t *testing.T) {
+	t.Run("Persists Across Restarts", func(t *testing.T) {
+		assert := assert.New(t)
+
+		historyPath := filepath.Join(t.TempDir(), "history.ndjson")
+
+		history := db.NewQueryHistory(historyPath)
+		assert.NoError(history.Load())
+		assert.NoError(history.Append("SELECT 1", nil, time.Millisecond))
+		assert.NoError(history.Append("SELECT nope", errors.New("no such column: nope"), 2*time.Millisecond))
+		assert.NoError(history.Append("SELECT 3", nil, 3*time.Millisecond))
+
+		info, err := os.Stat(historyPath)
+		assert.NoError(err)
+		assert.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+		reloaded := db.NewQueryHistory(historyPath)
+		assert.NoError(reloaded.Load())
+
+		recent := reloaded.Recent(2)
+		if assert.Len(recent, 2) {
+			assert.Equal("SELECT nope", recent[0].Query)
+			assert.Equal("no such column: nope", recent[0].Error)
+			assert.Equal(2*time.Millisecond, recent[0].Elapsed)
+			assert.Equal("SELECT 3", recent[1].Query)
+			assert.Empty(recent[1].Error)
+		}
+		assert.Len(reloaded.Recent(10), 3)
+		assert.Empty(reloaded.Recent(0))
+	})
+
+	t.Run("Skips Truncated Lines", func(t *testing.T) {
+		assert := assert.New(t)
+
+		historyPath := filepath.Join(t.TempDir(), "history.ndjson")
+
+		history := db.NewQueryHistory(historyPath)
+		assert.NoError(history.Append("SELECT 1", nil, 0))
+
+		// As if the process crashed midway through writing an entry
+		file, err := os.OpenFile(historyPath, os.O_WRONLY|os.O_APPEND, 0o600)
+		assert.NoError(err)
+		_, err = file.WriteString(`{"query":"SELECT 2","execu`)
+		assert.NoError(err)
+		assert.NoError(file.Close())
+
+		reloaded := db.NewQueryHistory(historyPath)
+		assert.NoError(reloaded.Load())
+		assert.Len(reloaded.Recent(10), 1)
+
+		// Saving compacts the file, later appends start on a fresh line
+		assert.NoError(reloaded.Save())
+		assert.NoError(reloaded.Append("SELECT 3", nil, 0))
+
+		reloaded = db.NewQueryHistory(historyPath)
+		assert.NoError(reloaded.Load())
+		recent := reloaded.Recent(10)
+		if assert.Len(recent, 2) {
+			assert.Equal("SELECT 1", recent[0].Query)
+			assert.Equal("SELECT 3", recent[1].Query)
+		}
+	})
+
+	t.Run("Appends After Truncated Line", func(t *testing.T) {
+		assert := assert.New(t)
+
+		historyPath := filepath.Join(t.TempDir(), "history.ndjson")
+
+		history := db.NewQueryHistory(historyPath)
+		assert.NoError(history.Append("SELECT 1", nil, 0))
+
+		file, err := os.OpenFile(historyPath, os.O_WRONLY|os.O_APPEND, 0o600)
+		assert.NoError(err)
+		_, err = file.WriteString(`{"query":"SELECT 2","execu`)
+		assert.NoError(err)
+		assert.NoError(file.Close())
+
+		// Without saving first, the entry still goes on its own line
+		assert.NoError(history.Append("SELECT 3", nil, 0))
+
+		reloaded := db.NewQueryHistory(historyPath)
+		assert.NoError(reloaded.Load())
+		recent := reloaded.Recent(10)
+		if assert.Len(recent, 2) {
+			assert.Equal("SELECT 1", recent[0].Query)
+			assert.Equal("SELECT 3", recent[1].Query)
+		}
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		assert := assert.New(t)
+
+		history := db.NewQueryHistory(filepath.Join(t.TempDir(), "missing.ndjson"))
+		assert.NoError(history.Load())
+		assert.Empty(history.Recent(10))
+	})
+}
@@ -0,0 +1,76 @@
+package db
+
+import "time"
+
+// Close the connection once no query has run for after, so a client left open doesn't hold on to a server session
+// (or run into the server's own idle limit). The next query reconnects, losing session state such as USE
+// Each query restarts the wait, a transaction or open query in progress keeps the connection open
+// In pool mode idle pooled connections are closed instead, 0 disables it (the default)
+func (db *DBClient) SetIdleClose(after time.Duration) {
+	db.idleCloseMu.Lock()
+	defer db.idleCloseMu.Unlock()
+
+	if db.idleCloseTimer != nil {
+		db.idleCloseTimer.Stop()
+		db.idleCloseTimer = nil
+	}
+
+	db.idleCloseAfter = after
+	if after > 0 {
+		db.idleCloseTimer = time.AfterFunc(after, db.closeIdleConn)
+	}
+}
+
+// Start waiting over, called as each query finishes
+func (db *DBClient) resetIdleClose() {
+	db.idleCloseMu.Lock()
+	defer db.idleCloseMu.Unlock()
+
+	if db.idleCloseTimer != nil {
+		db.idleCloseTimer.Reset(db.idleCloseAfter)
+	}
+}
+
+func (db *DBClient) closeIdleConn() {
+	db.idleCloseMu.Lock()
+	disabled := db.idleCloseAfter == 0
+	db.idleCloseMu.Unlock()
+	// Fired just as SetIdleClose(0) stopped it
+	if disabled {
+		return
+	}
+
+	// i.e. SwitchDatabase is swapping the pool out, check again later rather than waiting on it
+	// Queries only hold a read lock here, and the connections they have checked out aren't idle anyway
+	if db.pooled() {
+		if !db.connMu.TryRLock() {
+			db.resetIdleClose()
+			return
+		}
+		defer db.connMu.RUnlock()
+
+		db.closeIdleConns()
+		return
+	}
+
+	// The connection is in use, check again later rather than waiting on it
+	if !db.connMu.TryLock() {
+		db.resetIdleClose()
+		return
+	}
+	defer db.connMu.Unlock()
+
+	if db._conn == nil {
+		return
+	}
+	if db._tx != nil || db._openQuery != nil {
+		db.resetIdleClose()
+		return
+	}
+
+	// Statements were prepared on the connection, so they go with it
+	db.stmtCache.clear()
+	// Closing would only hand it back to database/sql's pool, still connected
+	_ = discardConn(db._conn)
+	db._conn = nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ping the connection every interval in the background, so idle connections aren't dropped by the server
+// or a proxy along the way (i.e. MySQL wait_timeout), if one was dropped it's reopened right away
+// A ping is skipped while a query or transaction is using the connection, they keep it alive anyway
+// Replaces any keepalive already running, stopped by StopKeepalive or Destroy
+func (db *DBClient) StartKeepalive(interval time.Duration) {
+	db.StopKeepalive()
+
+	ctx, cancel := context.WithCancel(db.ctx)
+	var done sync.WaitGroup
+	done.Add(1)
+
+	go func() {
+		defer done.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				db.keepalivePing(ctx, interval)
+			}
+		}
+	}()
+
+	db.stopKeepalive = func() {
+		cancel()
+		done.Wait()
+	}
+}
+
+// Stop the loop started by StartKeepalive, waiting for a ping in progress to finish
+// Does nothing if it isn't running
+func (db *DBClient) StopKeepalive() {
+	if db.stopKeepalive == nil {
+		return
+	}
+
+	db.stopKeepalive()
+	db.stopKeepalive = nil
+}
+
+func (db *DBClient) keepalivePing(ctx context.Context, timeout time.Duration) {
+	// database/sql only pings idle pooled connections as it hands them out, ping one of them ourselves
+	if db.pooled() {
+		if !db.connMu.TryRLock() {
+			return
+		}
+		defer db.connMu.RUnlock()
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		_ = db.sqlDB.PingContext(ctx)
+		return
+	}
+
+	if !db.connMu.TryLock() {
+		return
+	}
+	defer db.connMu.Unlock()
+
+	// Nothing to keep alive until the first query opens the connection
+	if db._conn == nil || db._tx != nil || db._openQuery != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// A failed reconnect is left for the next query to report
+	_, _ = db.getConnection(ctx)
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Message sent with NOTIFY on a channel passed to Listen
+type Notification struct {
+	Channel string
+	Payload string
+}
+
+// Connection waiting for notifications on one channel, see Listen
+type channelListener struct {
+	cancel context.CancelFunc
+	// Closed once the connection is closed, after notifications is
+	done chan struct{}
+}
+
+// Subscribe to NOTIFY messages on channel, only supported for PostgreSQL
+// Notifications are delivered until Unlisten is called or the DBClient is destroyed, the channel is closed then
+// Or if the connection is lost, listen again to resubscribe
+func (db *DBClient) Listen(channel string) (<-chan Notification, error) {
+	return db.ListenContext(db.ctx, channel)
+}
+
+// Same as Listen, but notifications stop once ctx is done as well
+// Listening uses a connection of its own, so queries carry on while waiting for notifications
+func (db *DBClient) ListenContext(ctx context.Context, channel string) (_ <-chan Notification, err error) {
+	if db.connManager.GetFlavor() != PostgreSQL {
+		return nil, fmt.Errorf("LISTEN not supported for %s", db.connManager.GetFlavor().DisplayName())
+	}
+	if channel == "" {
+		return nil, errors.New("Channel name is required to listen")
+	}
+
+	statement := "LISTEN " + PostgreSQL.QuoteIdentifier(channel)
+	defer db.logQuery(statement, nil, time.Now(), &err)
+
+	db.listenerMu.Lock()
+	defer db.listenerMu.Unlock()
+
+	if _, ok := db.listeners[channel]; ok {
+		return nil, fmt.Errorf("Already listening on channel %q", channel)
+	}
+
+	conn, err := db.connectListener(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = conn.Exec(ctx, statement); err != nil {
+		_ = conn.Close(context.Background())
+		return nil, errors.Join(
+			ErrExecFailed,
+			asQueryError(err),
+		)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	notifications := make(chan Notification)
+	listener := &channelListener{cancel: cancel, done: make(chan struct{})}
+	if db.listeners == nil {
+		db.listeners = map[string]*channelListener{}
+	}
+	db.listeners[channel] = listener
+
+	go func() {
+		defer close(listener.done)
+		defer func() { _ = conn.Close(context.Background()) }()
+		defer close(notifications)
+		defer db.forgetListener(channel, listener)
+
+		for {
+			notification, err := conn.WaitForNotification(ctx)
+			if err != nil {
+				return
+			}
+
+			select {
+			case notifications <- Notification{Channel: notification.Channel, Payload: notification.Payload}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return notifications, nil
+}
+
+// Stop delivering notifications on channel, closing the channel Listen returned
+func (db *DBClient) Unlisten(channel string) error {
+	db.listenerMu.Lock()
+	listener, ok := db.listeners[channel]
+	db.listenerMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("Not listening on channel %q", channel)
+	}
+
+	listener.cancel()
+	<-listener.done
+
+	return nil
+}
+
+// Unlisten on every channel, for Destroy
+func (db *DBClient) unlistenAll() {
+	db.listenerMu.Lock()
+	listeners := make([]*channelListener, 0, len(db.listeners))
+	for _, listener := range db.listeners {
+		listeners = append(listeners, listener)
+	}
+	db.listenerMu.Unlock()
+
+	for _, listener := range listeners {
+		listener.cancel()
+		<-listener.done
+	}
+}
+
+func (db *DBClient) forgetListener(channel string, listener *channelListener) {
+	db.listenerMu.Lock()
+	defer db.listenerMu.Unlock()
+
+	if db.listeners[channel] == listener {
+		delete(db.listeners, channel)
+	}
+}
+
+// Open a pgx connection outside of sqlDB, which only has room for the connection queries use
+func (db *DBClient) connectListener(ctx context.Context) (*pgx.Conn, error) {
+	// SwitchDatabase changes the DSN under connMu
+	db.connMu.RLock()
+	dsnProducer := db.sshTunnel.connManager(db.connManager)
+	dataSourceName, err := dsnProducer.GetDSN()
+	db.connMu.RUnlock()
+	if err != nil {
+		return nil, errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	}
+
+	connConfig, err := pgx.ParseConfig(dataSourceName)
+	if err != nil {
+		return nil, errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	}
+	if db.clientOptions.ConnectTimeout > 0 {
+		connConfig.ConnectTimeout = db.clientOptions.ConnectTimeout
+	}
+	setPostgresDial(&connConfig.Config, dialFuncFor(dsnProducer))
+
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return nil, errors.Join(
+			ErrConnectionFailed,
+			err,
+		)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Notified of every statement sent to the database, see DBClient.SetLogger
+// Errors are the ones returned to the caller, nil when successful
+type Logger interface {
+	LogQuery(sql string, args []any, elapsed time.Duration, err error)
+}
+
+// Logger that discards everything
+type NopLogger struct{}
+
+func (NopLogger) LogQuery(string, []any, time.Duration, error) {}
+
+// Logger writing a line per statement, ex: [1.2ms] SELECT * FROM users WHERE id = ? [42]
+type TextLogger struct {
+	// nil writes to stdout
+	Out io.Writer
+	// Log each arg as "?" rather than its value, i.e. when they may contain passwords
+	RedactArgs bool
+}
+
+// Logger writing every statement to stdout
+func NewStdoutLogger() *TextLogger {
+	return &TextLogger{Out: os.Stdout}
+}
+
+func (logger *TextLogger) LogQuery(sql string, args []any, elapsed time.Duration, err error) {
+	var line strings.Builder
+
+	fmt.Fprintf(&line, "[%s] %s", elapsed.Round(time.Microsecond), trimStatement(sql))
+	if len(args) > 0 {
+		line.WriteString(" [")
+		for argIdx, arg := range args {
+			if argIdx > 0 {
+				line.WriteString(", ")
+			}
+
+			if logger.RedactArgs {
+				line.WriteByte('?')
+			} else {
+				fmt.Fprintf(&line, "%#v", arg)
+			}
+		}
+		line.WriteByte(']')
+	}
+	if err != nil {
+		fmt.Fprintf(&line, " error: %s", strings.ReplaceAll(err.Error(), "\n", ": "))
+	}
+	line.WriteByte('\n')
+
+	out := logger.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, _ = io.WriteString(out, line.String())
+}
+
+// Log every statement sent, including session setup on new connections, nil stops logging
+func (db *DBClient) SetLogger(logger Logger) {
+	db.logger = logger
+}
+
+// Deferred with a pointer to the named error, same as recordHistory
+func (db *DBClient) logQuery(statement string, args []any, startedAt time.Time, err *error) {
+	if db.logger == nil {
+		return
+	}
+
+	db.logger.LogQuery(statement, args, time.Since(startedAt), *err)
+}
@@ -0,0 +1,40 @@
+package db_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextLogger(t *testing.T) {
+	t.Run("Args and errors", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		logger := &db.TextLogger{Out: &out}
+
+		logger.LogQuery("SELECT * FROM users WHERE id = ? AND name = ?;\n", []any{42, "bob"}, 1500*time.Microsecond, nil)
+		logger.LogQuery("DELETE FROM users", nil, time.Millisecond, errors.Join(db.ErrExecFailed, errors.New("locked")))
+
+		assert.Equal(
+			"[1.5ms] SELECT * FROM users WHERE id = ? AND name = ? [42, \"bob\"]\n"+
+				"[1ms] DELETE FROM users error: "+db.ErrExecFailed.Error()+": locked\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Redacted args", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		logger := &db.TextLogger{Out: &out, RedactArgs: true}
+
+		logger.LogQuery("UPDATE users SET password = ? WHERE id = ?", []any{"hunter2", 1}, time.Millisecond, nil)
+
+		assert.Equal("[1ms] UPDATE users SET password = ? WHERE id = ? [?, ?]\n", out.String())
+	})
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Run a query with :name placeholders bound from params, ex:
+//
+//	dbClient.QueryNamed("SELECT * FROM users WHERE id = :id", map[string]any{"id": 42})
+//
+// Placeholders are rewritten to the flavor's own syntax, so the same query works on MySQL and PostgreSQL
+// Binding follows sqlx, a literal colon is written as :: (i.e. for a PostgreSQL cast, x::::int or CAST(x AS int))
+func (db *DBClient) QueryNamed(query string, params map[string]any) (*QueryResult, error) {
+	return db.QueryNamedContext(db.ctx, query, params)
+}
+
+// Same as QueryNamed, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryNamedContext(ctx context.Context, query string, params map[string]any) (*QueryResult, error) {
+	boundQuery, args, err := sqlx.Named(query, params)
+	if err != nil {
+		return nil, errors.Join(
+			ErrQueryFailed,
+			errors.New("Failed to bind named parameters"),
+			err,
+		)
+	}
+
+	return db.QueryContext(ctx, db.Rebind(boundQuery), args...)
+}
@@ -0,0 +1,332 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// A query whose rows are kept open between calls to Fetch, see QueryOpen
+type OpenQuery struct {
+	mu        sync.Mutex
+	db        *DBClient
+	ctx       context.Context
+	cancel    context.CancelCauseFunc
+	statement string
+	args      []any
+	startedAt time.Time
+	rows      *sqlx.Rows
+	scanner   *rowScanner
+	opts      scanOptions
+	// Rows read ahead of the last Fetch to tell whether more remain, or known up front (i.e. a dry run)
+	pending     []map[string]*NullString
+	noResultSet bool
+	// In pool mode, the connection the rows are read from
+	slot *pooledConnSlot
+	// Set once the rows were closed and the connection handed back, see release
+	released bool
+	closed   bool
+	// Set by Close before cancelling, so a Fetch it interrupts isn't reported as a cancelled query
+	closeRequested atomic.Bool
+}
+
+// Run a query and read its rows a batch at a time, i.e. to show the first rows and load more as the user scrolls, ex:
+//
+//	openQuery, err := dbClient.QueryOpen("SELECT * FROM events")
+//	if err != nil {
+//		return err
+//	}
+//	defer openQuery.Close()
+//
+//	firstPage, more, err := openQuery.Fetch(100)
+//
+// The rows stay open on the connection until the last one was fetched or Close is called
+// Until then no other query can run on the client, they fail with ErrQueryOpen rather than waiting on the OpenQuery
+// In pool mode the OpenQuery has a connection to itself instead, so other queries carry on alongside it
+// The query timeout doesn't apply, rows may be left open for as long as the user takes to scroll
+func (db *DBClient) QueryOpen(statement string, args ...any) (*OpenQuery, error) {
+	return db.QueryOpenContext(db.ctx, statement, args...)
+}
+
+// Same as QueryOpen, but the query can be cancelled through ctx, for as long as it's open
+func (db *DBClient) QueryOpenContext(ctx context.Context, statement string, args ...any) (openQuery *OpenQuery, err error) {
+	defer db.recordHistory(statement, time.Now(), &err)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	openQuery = &OpenQuery{
+		db:        db,
+		cancel:    cancel,
+		statement: statement,
+		args:      args,
+		startedAt: time.Now(),
+		opts:      db.scanOptions(),
+	}
+
+	if db.pooled() {
+		db.connMu.RLock()
+		defer db.connMu.RUnlock()
+
+		openQuery.slot = &pooledConnSlot{}
+		ctx = context.WithValue(ctx, pooledConnKey{}, openQuery.slot)
+	} else {
+		db.connMu.Lock()
+		defer db.connMu.Unlock()
+
+		if err = db.assertNoOpenQuery(); err != nil {
+			cancel(nil)
+			return nil, err
+		}
+		if db._tx != nil {
+			cancel(nil)
+			return nil, errors.New("Can't open a query while a transaction is in progress")
+		}
+	}
+	openQuery.ctx = ctx
+
+	untrack := openQuery.track()
+	defer untrack()
+
+	fail := func(err error) (*OpenQuery, error) {
+		err = explainCancelled(ctx, err)
+		openQuery.releaseConn(err)
+		return nil, err
+	}
+
+	if db.shouldDryRun(statement) {
+		rowsEstimate, err := db.dryRunStatement(ctx, statement, args)
+		if err != nil {
+			return fail(err)
+		}
+
+		dryRunResult := newDryRunQueryResult(rowsEstimate)
+		openQuery.scanner = &rowScanner{columns: dryRunResult.Columns, columnTypeNames: dryRunResult.ColumnTypes}
+		openQuery.pending = dryRunResult.Rows
+		openQuery.releaseConn(nil)
+		return openQuery, nil
+	}
+
+	rows, err := db.queryRows(ctx, statement, args)
+	if err != nil {
+		return fail(err)
+	}
+	if rows == nil {
+		openQuery.noResultSet = true
+		openQuery.releaseConn(nil)
+		return openQuery, nil
+	}
+
+	openQuery.scanner, err = newRowScanner(rows, openQuery.opts)
+	if err != nil {
+		_ = rows.Close()
+		return fail(err)
+	}
+	openQuery.rows = rows
+
+	if !db.pooled() {
+		db._openQuery = openQuery
+	}
+
+	return openQuery, nil
+}
+
+// Read the next n rows (fewer at the end), and whether there are more after them
+// Once no more remain the rows are closed, letting other queries run again, later calls return no rows
+// If reading fails (i.e. the query was cancelled through DBClient.Cancel) the OpenQuery is closed
+func (openQuery *OpenQuery) Fetch(n int) (results *QueryResult, more bool, err error) {
+	if n < 1 {
+		return nil, false, fmt.Errorf("Invalid row count %d, must be at least 1", n)
+	}
+
+	openQuery.mu.Lock()
+	defer openQuery.mu.Unlock()
+
+	if openQuery.closed {
+		return nil, false, errors.New("OpenQuery is closed")
+	}
+	if openQuery.noResultSet {
+		return newNoResultSetQueryResult(), false, nil
+	}
+
+	if openQuery.rows != nil {
+		// Read one past n, so more is known without waiting for the next Fetch
+		if err = openQuery.readAhead(n + 1); err != nil {
+			if openQuery.closeRequested.Load() {
+				err = errors.New("OpenQuery was closed while fetching")
+			}
+			openQuery.closed = true
+			openQuery.pending = nil
+			_ = openQuery.release(err)
+			return nil, false, err
+		}
+	}
+
+	collector := newRowCollector(scanOptions{nullDisplay: openQuery.opts.nullDisplay})
+	fetched := min(n, len(openQuery.pending))
+	for _, row := range openQuery.pending[:fetched] {
+		_ = collector.add(row)
+	}
+	openQuery.pending = openQuery.pending[fetched:]
+
+	more = len(openQuery.pending) > 0
+	// Whatever is left is buffered, the connection is free for other queries already
+	if openQuery.rows == nil {
+		_ = openQuery.release(nil)
+	}
+
+	results = &QueryResult{
+		Rows:         collector.rows,
+		Columns:      openQuery.scanner.columns,
+		ColumnTypes:  openQuery.scanner.columnTypeNames,
+		MaxWidth:     collector.maxWidths(openQuery.scanner.columns),
+		nullDisplay:  openQuery.opts.nullDisplay,
+		binaryFormat: openQuery.opts.binaryFormat,
+	}
+	results.setStmtType(openQuery.statement)
+
+	return results, more, nil
+}
+
+// Buffer rows until there are count of them pending or none are left, closing the rows at the end
+func (openQuery *OpenQuery) readAhead(count int) error {
+	unlock := openQuery.lockConn()
+	defer unlock()
+
+	untrack := openQuery.track()
+	defer untrack()
+
+	for len(openQuery.pending) < count {
+		if !openQuery.rows.Next() {
+			err := explainCancelled(openQuery.ctx, rowsEndErr(openQuery.ctx, openQuery.rows))
+			if err != nil {
+				return err
+			}
+
+			return openQuery.closeRows()
+		}
+
+		row, err := openQuery.scanner.scan(openQuery.rows)
+		if err != nil {
+			return err
+		}
+		openQuery.pending = append(openQuery.pending, row)
+	}
+
+	return nil
+}
+
+// Selected column names, order preserved
+func (openQuery *OpenQuery) Columns() []string {
+	openQuery.mu.Lock()
+	defer openQuery.mu.Unlock()
+
+	if openQuery.scanner == nil {
+		return []string{}
+	}
+
+	return openQuery.scanner.columns
+}
+
+// Stop reading rows and let other queries run on the client again, safe to call more than once
+// May be called from another goroutine to interrupt a Fetch waiting on the database
+func (openQuery *OpenQuery) Close() error {
+	// Only interrupt a Fetch in progress, cancelling otherwise could needlessly cost the connection (i.e. for MySQL)
+	if !openQuery.mu.TryLock() {
+		openQuery.closeRequested.Store(true)
+		openQuery.cancel(nil)
+		openQuery.mu.Lock()
+	}
+	defer openQuery.mu.Unlock()
+
+	openQuery.closed = true
+	openQuery.pending = nil
+	return openQuery.release(nil)
+}
+
+// Close the rows (if still open), hand the connection back and log the query, only the first call does anything
+func (openQuery *OpenQuery) release(err error) (closeErr error) {
+	if openQuery.released {
+		return nil
+	}
+
+	unlock := openQuery.lockConn()
+	closeErr = openQuery.closeRows()
+	if openQuery.db._openQuery == openQuery {
+		openQuery.db._openQuery = nil
+	}
+	unlock()
+
+	openQuery.releaseConn(errors.Join(err, closeErr))
+	return closeErr
+}
+
+// Hand the connection back and log the query, for once the rows are closed or were never opened
+func (openQuery *OpenQuery) releaseConn(err error) {
+	openQuery.released = true
+	defer openQuery.cancel(nil)
+
+	if openQuery.slot != nil && openQuery.slot.conn != nil {
+		_ = openQuery.slot.conn.Close()
+		openQuery.slot.conn = nil
+	}
+	openQuery.db.logQuery(openQuery.statement, openQuery.args, openQuery.startedAt, &err)
+}
+
+func (openQuery *OpenQuery) closeRows() error {
+	if openQuery.rows == nil {
+		return nil
+	}
+
+	rows := openQuery.rows
+	openQuery.rows = nil
+	if err := rows.Close(); err != nil {
+		return errors.Join(
+			errors.New("Failed to cleanup rows"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Take turns with other statements on the single connection, in pool mode the OpenQuery's connection is its own
+func (openQuery *OpenQuery) lockConn() (unlock func()) {
+	if openQuery.slot != nil {
+		return func() {}
+	}
+
+	openQuery.db.connMu.Lock()
+	return openQuery.db.connMu.Unlock
+}
+
+// Register with the client while talking to the database, so DBClient.Cancel interrupts it
+func (openQuery *OpenQuery) track() (untrack func()) {
+	query := &activeQuery{cancel: openQuery.cancel}
+
+	db := openQuery.db
+	db.activeQueryMu.Lock()
+	db.activeQueries[query] = struct{}{}
+	db.activeQueryMu.Unlock()
+
+	return func() {
+		db.activeQueryMu.Lock()
+		delete(db.activeQueries, query)
+		db.activeQueryMu.Unlock()
+
+		db.resetIdleClose()
+	}
+}
+
+// Other queries can't share the single connection with an open query's rows, see QueryOpen
+// Expects connMu to be held
+func (db *DBClient) assertNoOpenQuery() error {
+	if db._openQuery != nil {
+		return ErrQueryOpen
+	}
+
+	return nil
+}
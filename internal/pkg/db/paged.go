@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Run a single page of a SELECT, pages start at 1
+// Also returns the total number of rows the query would return without paging
+// The total is best-effort, it's -1 when it couldn't be determined (the page itself is still returned)
+func (db *DBClient) QueryPaged(baseQuery string, page int, pageSize int) (results *QueryResult, total int, err error) {
+	return db.QueryPagedContext(db.ctx, baseQuery, page, pageSize)
+}
+
+// Same as QueryPaged, but the queries can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryPagedContext(
+	ctx context.Context,
+	baseQuery string,
+	page int,
+	pageSize int,
+) (results *QueryResult, total int, err error) {
+	if page < 1 || pageSize < 1 {
+		return nil, -1, fmt.Errorf("Invalid page %d of size %d, both must be at least 1", page, pageSize)
+	}
+
+	pagedQuery, err := buildPagedQuery(baseQuery, db.connManager.GetFlavor(), (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	results, err = db.QueryContext(ctx, pagedQuery)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return results, db.countRows(ctx, baseQuery), nil
+}
+
+func (db *DBClient) countRows(ctx context.Context, baseQuery string) int {
+	// Newlines keep a trailing -- comment in the base query from swallowing what follows
+	countQuery := fmt.Sprintf("SELECT COUNT(*) AS total FROM (%s\n) AS paged_count", trimStatement(baseQuery))
+
+	countResult, err := db.query(ctx, db.lookupScanOptions(), countQuery, nil)
+	if err != nil || len(countResult.Rows) != 1 {
+		return -1
+	}
+
+	total, err := strconv.Atoi(countResult.Rows[0]["total"].String)
+	if err != nil {
+		return -1
+	}
+
+	return total
+}
+
+var (
+	pagingClauseRegExp          = regexp.MustCompile(`(?i)\b(LIMIT|OFFSET|FETCH)\b`)
+	sqlServerPagingClauseRegExp = regexp.MustCompile(`(?i)\b(OFFSET|FETCH|TOP)\b`)
+	orderByRegExp               = regexp.MustCompile(`(?i)\bORDER\s+BY\b`)
+)
+
+func buildPagedQuery(baseQuery string, flavor DBFlavor, offset int, limit int) (string, error) {
+	if StatementType(baseQuery) != Select {
+		return "", errors.New("Only SELECT queries can be paged")
+	}
+
+	query := trimStatement(baseQuery)
+
+	// Adding our own clause on top would either be a syntax error or silently page the wrong rows
+	topLevelQuery := topLevelSQL(query, flavor)
+	clauseRegExp := pagingClauseRegExp
+	if flavor == SQLServer {
+		clauseRegExp = sqlServerPagingClauseRegExp
+	}
+	if pagingClause := clauseRegExp.FindString(topLevelQuery); pagingClause != "" {
+		return "", fmt.Errorf("Query already contains %s, remove it to page through the results", strings.ToUpper(pagingClause))
+	}
+
+	switch flavor {
+	case SQLServer:
+		{
+			// OFFSET/FETCH is only valid after an ORDER BY
+			if !orderByRegExp.MatchString(topLevelQuery) {
+				query += "\nORDER BY (SELECT NULL)"
+			}
+			return fmt.Sprintf("%s\nOFFSET %d ROWS FETCH NEXT %d ROWS ONLY", query, offset, limit), nil
+		}
+	default:
+		{
+			return fmt.Sprintf("%s\nLIMIT %d OFFSET %d", query, limit, offset), nil
+		}
+	}
+}
+
+// Drop the trailing ; so the statement can be embedded or extended
+func trimStatement(statement string) string {
+	return strings.TrimRight(strings.TrimSpace(statement), "; \t\r\n")
+}
+
+// The statement with quoted strings, comments and anything inside parentheses blanked out
+// Leaves only the outermost clauses, so keywords in subqueries or literals aren't mistaken for them
+func topLevelSQL(statement string, flavor DBFlavor) string {
+	var topLevel strings.Builder
+	depth := 0
+
+	for idx := 0; idx < len(statement); idx++ {
+		char := statement[idx]
+		remaining := statement[idx:]
+
+		var skipTo int
+		switch {
+		case char == '\'' || char == '"' || char == '`' || (char == '[' && flavor == SQLServer):
+			{
+				if char == '[' {
+					skipTo = len(statement)
+					if end := strings.IndexByte(remaining, ']'); end != -1 {
+						skipTo = idx + end + 1
+					}
+				} else {
+					skipTo = quotedEnd(statement, idx, flavor == MySQL)
+				}
+			}
+		case strings.HasPrefix(remaining, "--") || (char == '#' && flavor == MySQL):
+			skipTo = lineEnd(statement, idx)
+		case strings.HasPrefix(remaining, "/*"):
+			skipTo = blockCommentEnd(statement, idx)
+		case char == '$' && flavor == PostgreSQL:
+			skipTo = dollarQuotedEnd(statement, idx)
+		case char == '(':
+			{
+				depth++
+				topLevel.WriteByte(' ')
+				continue
+			}
+		case char == ')':
+			{
+				depth = max(depth-1, 0)
+				topLevel.WriteByte(' ')
+				continue
+			}
+		default:
+			{
+				if depth == 0 {
+					topLevel.WriteByte(char)
+				} else {
+					topLevel.WriteByte(' ')
+				}
+				continue
+			}
+		}
+
+		// Keep word boundaries intact where the skipped section was
+		topLevel.WriteByte(' ')
+		idx = skipTo - 1
+	}
+
+	return topLevel.String()
+}
@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// See DBClientOptions.PoolSize
+func (db *DBClient) pooled() bool {
+	return db.clientOptions.PoolSize > 0
+}
+
+// Runs connInit on every connection the driver opens, before database/sql hands it out
+type initConnector struct {
+	driver.Connector
+	connInit func(ctx context.Context, conn driver.Conn) error
+}
+
+func (connector *initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := connector.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = connector.connInit(ctx, conn); err != nil {
+		// Never handed out, nothing else will close it
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// For drivers which can't open a connector for a DSN themselves
+type dsnConnector struct {
+	dataSourceName string
+	sqlDriver      driver.Driver
+}
+
+func (connector *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return connector.sqlDriver.Open(connector.dataSourceName)
+}
+
+func (connector *dsnConnector) Driver() driver.Driver {
+	return connector.sqlDriver
+}
+
+// Open the pool through a connector running connInit on each new connection
+func openWithConnInit(
+	driverName string,
+	dataSourceName string,
+	connInit func(ctx context.Context, conn driver.Conn) error,
+) (*sqlx.DB, error) {
+	// Only to look up the registered driver, this doesn't connect
+	lookup, err := sqlx.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	sqlDriver := lookup.Driver()
+	_ = lookup.Close()
+
+	var connector driver.Connector = &dsnConnector{dataSourceName, sqlDriver}
+	if driverContext, ok := sqlDriver.(driver.DriverContext); ok {
+		if connector, err = driverContext.OpenConnector(dataSourceName); err != nil {
+			return nil, err
+		}
+	}
+
+	return sqlx.NewDb(sql.OpenDB(&initConnector{connector, connInit}), driverName), nil
+}
+
+// Bring a new pooled connection's session in line with what _conn gets in getConnection
+func (db *DBClient) initPooledConn(ctx context.Context, conn driver.Conn) error {
+	db.sessionMu.Lock()
+	sessionInit := db.sessionInit
+	readOnly := db.readOnly
+	searchPath := db.searchPath
+	db.sessionMu.Unlock()
+
+	for _, statement := range sessionInit {
+		startedAt := time.Now()
+		err := execDriverConn(ctx, conn, statement)
+		db.logQuery(statement, nil, startedAt, &err)
+		if err != nil {
+			return errors.Join(
+				fmt.Errorf("Failed to initialize session with %q", statement),
+				err,
+			)
+		}
+	}
+
+	if len(searchPath) > 0 {
+		statement := searchPathStatement(searchPath)
+		startedAt := time.Now()
+		err := execDriverConn(ctx, conn, statement)
+		db.logQuery(statement, nil, startedAt, &err)
+		if err != nil {
+			return errors.Join(
+				errors.New("Failed to set search_path"),
+				err,
+			)
+		}
+	}
+
+	if readOnly && db.connManager.GetFlavor() == PostgreSQL {
+		if err := execDriverConn(ctx, conn, "SET default_transaction_read_only = on"); err != nil {
+			return errors.Join(
+				errors.New("Failed to set session to read-only"),
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Run a statement without args directly on a driver connection, which database/sql hasn't wrapped yet
+func execDriverConn(ctx context.Context, conn driver.Conn, statement string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, statement, nil)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, statement)
+	} else {
+		stmt, err = conn.Prepare(statement)
+	}
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if stmtExecer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = stmtExecer.ExecContext(ctx, nil)
+	} else {
+		_, err = stmt.Exec(nil)
+	}
+	return err
+}
+
+// Connection checked out for the query running under a context, see trackActiveQuery
+type pooledConnKey struct{}
+
+type pooledConnSlot struct {
+	conn *sqlx.Conn
+}
+
+// Pool mode's getConnection, the connection stays checked out for the rest of the query
+func (db *DBClient) getPooledConnection(ctx context.Context) (*sqlx.Conn, error) {
+	slot, ok := ctx.Value(pooledConnKey{}).(*pooledConnSlot)
+	if !ok {
+		return nil, errors.New("No connection was checked out for the query")
+	}
+
+	if slot.conn != nil {
+		// Only asked again to retry or run another statement, i.e. a lookup before the query itself
+		err := slot.conn.PingContext(ctx)
+		if err == nil {
+			return slot.conn, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.Join(
+				ErrConnectionFailed,
+				ctxErr,
+			)
+		}
+
+		_ = discardConn(slot.conn)
+		slot.conn = nil
+	}
+
+	conn, err := db.sqlDB.Connx(ctx)
+	if err != nil {
+		return nil, errors.Join(
+			ErrConnectionFailed,
+			err,
+		)
+	}
+
+	slot.conn = conn
+	return conn, nil
+}
+
+// The connection the query running under ctx is using, nil if it hasn't connected yet
+func (db *DBClient) activeConn(ctx context.Context) *sqlx.Conn {
+	if slot, ok := ctx.Value(pooledConnKey{}).(*pooledConnSlot); ok {
+		return slot.conn
+	}
+
+	return db._conn
+}
+
+// Drop every idle connection in the pool, so the next ones start a fresh session
+// Call with connMu held, so sqlDB isn't swapped out meanwhile (only idle closing gets by with a read lock)
+func (db *DBClient) closeIdleConns() {
+	db.sqlDB.SetMaxIdleConns(0)
+	db.sqlDB.SetMaxIdleConns(db.clientOptions.PoolSize)
+}
+
+// Change session settings under sessionMu, for initPooledConn to read
+// Connections already checked out (i.e. by a transaction) are left with the old settings, see sessionGeneration
+func (db *DBClient) updateSession(update func()) {
+	db.sessionMu.Lock()
+	defer db.sessionMu.Unlock()
+
+	update()
+	db.sessionGeneration++
+	// Cached results were read under the old settings, i.e. another search_path
+	db.resultCache.clear()
+}
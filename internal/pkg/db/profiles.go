@@ -0,0 +1,261 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Read named connection profiles from a YAML (.yaml/.yml) or TOML (.toml) file, keyed by profile name, ex:
+//
+//	staging:
+//	  flavor: postgres
+//	  host: staging.internal
+//	  user: app
+//	  password: ${PGPASSWORD}
+//	  database: shop
+//	  safe_mode: true
+//	  tls:
+//	    mode: verify-full
+//	    ca_cert: /etc/ssl/staging-ca.pem
+//
+// Other keys are port, socket, tls client_cert and client_key, and options (see DBConnOptions.AdditionalOptions)
+// ${NAME} anywhere in a value is replaced with that environment variable, so secrets can stay out of the file
+func LoadProfiles(path string) (map[string]ConnManager, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Join(
+			errors.New("Failed to read profiles"),
+			err,
+		)
+	}
+
+	var rawProfiles map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &rawProfiles)
+	case ".toml":
+		err = toml.Unmarshal(contents, &rawProfiles)
+	default:
+		return nil, fmt.Errorf("Unknown profiles format %q, expected .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, errors.Join(
+			fmt.Errorf("Failed to parse profiles in %s", path),
+			err,
+		)
+	}
+
+	profiles := make(map[string]ConnManager, len(rawProfiles))
+	for name, rawProfile := range rawProfiles {
+		fields, ok := rawProfile.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("Profile %q must be a table of connection options", name)
+		}
+
+		connOptions, err := profileConnOptions(fields)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid profile %q: %w", name, err)
+		}
+		profiles[name] = connOptions
+	}
+
+	return profiles, nil
+}
+
+func profileConnOptions(fields map[string]any) (*DBConnOptions, error) {
+	connOptions := DBConnOptions{}
+
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "flavor":
+			{
+				var flavor string
+				if flavor, err = profileString(key, value); err == nil {
+					connOptions.Flavor, err = profileFlavor(flavor)
+				}
+			}
+		case "host":
+			connOptions.Host, err = profileString(key, value)
+		case "port":
+			connOptions.Port, err = profilePort(value)
+		case "user":
+			connOptions.User, err = profileString(key, value)
+		case "password":
+			connOptions.Password, err = profileString(key, value)
+		case "database":
+			connOptions.DatabaseName, err = profileString(key, value)
+		case "socket":
+			connOptions.Socket, err = profileString(key, value)
+		case "safe_mode":
+			connOptions.SafeMode, err = profileBool(key, value)
+		case "tls":
+			connOptions.TLS, err = profileTLS(value)
+		case "options":
+			connOptions.AdditionalOptions, err = profileOptions(value)
+		default:
+			err = fmt.Errorf("Unknown option %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if connOptions.Flavor == "" {
+		return nil, errors.New("flavor must be set, one of mysql, postgres, sqlite, sqlserver")
+	}
+	if err := connOptions.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &connOptions, nil
+}
+
+func profileFlavor(name string) (DBFlavor, error) {
+	switch strings.ToLower(name) {
+	case "mysql":
+		return MySQL, nil
+	case "postgres", "postgresql", "pgx":
+		return PostgreSQL, nil
+	case "sqlite":
+		return SQLite, nil
+	case "sqlserver", "mssql":
+		return SQLServer, nil
+	default:
+		return "", fmt.Errorf("Unknown flavor %q, expected one of mysql, postgres, sqlite, sqlserver", name)
+	}
+}
+
+func profileTLS(value any) (TLSOptions, error) {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return TLSOptions{}, errors.New("tls must be a table")
+	}
+
+	tlsOptions := TLSOptions{}
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "mode":
+			{
+				var mode string
+				mode, err = profileString("tls mode", value)
+				tlsOptions.Mode = TLSMode(mode)
+			}
+		case "ca_cert":
+			tlsOptions.CACert, err = profileString("tls ca_cert", value)
+		case "client_cert":
+			tlsOptions.ClientCert, err = profileString("tls client_cert", value)
+		case "client_key":
+			tlsOptions.ClientKey, err = profileString("tls client_key", value)
+		default:
+			err = fmt.Errorf("Unknown tls option %q", key)
+		}
+		if err != nil {
+			return TLSOptions{}, err
+		}
+	}
+
+	return tlsOptions, tlsOptions.validate()
+}
+
+func profileOptions(value any) (map[string]string, error) {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("options must be a table")
+	}
+
+	options := make(map[string]string, len(fields))
+	for key, value := range fields {
+		// Driver params are strings in the DSN anyway, ex: parseTime: true
+		switch value.(type) {
+		case bool, int, int64, float64:
+			value = fmt.Sprint(value)
+		}
+
+		option, err := profileString(fmt.Sprint("option ", key), value)
+		if err != nil {
+			return nil, err
+		}
+		options[key] = option
+	}
+
+	return options, nil
+}
+
+func profileString(key string, value any) (string, error) {
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", key)
+	}
+
+	return expandProfileEnv(str)
+}
+
+func profileBool(key string, value any) (bool, error) {
+	boolean, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+
+	return boolean, nil
+}
+
+// Either a number, or a string so it can come from the environment, ex: port: ${PGPORT}
+func profilePort(value any) (uint, error) {
+	var rawPort string
+	switch port := value.(type) {
+	case int:
+		rawPort = strconv.Itoa(port)
+	case int64:
+		rawPort = strconv.FormatInt(port, 10)
+	case string:
+		{
+			var err error
+			if rawPort, err = expandProfileEnv(port); err != nil {
+				return 0, err
+			}
+		}
+	default:
+		return 0, errors.New("port must be a number")
+	}
+
+	port, err := strconv.ParseUint(rawPort, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid port %q", rawPort)
+	}
+
+	return uint(port), nil
+}
+
+var profileEnvPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Replace each ${NAME} with the environment variable, a variable that isn't set is an error rather than silently empty
+// Only the braced form is expanded, a lone $ (i.e. in a password) is kept as is
+func expandProfileEnv(value string) (string, error) {
+	var missing []string
+	expanded := profileEnvPattern.ReplaceAllStringFunc(value, func(match string) string {
+		variable := profileEnvPattern.FindStringSubmatch(match)[1]
+
+		envValue, ok := os.LookupEnv(variable)
+		if !ok && !slices.Contains(missing, variable) {
+			missing = append(missing, variable)
+		}
+		return envValue
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("Environment variable %s is not set", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
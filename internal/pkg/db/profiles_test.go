@@ -0,0 +1,139 @@
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeProfiles(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadProfiles(t *testing.T) {
+	expected := map[string]db.ConnManager{
+		"staging": &db.DBConnOptions{
+			Flavor:       db.PostgreSQL,
+			Host:         "staging.internal",
+			Port:         5433,
+			User:         "app",
+			Password:     "pa$$word",
+			DatabaseName: "shop",
+			SafeMode:     true,
+			TLS: db.TLSOptions{
+				Mode:   db.TLSVerifyFull,
+				CACert: "/etc/ssl/staging-ca.pem",
+			},
+			AdditionalOptions: map[string]string{"application_name": "redline", "connect_timeout": "5"},
+		},
+		"local": &db.DBConnOptions{
+			Flavor:       db.SQLite,
+			DatabaseName: "/tmp/local.db",
+		},
+	}
+
+	t.Run("YAML", func(t *testing.T) {
+		assert := assert.New(t)
+		t.Setenv("STAGING_PASSWORD", "pa$$word")
+		t.Setenv("STAGING_PORT", "5433")
+
+		path := writeProfiles(t, "profiles.yaml", `
+staging:
+  flavor: postgres
+  host: staging.internal
+  port: ${STAGING_PORT}
+  user: app
+  password: ${STAGING_PASSWORD}
+  database: shop
+  safe_mode: true
+  tls:
+    mode: verify-full
+    ca_cert: /etc/ssl/staging-ca.pem
+  options:
+    application_name: redline
+    connect_timeout: 5
+local:
+  flavor: sqlite
+  database: /tmp/local.db
+`)
+
+		profiles, err := db.LoadProfiles(path)
+		assert.NoError(err)
+		assert.Equal(expected, profiles)
+	})
+
+	t.Run("TOML", func(t *testing.T) {
+		assert := assert.New(t)
+		t.Setenv("STAGING_PASSWORD", "pa$$word")
+
+		path := writeProfiles(t, "profiles.toml", `
+# Shared staging database
+[staging]
+flavor = "postgres"
+host = "staging.internal"
+port = 5433
+user = 'app'
+password = "${STAGING_PASSWORD}"
+database = "shop"
+safe_mode = true
+options = { application_name = "redline", connect_timeout = 5 }
+
+[staging.tls]
+mode = "verify-full"
+ca_cert = "/etc/ssl/staging-ca.pem" # Issued by the internal CA
+
+[local]
+flavor = "sqlite"
+database = "/tmp/local.db"
+`)
+
+		profiles, err := db.LoadProfiles(path)
+		assert.NoError(err)
+		assert.Equal(expected, profiles)
+	})
+
+	t.Run("Unset environment variable", func(t *testing.T) {
+		assert := assert.New(t)
+		os.Unsetenv("REDLINE_UNSET_PASSWORD")
+
+		path := writeProfiles(t, "profiles.yaml", `
+staging:
+  flavor: mysql
+  host: staging.internal
+  password: ${REDLINE_UNSET_PASSWORD}
+`)
+
+		_, err := db.LoadProfiles(path)
+		assert.ErrorContains(err, `Invalid profile "staging"`)
+		assert.ErrorContains(err, "REDLINE_UNSET_PASSWORD is not set")
+	})
+
+	t.Run("Invalid profiles", func(t *testing.T) {
+		for name, contents := range map[string]string{
+			"profiles.yaml": "staging:\n  flavor: mysql\n  hostname: staging.internal\n",
+			"missing.yaml":  "staging:\n  host: staging.internal\n",
+			"flavor.toml":   "[staging]\nflavor = \"oracle\"\n",
+			"tls.toml":      "[staging]\nflavor = \"mysql\"\ntls = { mode = \"sometimes\" }\n",
+			"syntax.toml":   "[staging]\nflavor = mysql\n",
+			"twice.toml":    "[staging]\nflavor = \"mysql\"\nflavor = \"pgx\"\n",
+			"array.toml":    "[staging]\nflavor = \"mysql\"\nhosts = [\"a\", \"b\"]\n",
+			"profiles.ini":  "[staging]\nflavor = mysql\n",
+		} {
+			_, err := db.LoadProfiles(writeProfiles(t, name, contents))
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := db.LoadProfiles(filepath.Join(t.TempDir(), "profiles.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
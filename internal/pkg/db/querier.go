@@ -0,0 +1,18 @@
+package db
+
+import "context"
+
+// What consumers (i.e. the UI) need from a database client, so a fake can be substituted in tests
+// See the dbtest package for an in-memory implementation
+// Like DBClient, a successful Query always returns a result, see QueryResult.NoResultSet
+type Querier interface {
+	Query(statement string, args ...any) (*QueryResult, error)
+	QueryContext(ctx context.Context, statement string, args ...any) (*QueryResult, error)
+	Exec(statement string, args ...any) (*ExecResult, error)
+	ExecContext(ctx context.Context, statement string, args ...any) (*ExecResult, error)
+	// Stop the query that's currently running, if any
+	Cancel()
+	Destroy() error
+}
+
+var _ Querier = (*DBClient)(nil)
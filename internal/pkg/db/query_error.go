@@ -0,0 +1,85 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+	"modernc.org/sqlite"
+)
+
+// Error the database returned for a statement, with its own error code, ex:
+//
+//	var queryErr *db.QueryError
+//	if errors.As(err, &queryErr) && queryErr.SQLState == "23505" {
+//		fmt.Println("That record already exists")
+//	}
+type QueryError struct {
+	// Native error code, the error number for MySQL (ex: 1062) and SQL Server, the extended result code for SQLite
+	// For PostgreSQL, the SQLSTATE
+	Code string
+	// Standard SQLSTATE (ex: 23505), empty for SQLite and SQL Server which don't report one
+	SQLState string
+	// As reported by the database, without the driver's prefix
+	Message string
+	Wrapped error
+}
+
+func (queryErr *QueryError) Error() string {
+	return queryErr.Wrapped.Error()
+}
+
+func (queryErr *QueryError) Unwrap() error {
+	return queryErr.Wrapped
+}
+
+// Wrap err in a QueryError if it came from the database, otherwise (i.e. a connection failure) it's returned as is
+func asQueryError(err error) error {
+	var queryErr *QueryError
+	if err == nil || errors.As(err, &queryErr) {
+		return err
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return &QueryError{
+			Code:     strconv.Itoa(int(mysqlErr.Number)),
+			SQLState: strings.TrimRight(string(mysqlErr.SQLState[:]), "\x00"),
+			Message:  mysqlErr.Message,
+			Wrapped:  err,
+		}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return &QueryError{
+			Code:     pgErr.Code,
+			SQLState: pgErr.Code,
+			Message:  pgErr.Message,
+			Wrapped:  err,
+		}
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return &QueryError{
+			Code:    strconv.Itoa(sqliteErr.Code()),
+			Message: sqliteErr.Error(),
+			Wrapped: err,
+		}
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		return &QueryError{
+			Code:    strconv.Itoa(int(mssqlErr.Number)),
+			Message: mssqlErr.Message,
+			Wrapped: err,
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Run a query and scan the rows straight into dest, mapped by sqlx (columns to fields by `db` tag, or lowercased name)
+// dest is either a pointer to a slice, filled with every row, or a pointer to a struct, filled from the first row, ex:
+//
+//	var users []struct {
+//		ID    int64  `db:"id"`
+//		Email string `db:"email"`
+//	}
+//	err := dbClient.QueryInto(&users, "SELECT id, email FROM users")
+//
+// Values are kept as the driver returns them, none of the display settings (i.e. SetNullDisplay) apply
+// A struct dest with no matching row fails with sql.ErrNoRows, a column without a matching field is an error
+func (db *DBClient) QueryInto(dest any, statement string, args ...any) error {
+	return db.QueryIntoContext(db.ctx, dest, statement, args...)
+}
+
+// Same as QueryInto, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryIntoContext(ctx context.Context, dest any, statement string, args ...any) (err error) {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Pointer || destValue.IsNil() {
+		return fmt.Errorf("QueryInto needs a pointer to a slice or struct, got %T", dest)
+	}
+
+	intoSlice := destValue.Elem().Kind() == reflect.Slice
+	if !intoSlice && destValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("QueryInto needs a pointer to a slice or struct, got %T", dest)
+	}
+
+	defer db.recordHistory(statement, time.Now(), &err)
+	defer db.logQuery(statement, args, time.Now(), &err)
+	if err = db.assertStatementAllowed(statement); err != nil {
+		return err
+	}
+
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, db.explainQueryTimeout(ctx, err)) }()
+
+	if db.shouldDryRun(statement) {
+		return errors.New("Nothing to scan into dest, the statement was only validated in dry run mode")
+	}
+
+	db.invalidateCachedServerInfo(statement)
+	db.resultCache.invalidateFor(statement)
+
+	conn, err := db.getConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	statementWithParams, err := db.transformStatement(ctx, statement, args)
+	if err != nil {
+		return errors.Join(
+			ErrQueryFailed,
+			asQueryError(err),
+		)
+	}
+
+	scanInto := func(conn *sqlx.Conn) error {
+		if intoSlice {
+			return conn.SelectContext(ctx, dest, statementWithParams.statement, statementWithParams.params...)
+		}
+		return conn.GetContext(ctx, dest, statementWithParams.statement, statementWithParams.params...)
+	}
+
+	err = scanInto(conn)
+	if err != nil && db.shouldRetryQuery(ctx, statement, err) {
+		// Start over, rather than appending to whatever was scanned before the connection dropped
+		destValue.Elem().SetZero()
+
+		// The dead connection fails its ping, so this reconnects
+		if conn, err = db.getConnection(ctx); err != nil {
+			return err
+		}
+		err = scanInto(conn)
+	}
+	if err != nil {
+		return errors.Join(
+			ErrQueryFailed,
+			asQueryError(err),
+		)
+	}
+
+	return nil
+}
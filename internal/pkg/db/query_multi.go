@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Run a statement that may return several result sets (i.e. a stored procedure) and store each of them
+// Query only reads the first result set
+// For MySQL, several ;-separated statements in one call also need multiStatements=true in the additional options
+func (db *DBClient) QueryMulti(statement string, args ...any) (results []*QueryResult, err error) {
+	return db.QueryMultiContext(db.ctx, statement, args...)
+}
+
+// Same as QueryMulti, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryMultiContext(ctx context.Context, statement string, args ...any) (results []*QueryResult, err error) {
+	defer db.recordHistory(statement, time.Now(), &err)
+	defer db.logQuery(statement, args, time.Now(), &err)
+	defer func() { db.setMultiStmtTypes(results, statement) }()
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, db.explainQueryTimeout(ctx, err)) }()
+
+	if db.shouldDryRun(statement) {
+		rowsEstimate, err := db.dryRunStatement(ctx, statement, args)
+		if err != nil {
+			return nil, err
+		}
+		return []*QueryResult{newDryRunQueryResult(rowsEstimate)}, nil
+	}
+
+	startedAt := time.Now()
+	rows, err := db.queryRows(ctx, statement, args)
+	if err != nil || rows == nil {
+		return nil, err
+	}
+	queryDuration := time.Since(startedAt)
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			err = errors.Join(
+				err,
+				errors.New("Failed to cleanup rows"),
+				closeErr,
+			)
+			results = nil
+		}
+	}()
+
+	opts := db.scanOptions()
+	for {
+		scanStartedAt := time.Now()
+
+		collector := newRowCollector(opts)
+		columns, columnTypes, err := scanResultSet(ctx, rows, opts, collector.add)
+		if err != nil && !errors.Is(err, errStopIteration) {
+			return nil, err
+		}
+
+		// i.e. the status MySQL sends after a procedure's results, there's nothing to show for it
+		if len(columns) > 0 {
+			result := &QueryResult{
+				Rows:         collector.rows,
+				Columns:      columns,
+				ColumnTypes:  columnTypes,
+				MaxWidth:     collector.maxWidths(columns),
+				Truncated:    collector.truncated,
+				nullDisplay:  opts.nullDisplay,
+				binaryFormat: opts.binaryFormat,
+			}
+			result.setTiming(queryDuration, time.Since(scanStartedAt))
+			results = append(results, result)
+		}
+
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+
+	// NextResultSet reports failures through Err, same as Next
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(
+			ErrRowScan,
+			err,
+		)
+	}
+
+	return results, nil
+}
+
+// Each result set is typed by the statement it came from, when that can be told
+// A procedure may return any number of result sets, or none, so with a CALL (or anything else that's Other)
+// among the statements, or a different number of results than statements, the types are left unset
+func (db *DBClient) setMultiStmtTypes(results []*QueryResult, statement string) {
+	statements := SplitStatements(statement, db.connManager.GetFlavor())
+	if len(statements) != len(results) {
+		return
+	}
+	for _, statement := range statements {
+		if StatementType(statement) == Other {
+			return
+		}
+	}
+
+	for idx, result := range results {
+		result.setStmtType(statements[idx])
+	}
+}
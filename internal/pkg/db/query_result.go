@@ -1,19 +1,52 @@
 package db
 
 import (
+	"bufio"
+	"bytes"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
-	"strings"
+	"io"
+	"strconv"
+	"time"
 )
 
+// A column value that keeps track of whether it was NULL
+// Use Valid (or IsNull) rather than comparing the rendered string, a row could contain the text "NULL"
 type NullString struct {
 	sql.NullString
+	// Whether the value was cut short, see DBClient.SetMaxColumnBytes
+	Truncated bool
+	// Set when String was changed for display, see RawValue
+	raw string
 }
 
+func (nullString *NullString) IsNull() bool {
+	return !nullString.Valid
+}
+
+// How NULL values are rendered unless configured otherwise, see QueryResult.SetNullDisplay
+const DefaultNullDisplay = "NULL"
+
+// Displayable form of the value, NULL values are rendered as "NULL"
 func (nullString *NullString) ToString() string {
+	return nullString.Display(DefaultNullDisplay)
+}
+
+// Same as ToString, with NULL values rendered as nullDisplay
+func (nullString *NullString) Display(nullDisplay string) string {
 	if !nullString.Valid {
-		return "NULL"
+		return nullDisplay
+	}
+
+	return nullString.String
+}
+
+// The value as the database returned it, i.e. 1 for a boolean displayed as true (see DBClient.SetBoolDisplay)
+func (nullString *NullString) RawValue() string {
+	if nullString.raw != "" {
+		return nullString.raw
 	}
 
 	return nullString.String
@@ -36,6 +69,97 @@ type QueryResult struct {
 	Rows []map[string]*NullString
 	// Column names, order preserved with how they were selected
 	Columns []string
+	// Database type name of each column (ex: INT, VARCHAR), aligned by index with Columns
+	// May be empty for a column if the driver doesn't report it
+	ColumnTypes []string
+	// Display width of the widest value in each column (header included), aligned by index with Columns
+	// Counted in terminal cells, so wide characters (i.e. CJK) count as 2, before any TableOptions.MaxColumnWidth
+	// NULL values count as the null display set when the query ran
+	MaxWidth []int
+	// Total time to run the query and read every row
+	Elapsed time.Duration
+	// Time until the database responded, including checking the connection is alive
+	QueryDuration time.Duration
+	// Time spent reading the rows client-side, can dominate Elapsed for large results
+	ScanDuration time.Duration
+	// Raw plan from ExplainWithOptions when requested as JSON, otherwise empty
+	PlanJSON string
+	// Raised by the query, see SetCollectWarnings
+	Warnings []string
+	// More rows were available than DBClient.SetMaxRows allows, only the first ones are in Rows
+	Truncated bool
+	// The statement didn't produce a result set at all (i.e. DDL run through Query), Columns and Rows are empty
+	// A SELECT matching nothing still has its Columns, with no Rows
+	NoResultSet bool
+	// Rows changed by a statement DBClient.Run sent through Exec, 0 otherwise
+	RowsAffected int64
+	// Kind of statement which produced the results, see StatementType
+	// Empty for QueryMulti results that can't be matched to a statement, i.e. from a CALL
+	StmtType StmtType
+	// nil uses DefaultNullDisplay
+	nullDisplay *string
+	// How binary values in Rows were encoded, to decode them in TypedRows
+	binaryFormat BinaryFormat
+}
+
+// Render NULL values as display (ex: an empty string, ∅ or \N) in the table and CSV output
+func (queryResult *QueryResult) SetNullDisplay(display string) {
+	queryResult.nullDisplay = &display
+}
+
+func (queryResult *QueryResult) NullDisplay() string {
+	if queryResult.nullDisplay == nil {
+		return DefaultNullDisplay
+	}
+
+	return *queryResult.nullDisplay
+}
+
+// Displayable form of a value from the results, NULL values are rendered using NullDisplay
+func (queryResult *QueryResult) DisplayValue(value *NullString) string {
+	if value == nil {
+		return queryResult.NullDisplay()
+	}
+
+	return value.Display(queryResult.NullDisplay())
+}
+
+// Result of a statement which didn't produce a result set
+func newNoResultSetQueryResult() *QueryResult {
+	return &QueryResult{
+		Rows:        []map[string]*NullString{},
+		Columns:     []string{},
+		ColumnTypes: []string{},
+		MaxWidth:    []int{},
+		NoResultSet: true,
+	}
+}
+
+// Deferred by the query methods, results may be nil when the query failed
+func (queryResult *QueryResult) setStmtType(statement string) {
+	if queryResult == nil {
+		return
+	}
+
+	queryResult.StmtType = StatementType(statement)
+}
+
+func (queryResult *QueryResult) setTiming(queryDuration time.Duration, scanDuration time.Duration) {
+	queryResult.QueryDuration = queryDuration
+	queryResult.ScanDuration = scanDuration
+	queryResult.Elapsed = queryDuration + scanDuration
+}
+
+type ExecResult struct {
+	// When DryRun, this is the planner's estimate instead, -1 if it couldn't be determined
+	RowsAffected int64
+	// Last auto-generated ID from an INSERT
+	// nil when the driver doesn't support it, such as PostgreSQL (use RETURNING instead)
+	LastInsertId *int64
+	// The statement was only validated, see SetDryRun
+	DryRun bool
+	// Raised by the statement, see SetCollectWarnings
+	Warnings []string
 }
 
 func (queryResult *QueryResult) ToJSON() (res []byte) {
@@ -52,25 +176,187 @@ func (queryResult *QueryResult) ToJSON() (res []byte) {
 	return res
 }
 
-func (queryResult *QueryResult) ToCSV() (res []byte) {
-	var resString strings.Builder
+type JSONOptions struct {
+	// Use ColumnTypes to write numbers and booleans as native JSON values
+	// Otherwise every value is written as a string (or null), which round trips without loss
+	CoerceTypes bool
+}
+
+// Write the results as a JSON array of objects keyed by column name, with every value as a string or null
+func (queryResult *QueryResult) WriteJSON(w io.Writer) error {
+	return queryResult.WriteJSONWithOptions(w, JSONOptions{})
+}
+
+func (queryResult *QueryResult) WriteJSONWithOptions(w io.Writer, opts JSONOptions) error {
+	var buf bytes.Buffer
+
+	buf.WriteByte('[')
+	for rowIdx, row := range queryResult.Rows {
+		if rowIdx > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := queryResult.writeJSONObject(&buf, row, opts); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+
+	_, err := buf.WriteTo(w)
+	return err
+}
 
-	// Add column header
-	resString.WriteString(
-		strings.Join(queryResult.Columns, ","),
-	)
+// Write the results as newline-delimited JSON, one object per row keyed by column name, i.e. to pipe into jq
+// Values are strings or null, same as WriteJSON. See NDJSONRowWriter to write rows as they're read instead
+func (queryResult *QueryResult) WriteNDJSON(w io.Writer) error {
+	return queryResult.WriteNDJSONWithOptions(w, JSONOptions{})
+}
+
+func (queryResult *QueryResult) WriteNDJSONWithOptions(w io.Writer, opts JSONOptions) error {
+	bufferedWriter := bufio.NewWriter(w)
 
-	// Add each row
+	var buf bytes.Buffer
 	for _, row := range queryResult.Rows {
-		resString.WriteRune('\n')
-		rowValues := make([]string, len(queryResult.Columns))
+		buf.Reset()
+		if err := queryResult.writeJSONObject(&buf, row, opts); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+
+		if _, err := buf.WriteTo(bufferedWriter); err != nil {
+			return err
+		}
+	}
 
+	return bufferedWriter.Flush()
+}
+
+// Written by hand so keys follow column order
+func (queryResult *QueryResult) writeJSONObject(buf *bytes.Buffer, row map[string]*NullString, opts JSONOptions) error {
+	buf.WriteByte('{')
+	for columnIdx, columnName := range queryResult.Columns {
+		if columnIdx > 0 {
+			buf.WriteByte(',')
+		}
+
+		encodedKey, err := json.Marshal(columnName)
+		if err != nil {
+			return err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+
+		encodedValue, err := json.Marshal(queryResult.jsonValue(row[columnName], columnIdx, opts))
+		if err != nil {
+			return err
+		}
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// Callback for DBClient.QueryStream writing each row to w as a line of JSON, so huge results aren't buffered, ex:
+//
+//	dbClient.QueryStream("SELECT * FROM events", db.NDJSONRowWriter(os.Stdout))
+//
+// Keys are sorted rather than in column order, values are strings or null
+func NDJSONRowWriter(w io.Writer) func(row map[string]*NullString) error {
+	encoder := json.NewEncoder(w)
+
+	return func(row map[string]*NullString) error {
+		return encoder.Encode(row)
+	}
+}
+
+func (queryResult *QueryResult) jsonValue(cellValue *NullString, columnIdx int, opts JSONOptions) any {
+	if cellValue == nil || cellValue.IsNull() {
+		return nil
+	}
+	if !opts.CoerceTypes {
+		return cellValue.String
+	}
+
+	switch queryResult.columnKind(columnIdx) {
+	case columnKindInteger, columnKindDecimal:
+		{
+			// Keep the original text, so large or precise values aren't rounded through a float
+			// Values like NaN aren't valid JSON numbers, so those stay as strings
+			if isJSONNumber(cellValue.RawValue()) {
+				return json.Number(cellValue.RawValue())
+			}
+		}
+	case columnKindBool:
+		{
+			if value, err := strconv.ParseBool(cellValue.RawValue()); err == nil {
+				return value
+			}
+		}
+	}
+
+	return cellValue.String
+}
+
+func isJSONNumber(value string) bool {
+	if value == "" || (value[0] != '-' && (value[0] < '0' || value[0] > '9')) {
+		return false
+	}
+
+	return json.Valid([]byte(value))
+}
+
+// CSV of the results with a header row, for display so NULL values are written using NullDisplay
+func (queryResult *QueryResult) ToCSV() (res []byte) {
+	var buf bytes.Buffer
+
+	err := queryResult.WriteCSVWithOptions(&buf, CSVOptions{KeepNullLiteral: true})
+	if err != nil {
+		// Writing to an in-memory buffer shouldn't fail
+		panic(errors.Join(
+			errors.New("Failed to write query results as CSV"),
+			err,
+		))
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+type CSVOptions struct {
+	// Write NULL values using NullDisplay ("NULL" by default) instead of an empty field
+	KeepNullLiteral bool
+}
+
+// Write the results as CSV with a header row, NULL values are written as empty fields
+func (queryResult *QueryResult) WriteCSV(w io.Writer) error {
+	return queryResult.WriteCSVWithOptions(w, CSVOptions{})
+}
+
+func (queryResult *QueryResult) WriteCSVWithOptions(w io.Writer, opts CSVOptions) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(queryResult.Columns); err != nil {
+		return err
+	}
+
+	// Follow column order, rather than the row map's (random) order
+	record := make([]string, len(queryResult.Columns))
+	for _, row := range queryResult.Rows {
 		for columnIdx, columnName := range queryResult.Columns {
 			cellValue := row[columnName]
-			rowValues[columnIdx] = cellValue.ToString()
+
+			if cellValue.IsNull() && !opts.KeepNullLiteral {
+				record[columnIdx] = ""
+			} else {
+				record[columnIdx] = queryResult.DisplayValue(cellValue)
+			}
+		}
+
+		if err := csvWriter.Write(record); err != nil {
+			return err
 		}
-		resString.WriteString(strings.Join(rowValues, ","))
 	}
 
-	return []byte(resString.String())
+	csvWriter.Flush()
+	return csvWriter.Error()
 }
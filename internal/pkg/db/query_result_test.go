@@ -0,0 +1,279 @@
+package db_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNullString(value string) *db.NullString {
+	return &db.NullString{NullString: sql.NullString{String: value, Valid: true}}
+}
+
+func newTestNull() *db.NullString {
+	return &db.NullString{}
+}
+
+// Fixture covering characters which need escaping, along with NULL vs the text "NULL"
+func newTestQueryResult() *db.QueryResult {
+	return &db.QueryResult{
+		Columns:     []string{"id", "name", "note"},
+		ColumnTypes: []string{"INTEGER", "TEXT", "TEXT"},
+		Rows: []map[string]*db.NullString{
+			{
+				"id":   newTestNullString("1"),
+				"name": newTestNullString("Smith, John"),
+				"note": newTestNull(),
+			},
+			{
+				"id":   newTestNullString("2"),
+				"name": newTestNullString(`say "hi"`),
+				"note": newTestNullString("NULL"),
+			},
+		},
+	}
+}
+
+func TestQueryResultWriteCSV(t *testing.T) {
+	t.Run("NULL as empty field", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		err := newTestQueryResult().WriteCSV(&out)
+		assert.NoError(err)
+
+		assert.Equal(
+			"id,name,note\n"+
+				"1,\"Smith, John\",\n"+
+				"2,\"say \"\"hi\"\"\",NULL\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Keep NULL literal", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		err := newTestQueryResult().WriteCSVWithOptions(&out, db.CSVOptions{KeepNullLiteral: true})
+		assert.NoError(err)
+
+		assert.Equal(
+			"id,name,note\n"+
+				"1,\"Smith, John\",NULL\n"+
+				"2,\"say \"\"hi\"\"\",NULL\n",
+			out.String(),
+		)
+	})
+
+	t.Run("ToCSV", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(
+			"id,name,note\n"+
+				"1,\"Smith, John\",NULL\n"+
+				"2,\"say \"\"hi\"\"\",NULL",
+			string(newTestQueryResult().ToCSV()),
+		)
+	})
+
+	t.Run("Custom NULL display", func(t *testing.T) {
+		assert := assert.New(t)
+
+		result := newTestQueryResult()
+		result.SetNullDisplay(`\N`)
+
+		var out strings.Builder
+		err := result.WriteCSVWithOptions(&out, db.CSVOptions{KeepNullLiteral: true})
+		assert.NoError(err)
+
+		assert.Equal(
+			"id,name,note\n"+
+				"1,\"Smith, John\",\\N\n"+
+				"2,\"say \"\"hi\"\"\",NULL\n",
+			out.String(),
+		)
+
+		// Empty field regardless when the literal isn't kept
+		out.Reset()
+		assert.NoError(result.WriteCSV(&out))
+		assert.Equal(
+			"id,name,note\n"+
+				"1,\"Smith, John\",\n"+
+				"2,\"say \"\"hi\"\"\",NULL\n",
+			out.String(),
+		)
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		assert := assert.New(t)
+
+		result := &db.QueryResult{Columns: []string{"a", "b"}, Rows: []map[string]*db.NullString{}}
+
+		var out strings.Builder
+		assert.NoError(result.WriteCSV(&out))
+		assert.Equal("a,b\n", out.String())
+	})
+}
+
+func TestQueryResultWriteJSON(t *testing.T) {
+	typedResult := &db.QueryResult{
+		Columns:     []string{"id", "price", "active", "label", "ratio"},
+		ColumnTypes: []string{"UNSIGNED BIGINT", "NUMERIC", "BOOL", "VARCHAR", "FLOAT8"},
+		Rows: []map[string]*db.NullString{
+			{
+				"id":     newTestNullString("18446744073709551615"),
+				"price":  newTestNullString("123456789.123456789"),
+				"active": newTestNullString("true"),
+				"label":  newTestNullString("42"),
+				"ratio":  newTestNull(),
+			},
+			{
+				"id":     newTestNullString("2"),
+				"price":  newTestNullString("NaN"),
+				"active": newTestNullString("f"),
+				"label":  newTestNullString("NULL"),
+				"ratio":  newTestNullString("-1.5e-3"),
+			},
+		},
+	}
+
+	t.Run("Strings by default", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		assert.NoError(typedResult.WriteJSON(&out))
+
+		assert.Equal(
+			`[{"id":"18446744073709551615","price":"123456789.123456789","active":"true","label":"42","ratio":null},`+
+				`{"id":"2","price":"NaN","active":"f","label":"NULL","ratio":"-1.5e-3"}]`,
+			out.String(),
+		)
+	})
+
+	t.Run("Coerce types", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		assert.NoError(typedResult.WriteJSONWithOptions(&out, db.JSONOptions{CoerceTypes: true}))
+
+		assert.Equal(
+			`[{"id":18446744073709551615,"price":123456789.123456789,"active":true,"label":"42","ratio":null},`+
+				`{"id":2,"price":"NaN","active":false,"label":"NULL","ratio":-1.5e-3}]`,
+			out.String(),
+		)
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		assert.NoError((&db.QueryResult{Columns: []string{"a"}}).WriteJSON(&out))
+		assert.Equal("[]", out.String())
+	})
+}
+
+func TestQueryResultWriteNDJSON(t *testing.T) {
+	result := &db.QueryResult{
+		Columns:     []string{"id", "note"},
+		ColumnTypes: []string{"INT", "TEXT"},
+		Rows: []map[string]*db.NullString{
+			{"id": newTestNullString("1"), "note": newTestNullString("line\nbreak")},
+			{"id": newTestNullString("2"), "note": newTestNull()},
+		},
+	}
+
+	t.Run("One object per line", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		assert.NoError(result.WriteNDJSON(&out))
+		assert.Equal(
+			`{"id":"1","note":"line\nbreak"}`+"\n"+
+				`{"id":"2","note":null}`+"\n",
+			out.String(),
+		)
+
+		out.Reset()
+		assert.NoError(result.WriteNDJSONWithOptions(&out, db.JSONOptions{CoerceTypes: true}))
+		assert.Equal(
+			`{"id":1,"note":"line\nbreak"}`+"\n"+
+				`{"id":2,"note":null}`+"\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Row writer", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		writeRow := db.NDJSONRowWriter(&out)
+		for _, row := range result.Rows {
+			assert.NoError(writeRow(row))
+		}
+
+		assert.Equal(
+			`{"id":"1","note":"line\nbreak"}`+"\n"+
+				`{"id":"2","note":null}`+"\n",
+			out.String(),
+		)
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		assert.NoError((&db.QueryResult{Columns: []string{"a"}}).WriteNDJSON(&out))
+		assert.Empty(out.String())
+	})
+}
+
+func TestQueryResultHash(t *testing.T) {
+	assert := assert.New(t)
+
+	hash := newTestQueryResult().Hash()
+	assert.Len(hash, 64)
+	assert.Equal(hash, newTestQueryResult().Hash(), "Same data hashes the same")
+
+	// Not affected by anything besides columns and rows
+	timed := newTestQueryResult()
+	timed.Elapsed = time.Second
+	timed.SetNullDisplay("∅")
+	assert.Equal(hash, timed.Hash())
+
+	changed := newTestQueryResult()
+	changed.Rows[1]["name"] = newTestNullString("say hi")
+	assert.NotEqual(hash, changed.Hash())
+
+	reordered := newTestQueryResult()
+	reordered.Rows[0], reordered.Rows[1] = reordered.Rows[1], reordered.Rows[0]
+	assert.NotEqual(hash, reordered.Hash())
+
+	renamed := newTestQueryResult()
+	renamed.Columns[2] = "notes"
+	for _, row := range renamed.Rows {
+		row["notes"] = row["note"]
+	}
+	assert.NotEqual(hash, renamed.Hash())
+
+	nullAsText := func(value *db.NullString) string {
+		return (&db.QueryResult{
+			Columns: []string{"a"},
+			Rows:    []map[string]*db.NullString{{"a": value}},
+		}).Hash()
+	}
+	assert.NotEqual(nullAsText(newTestNull()), nullAsText(newTestNullString("NULL")))
+	assert.NotEqual(nullAsText(newTestNull()), nullAsText(newTestNullString("")))
+	assert.Equal(nullAsText(newTestNull()), nullAsText(nil), "Missing values are NULL")
+
+	shifted := func(a string, b string) string {
+		return (&db.QueryResult{
+			Columns: []string{"a", "b"},
+			Rows:    []map[string]*db.NullString{{"a": newTestNullString(a), "b": newTestNullString(b)}},
+		}).Hash()
+	}
+	assert.NotEqual(shifted("ab", "c"), shifted("a", "bc"))
+}
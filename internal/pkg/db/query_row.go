@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// Returned from a QueryStream callback to stop reading, once we have what we need
+var errStopIteration = errors.New("stop iteration")
+
+// Run a query and get just the first row, the rest aren't read
+// Returns nil if the query matched no rows
+func (db *DBClient) QueryRow(statement string, args ...any) (row map[string]*NullString, err error) {
+	return db.QueryRowContext(db.ctx, statement, args...)
+}
+
+// Same as QueryRow, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryRowContext(ctx context.Context, statement string, args ...any) (row map[string]*NullString, err error) {
+	row, _, err = db.queryFirstRow(ctx, db.scanOptions(), statement, args)
+	return row, err
+}
+
+// Run a query and get the first column of the first row, i.e. for SELECT COUNT(*)
+// Returns nil if the query matched no rows, use IsNull to tell a NULL value apart
+func (db *DBClient) QueryScalar(statement string, args ...any) (value *NullString, err error) {
+	return db.QueryScalarContext(db.ctx, statement, args...)
+}
+
+// Same as QueryScalar, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryScalarContext(ctx context.Context, statement string, args ...any) (value *NullString, err error) {
+	row, columns, err := db.queryFirstRow(ctx, db.scanOptions(), statement, args)
+	if err != nil || row == nil {
+		return nil, err
+	}
+
+	return row[columns[0]], nil
+}
+
+func (db *DBClient) queryFirstRow(
+	ctx context.Context,
+	opts scanOptions,
+	statement string,
+	args []any,
+) (row map[string]*NullString, columns []string, err error) {
+	columns, err = db.queryStream(ctx, opts, statement, func(firstRow map[string]*NullString) error {
+		row = firstRow
+		return errStopIteration
+	}, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, nil, errors.New("Query returned no columns")
+	}
+
+	return row, columns, nil
+}
@@ -0,0 +1,22 @@
+package db
+
+import "strings"
+
+// Quote name so it's always read as a single identifier (i.e. a table or column), whatever characters it contains
+// Backticks for MySQL, brackets for SQL Server, double quotes otherwise, embedded quote characters are doubled
+// For a qualified name (ex: schema.table), quote each part separately
+func (flavor DBFlavor) QuoteIdentifier(name string) string {
+	switch flavor {
+	case MySQL:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case SQLServer:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// Quote name as an identifier for the connected database, see DBFlavor.QuoteIdentifier
+func (db *DBClient) QuoteIdentifier(name string) string {
+	return db.connManager.GetFlavor().QuoteIdentifier(name)
+}
@@ -0,0 +1,23 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("`order`", db.MySQL.QuoteIdentifier("order"))
+	assert.Equal("`we``ird`", db.MySQL.QuoteIdentifier("we`ird"))
+
+	assert.Equal(`"User Table"`, db.PostgreSQL.QuoteIdentifier("User Table"))
+	assert.Equal(`"say ""hi"""`, db.PostgreSQL.QuoteIdentifier(`say "hi"`))
+
+	assert.Equal(`"a.b"`, db.SQLite.QuoteIdentifier("a.b"))
+
+	assert.Equal("[select]", db.SQLServer.QuoteIdentifier("select"))
+	assert.Equal("[we]]ird]", db.SQLServer.QuoteIdentifier("we]ird"))
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Statements which are allowed to run in read-only mode, by leading keyword
+var readOnlyKeywords = []string{"SELECT", "SHOW", "EXPLAIN", "DESCRIBE"}
+
+func isReadOnlyStatement(statement string, flavor DBFlavor) bool {
+	_, refused := readOnlyRefusedKeyword(statement, flavor)
+	return !refused
+}
+
+// Keyword of the first statement read-only mode refuses, every ; separated statement is checked
+// so a write can't ride along after a read, i.e. the DROP in SELECT 1; DROP TABLE users
+func readOnlyRefusedKeyword(statement string, flavor DBFlavor) (keyword string, refused bool) {
+	statements := SplitStatements(statement, flavor)
+	if len(statements) == 0 {
+		return statementLeadingKeyword(statement), true
+	}
+
+	for _, splitStatement := range statements {
+		if keyword, readOnly := classifyReadOnly(splitStatement, flavor); !readOnly {
+			return keyword, true
+		}
+	}
+
+	return "", false
+}
+
+// Keyword naming a single statement, and whether it only reads
+func classifyReadOnly(statement string, flavor DBFlavor) (keyword string, readOnly bool) {
+	keyword, rest := splitLeadingKeyword(statement)
+
+	switch keyword {
+	case "WITH":
+		// Name what WITH is attached to, i.e. the DELETE in WITH stale AS (...) DELETE ...
+		attachedKeyword := statementKeywordAfterCTEs(statement)
+		return cmp.Or(attachedKeyword, keyword), attachedKeyword == "SELECT"
+	case "EXPLAIN", "DESCRIBE":
+		// Runs the statement being explained, only PostgreSQL's read-only session stops EXPLAIN ANALYZE DELETE ...
+		if flavor != PostgreSQL && statementLeadingKeyword(rest) == "ANALYZE" {
+			return fmt.Sprint(keyword, " ANALYZE"), false
+		}
+	}
+
+	return keyword, slices.Contains(readOnlyKeywords, keyword)
+}
+
+// When enabled, any statement that isn't a SELECT/SHOW/EXPLAIN/DESCRIBE is rejected before being sent
+// Several statements sent at once are only allowed if every one of them is
+// For PostgreSQL the session is also set to read-only, which additionally catches writes hidden in
+// otherwise allowed statements, i.e. EXPLAIN ANALYZE DELETE ..., elsewhere EXPLAIN ANALYZE is refused
+func (db *DBClient) SetReadOnly(readOnly bool) error {
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	db.updateSession(func() {
+		db.readOnly = readOnly
+	})
+
+	if db.connManager.GetFlavor() != PostgreSQL {
+		return nil
+	}
+
+	// Pooled connections pick this up as they're opened, so start over with new ones
+	if db.pooled() {
+		db.closeIdleConns()
+		return nil
+	}
+
+	// New connections pick this up in getConnection, only need to update an existing one
+	if db._conn == nil {
+		return nil
+	}
+	if err := db.assertNoOpenQuery(); err != nil {
+		return err
+	}
+
+	return db.applyReadOnlySession(db.ctx, db._conn)
+}
+
+func (db *DBClient) IsReadOnly() bool {
+	return db.readOnly
+}
+
+// Checked before any statement is sent, read-only mode first then safe mode confirmation
+func (db *DBClient) assertStatementAllowed(statement string) error {
+	if err := db.assertNotReadOnly(statement); err != nil {
+		return err
+	}
+
+	return db.confirmStatement(statement)
+}
+
+func (db *DBClient) assertNotReadOnly(statement string) error {
+	if !db.readOnly {
+		return nil
+	}
+
+	keyword, refused := readOnlyRefusedKeyword(statement, db.connManager.GetFlavor())
+	if !refused {
+		return nil
+	}
+
+	return fmt.Errorf("%w: refusing to run %s", ErrReadOnly, keyword)
+}
+
+func (db *DBClient) applyReadOnlySession(ctx context.Context, conn *sqlx.Conn) error {
+	value := "off"
+	if db.readOnly {
+		value = "on"
+	}
+
+	_, err := conn.ExecContext(ctx, fmt.Sprint("SET default_transaction_read_only = ", value))
+	return err
+}
@@ -0,0 +1,54 @@
+package db
+
+import "strings"
+
+// Rewrite ? placeholders to the flavor's bind parameter syntax, ex: $1, $2 for PostgreSQL and @p1, @p2 for SQL Server
+// A ? within a string literal, quoted identifier or comment is left alone. MySQL and SQLite queries are returned as is
+func (flavor DBFlavor) Rebind(query string) string {
+	if placeholder(flavor, 1) == "?" {
+		return query
+	}
+
+	var rebound strings.Builder
+	argCount := 0
+
+	for idx := 0; idx < len(query); idx++ {
+		char := query[idx]
+		remaining := query[idx:]
+
+		var skipTo int
+		switch {
+		case char == '\'' || char == '"':
+			skipTo = quotedEnd(query, idx, false)
+		case char == '[' && flavor == SQLServer:
+			skipTo = len(query)
+			if end := strings.IndexByte(remaining, ']'); end != -1 {
+				skipTo = idx + end + 1
+			}
+		case strings.HasPrefix(remaining, "--"):
+			skipTo = lineEnd(query, idx)
+		case strings.HasPrefix(remaining, "/*"):
+			skipTo = blockCommentEnd(query, idx)
+		case char == '$' && flavor == PostgreSQL:
+			skipTo = dollarQuotedEnd(query, idx)
+		case char == '?':
+			argCount++
+			rebound.WriteString(placeholder(flavor, argCount))
+			continue
+		default:
+			rebound.WriteByte(char)
+			continue
+		}
+
+		rebound.WriteString(query[idx:skipTo])
+		idx = skipTo - 1
+	}
+
+	return rebound.String()
+}
+
+// Rewrite ? placeholders for the connected database, see DBFlavor.Rebind
+// ex: dbClient.Query(dbClient.Rebind("SELECT * FROM users WHERE id = ?"), id)
+func (db *DBClient) Rebind(query string) string {
+	return db.connManager.GetFlavor().Rebind(query)
+}
@@ -0,0 +1,25 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	assert := assert.New(t)
+
+	query := "SELECT * FROM users WHERE id = ? AND name = ?"
+	assert.Equal(query, db.MySQL.Rebind(query))
+	assert.Equal(query, db.SQLite.Rebind(query))
+	assert.Equal("SELECT * FROM users WHERE id = $1 AND name = $2", db.PostgreSQL.Rebind(query))
+	assert.Equal("SELECT * FROM users WHERE id = @p1 AND name = @p2", db.SQLServer.Rebind(query))
+
+	// Only placeholders are rewritten, not a ? that's part of a literal, identifier or comment
+	assert.Equal(
+		`SELECT 'what?', 'it''s ?', "col?" FROM t -- why?`+"\n"+`WHERE a = $1 /* ? */ AND b = $$?$$ AND c = $2`,
+		db.PostgreSQL.Rebind(`SELECT 'what?', 'it''s ?', "col?" FROM t -- why?`+"\n"+`WHERE a = ? /* ? */ AND b = $$?$$ AND c = ?`),
+	)
+	assert.Equal("SELECT [col?] FROM t WHERE a = @p1", db.SQLServer.Rebind("SELECT [col?] FROM t WHERE a = ?"))
+}
@@ -0,0 +1,91 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var errServerDown = errors.New("server down for test")
+
+// Hands out connections that fail their ping once down is set, and refuses new ones meanwhile
+type flakyConnector struct {
+	down atomic.Bool
+}
+
+func (connector *flakyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if connector.down.Load() {
+		return nil, errServerDown
+	}
+
+	return &flakyConn{connector}, nil
+}
+
+func (connector *flakyConnector) Driver() driver.Driver {
+	return nil
+}
+
+type flakyConn struct {
+	connector *flakyConnector
+}
+
+func (conn *flakyConn) Ping(ctx context.Context) error {
+	if conn.connector.down.Load() {
+		return errServerDown
+	}
+
+	return nil
+}
+
+func (conn *flakyConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported by test connection")
+}
+
+func (conn *flakyConn) Close() error {
+	return nil
+}
+
+func (conn *flakyConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported by test connection")
+}
+
+func TestDBReconnectFails(t *testing.T) {
+	assert := assert.New(t)
+
+	connector := &flakyConnector{}
+	sqlDB := sql.OpenDB(connector)
+	defer sqlDB.Close()
+
+	dbClient := db.NewDBClientFromDB(sqlDB, db.SQLite)
+	defer dbClient.Destroy()
+
+	reconnectReasons := []error{}
+	dbClient.SetOnReconnect(func(err error) {
+		reconnectReasons = append(reconnectReasons, err)
+	})
+
+	assert.NoError(dbClient.Healthy(context.Background()))
+
+	connector.down.Store(true)
+	err := dbClient.Healthy(context.Background())
+	assert.ErrorIs(err, db.ErrConnectionFailed)
+	assert.ErrorIs(err, db.ErrConnectionLost)
+	assert.ErrorIs(err, errServerDown)
+
+	// The dead connection was let go, so trying again only reconnects rather than reporting it lost a second time
+	err = dbClient.Healthy(context.Background())
+	assert.ErrorIs(err, errServerDown)
+	assert.NotErrorIs(err, db.ErrConnectionLost)
+	if assert.Len(reconnectReasons, 1) {
+		assert.ErrorIs(reconnectReasons[0], errServerDown)
+	}
+
+	connector.down.Store(false)
+	assert.NoError(dbClient.Healthy(context.Background()))
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// A Ctrl-C within this long of the previous one exits RunInteractive, rather than cancelling
+const interactiveExitWindow = 2 * time.Second
+
+// What RunInteractive goes by, tests swap these out to avoid depending on timing (see export_test.go)
+type interactiveConfig struct {
+	exitWindow time.Duration
+	now        func() time.Time
+	// Called once a prompt is written and RunInteractive waits on input or Ctrl-C, nil for none
+	promptReady func()
+	// Called as each statement starts, once Ctrl-C would cancel it, nil for none
+	statementStarted func()
+}
+
+// Read ; terminated statements from in and write each result (or error) to out, until in is exhausted
+// Ctrl-C (SIGINT) cancels the running query, or discards a partially typed statement, instead of killing the process
+// A second Ctrl-C within 2s returns ErrInterrupted, ex:
+//
+//	err := dbClient.RunInteractive(os.Stdin, os.Stdout)
+//	if errors.Is(err, db.ErrInterrupted) {
+//		os.Exit(130)
+//	}
+//
+// After returning on Ctrl-C, in is still read from in the background, since an io.Reader can't be interrupted
+func (db *DBClient) RunInteractive(in io.Reader, out io.Writer) error {
+	return db.runInteractive(in, out, interactiveConfig{
+		exitWindow: interactiveExitWindow,
+		now:        time.Now,
+	})
+}
+
+func (db *DBClient) runInteractive(in io.Reader, out io.Writer, config interactiveConfig) error {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	session := &interactiveSession{db: db, out: out, interrupts: interrupts, config: config}
+	flavor := db.connManager.GetFlavor()
+
+	var pending strings.Builder
+	for {
+		if pending.Len() == 0 {
+			fmt.Fprint(out, "> ")
+		} else {
+			fmt.Fprint(out, "-> ")
+		}
+		if config.promptReady != nil {
+			config.promptReady()
+		}
+
+		select {
+		case <-interrupts:
+			fmt.Fprintln(out)
+			if session.interrupted() {
+				return ErrInterrupted
+			}
+			pending.Reset()
+		case line, ok := <-lines:
+			if !ok {
+				// Whatever is left didn't end with ;, run it anyway so piped scripts don't need the last one
+				fmt.Fprintln(out)
+				if err := session.run(pending.String()); err != nil {
+					return err
+				}
+				return <-readErr
+			}
+
+			pending.WriteString(line)
+			pending.WriteByte('\n')
+			if !statementComplete(pending.String(), flavor) {
+				continue
+			}
+
+			script := pending.String()
+			pending.Reset()
+			if err := session.run(script); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Whether input ends with a ; outside of any quotes, comments or parentheses, so it's ready to run
+func statementComplete(input string, flavor DBFlavor) bool {
+	return strings.HasSuffix(strings.TrimSpace(topLevelSQL(input, flavor)), ";")
+}
+
+type interactiveSession struct {
+	db         *DBClient
+	out        io.Writer
+	interrupts <-chan os.Signal
+	config     interactiveConfig
+	// When Ctrl-C was last pressed, for telling if the next one should exit
+	lastInterrupt time.Time
+}
+
+// Record a Ctrl-C, returning whether it came soon enough after the last one to exit
+func (session *interactiveSession) interrupted() bool {
+	now := session.config.now()
+	exit := now.Sub(session.lastInterrupt) < session.config.exitWindow
+	session.lastInterrupt = now
+
+	return exit
+}
+
+type interactiveOutcome struct {
+	result *QueryResult
+	err    error
+}
+
+// Run each statement in script, writing results as they complete
+// Statements run in the background so Ctrl-C can cancel them, which also skips the rest of the script
+func (session *interactiveSession) run(script string) error {
+	statements := SplitStatements(script, session.db.connManager.GetFlavor())
+	if len(statements) == 0 {
+		return nil
+	}
+
+	// Cancelling the context rather than through Cancel also stops a statement which hadn't quite started yet
+	ctx, cancel := context.WithCancelCause(session.db.ctx)
+	defer cancel(nil)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	outcomes := make(chan interactiveOutcome)
+	go func() {
+		defer close(outcomes)
+
+		for _, statement := range statements {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if session.config.statementStarted != nil {
+				session.config.statementStarted()
+			}
+			result, err := session.db.RunContext(ctx, statement)
+			select {
+			case outcomes <- interactiveOutcome{result, err}:
+			case <-stop:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case outcome, ok := <-outcomes:
+			if !ok {
+				return nil
+			}
+			session.writeOutcome(outcome)
+		case <-session.interrupts:
+			exit := session.interrupted()
+
+			cancel(ErrCancelled)
+			if exit {
+				fmt.Fprintln(session.out)
+				return ErrInterrupted
+			}
+		}
+	}
+}
+
+func (session *interactiveSession) writeOutcome(outcome interactiveOutcome) {
+	out := session.out
+	if outcome.err != nil {
+		fmt.Fprintf(out, "Error: %s\n", strings.ReplaceAll(outcome.err.Error(), "\n", ": "))
+		return
+	}
+
+	result := outcome.result
+	elapsed := result.Elapsed.Round(time.Millisecond)
+	switch {
+	case !result.NoResultSet:
+		if err := result.RenderTable(out, TableOptions{}); err != nil {
+			fmt.Fprintf(out, "Error: %s\n", err)
+			return
+		}
+		fmt.Fprintf(out, "Took %s\n", elapsed)
+	case result.StmtType == Insert || result.StmtType == Update || result.StmtType == Delete:
+		fmt.Fprintf(out, "OK, %d row(s) affected (%s)\n", result.RowsAffected, elapsed)
+	default:
+		fmt.Fprintf(out, "OK (%s)\n", elapsed)
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(out, "Warning: %s\n", warning)
+	}
+}
@@ -0,0 +1,156 @@
+package db_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunInteractive writes from its own goroutine while the test reads
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (buffer *syncBuffer) Write(p []byte) (int, error) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	return buffer.buf.Write(p)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	return buffer.buf.String()
+}
+
+func TestDBSQLiteRunInteractive(t *testing.T) {
+	t.Run("Runs statements", func(t *testing.T) {
+		assert := assert.New(t)
+
+		connOptions := newSQLiteConnOptions(t)
+		dbClient, err := db.CreateDBClient(&connOptions)
+		assert.NoError(err)
+		defer dbClient.Destroy()
+
+		in := strings.NewReader(strings.Join([]string{
+			"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);",
+			"INSERT INTO users (name) VALUES ('alice'), ('b;ob');",
+			"SELECT name",
+			"FROM missing;",
+			"SELECT name",
+			"FROM users ORDER BY id;",
+			"SELECT 1 AS one",
+		}, "\n"))
+		var out strings.Builder
+
+		assert.NoError(dbClient.RunInteractive(in, &out))
+
+		output := out.String()
+		assert.Contains(output, "OK (")
+		assert.Contains(output, "OK, 2 row(s) affected")
+		assert.Contains(output, "Error: Query Failed: SQL logic error: no such table: missing")
+		assert.Contains(output, "| b;ob  |")
+		assert.Contains(output, "(2 rows)")
+		assert.Contains(output, "-> ", "Continues multi-line statements")
+		assert.Contains(output, "| one |", "Runs the last statement without a ;")
+	})
+
+	t.Run("Ctrl-C", func(t *testing.T) {
+		assert := assert.New(t)
+
+		connOptions := newSQLiteConnOptions(t)
+		dbClient, err := db.CreateDBClient(&connOptions)
+		assert.NoError(err)
+		defer dbClient.Destroy()
+
+		// Should a Ctrl-C be missed, the query still ends rather than hanging the test
+		dbClient.SetQueryTimeout(10 * time.Second)
+
+		// Only moves when the test says so, so how long the test takes doesn't affect what counts as a second Ctrl-C
+		var clockMu sync.Mutex
+		clock := time.Unix(0, 0)
+		advance := func(by time.Duration) {
+			clockMu.Lock()
+			defer clockMu.Unlock()
+			clock = clock.Add(by)
+		}
+
+		prompts := make(chan struct{}, 16)
+		started := make(chan struct{}, 16)
+		config := db.InteractiveTestConfig{
+			ExitWindow: time.Second,
+			Now: func() time.Time {
+				clockMu.Lock()
+				defer clockMu.Unlock()
+				return clock
+			},
+			PromptReady:      func() { prompts <- struct{}{} },
+			StatementStarted: func() { started <- struct{}{} },
+		}
+
+		in, inWriter := io.Pipe()
+		defer inWriter.Close()
+		var out syncBuffer
+		// Blocks until RunInteractive reads the line, which it won't while stuck on a query
+		writeLine := func(line string) {
+			go func() { _, _ = io.WriteString(inWriter, line+"\n") }()
+		}
+
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- dbClient.RunInteractiveWithTestConfig(in, &out, config)
+		}()
+
+		waitFor := func(signal <-chan struct{}, what string) {
+			select {
+			case <-signal:
+			case err := <-runErr:
+				assert.FailNow(fmt.Sprintf("Exited waiting for the %s", what), "err: %v, output: %q", err, out.String())
+			case <-time.After(5 * time.Second):
+				assert.FailNow(fmt.Sprintf("Timed out waiting for the %s", what), "output: %q", out.String())
+			}
+		}
+		interrupt := func() {
+			assert.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGINT))
+		}
+
+		waitFor(prompts, "first prompt")
+		writeLine("WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter) SELECT count(*) FROM counter;")
+
+		waitFor(started, "query to start")
+		interrupt()
+		waitFor(prompts, "prompt after cancelling")
+		assert.Contains(out.String(), "Error: Query cancelled")
+
+		// Still usable after cancelling
+		writeLine("SELECT 42 AS answer;")
+		waitFor(started, "second query to start")
+		waitFor(prompts, "prompt after the second query")
+		assert.Contains(out.String(), "| 42     |")
+
+		// Too long after the last one to exit, this only clears the prompt
+		advance(2 * time.Second)
+		interrupt()
+		waitFor(prompts, "prompt after a late Ctrl-C")
+
+		// Soon after the last one, so this exits
+		advance(500 * time.Millisecond)
+		interrupt()
+		select {
+		case err := <-runErr:
+			assert.ErrorIs(err, db.ErrInterrupted)
+		case <-time.After(5 * time.Second):
+			assert.Fail("Second Ctrl-C didn't exit")
+		}
+	})
+}
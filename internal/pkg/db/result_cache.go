@@ -0,0 +1,192 @@
+package db
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Keep Query results for ttl, so running the same SELECT again (with the same args) skips the database
+// Only plain SELECTs are cached, never locking reads (FOR UPDATE) or SELECT ... INTO
+// Any other statement run through Query, Exec or a prepared statement, ending a transaction, and changing session
+// settings (i.e. SetSearchPath) clears the cache
+// Nothing is cached or served from the cache while a transaction is in progress, it may see rows that aren't committed
+// Changes made some other way (i.e. by another client) only show up once entries expire, or after InvalidateCache
+// 0 disables caching, which is the default
+func (db *DBClient) SetResultCacheTTL(ttl time.Duration) {
+	db.resultCache.setTTL(ttl)
+}
+
+// Drop every cached result, the next Query of each goes to the database
+func (db *DBClient) InvalidateCache() {
+	db.resultCache.clear()
+}
+
+type resultCacheKey struct {
+	statement string
+	args      string
+	opts      scanOptions
+}
+
+type resultCacheEntry struct {
+	result    *QueryResult
+	expiresAt time.Time
+}
+
+// See SetResultCacheTTL, entries are copies so callers can't change them through a result they were handed
+type resultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[resultCacheKey]resultCacheEntry
+	// Set between BeginTx and the transaction ending, see beginTx
+	inTx bool
+}
+
+func newResultCacheKey(statement string, args []any, opts scanOptions) resultCacheKey {
+	return resultCacheKey{statement, resultCacheArgs(args), opts}
+}
+
+// Args by value, so pointers to the same value share a key rather than each printing its address
+func resultCacheArgs(args []any) string {
+	values := make([]any, len(args))
+	for argIdx, arg := range args {
+		value := reflect.ValueOf(arg)
+		for value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		if value.IsValid() {
+			values[argIdx] = value.Interface()
+		}
+	}
+
+	return fmt.Sprintf("%#v", values)
+}
+
+// In single mode the transaction shares the connection with Query, so reads would see its uncommitted writes
+// Entries from before the transaction are kept out of it too, they don't include its writes
+func (cache *resultCache) beginTx() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.inTx = true
+}
+
+// Whatever the transaction wrote is either visible now or was rolled back, start over either way
+func (cache *resultCache) endTx() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.inTx = false
+	cache.entries = nil
+}
+
+func (cache *resultCache) setTTL(ttl time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.ttl = ttl
+	cache.entries = nil
+}
+
+func (cache *resultCache) enabled() bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.ttl > 0 && !cache.inTx
+}
+
+func (cache *resultCache) get(key resultCacheKey) (*QueryResult, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(cache.entries, key)
+		return nil, false
+	}
+
+	return entry.result.clone(), true
+}
+
+func (cache *resultCache) put(key resultCacheKey, result *QueryResult) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.ttl <= 0 || cache.inTx {
+		return
+	}
+
+	now := time.Now()
+	if cache.entries == nil {
+		cache.entries = map[resultCacheKey]resultCacheEntry{}
+	}
+	// Expired entries are never read again, don't let them pile up
+	maps.DeleteFunc(cache.entries, func(_ resultCacheKey, entry resultCacheEntry) bool {
+		return now.After(entry.expiresAt)
+	})
+
+	cache.entries[key] = resultCacheEntry{result.clone(), now.Add(cache.ttl)}
+}
+
+func (cache *resultCache) clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries = nil
+}
+
+// About to run statement, which may change what cached SELECTs would return
+func (cache *resultCache) invalidateFor(statement string) {
+	if StatementType(statement) != Select {
+		cache.clear()
+	}
+}
+
+var uncacheableSelectPattern = regexp.MustCompile(`(?i)\b(FOR|INTO)\b`)
+
+// Whether statement is a plain SELECT, which reads the same rows every time until something is written
+func cacheableQuery(statement string, flavor DBFlavor) bool {
+	if statementLeadingKeyword(statement) != "SELECT" {
+		return false
+	}
+
+	return !uncacheableSelectPattern.MatchString(topLevelSQL(statement, flavor))
+}
+
+// Deep copy, sharing nothing the caller could modify with queryResult
+func (queryResult *QueryResult) clone() *QueryResult {
+	cloned := *queryResult
+	cloned.Columns = slices.Clone(queryResult.Columns)
+	cloned.ColumnTypes = slices.Clone(queryResult.ColumnTypes)
+	cloned.MaxWidth = slices.Clone(queryResult.MaxWidth)
+	cloned.Warnings = slices.Clone(queryResult.Warnings)
+	if queryResult.nullDisplay != nil {
+		nullDisplay := *queryResult.nullDisplay
+		cloned.nullDisplay = &nullDisplay
+	}
+
+	if queryResult.Rows != nil {
+		cloned.Rows = make([]map[string]*NullString, len(queryResult.Rows))
+		for rowIdx, row := range queryResult.Rows {
+			clonedRow := make(map[string]*NullString, len(row))
+			for column, value := range row {
+				if value != nil {
+					clonedValue := *value
+					value = &clonedValue
+				}
+				clonedRow[column] = value
+			}
+			cloned.Rows[rowIdx] = clonedRow
+		}
+	}
+
+	return &cloned
+}
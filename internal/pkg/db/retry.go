@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MySQL server errors sent right before it drops the connection
+var mysqlConnectionErrors = []uint16{
+	// ER_SERVER_SHUTDOWN
+	1053,
+	// ER_CONNECTION_KILLED
+	1927,
+	// ER_CLIENT_INTERACTION_TIMEOUT
+	4031,
+}
+
+// Whether a failed query should be re-run once on a fresh connection, see DBClientOptions.RetryOnConnLoss
+// Only read statements outside of a transaction, anything else may have already taken effect
+func (db *DBClient) shouldRetryQuery(ctx context.Context, statement string, err error) bool {
+	if !db.retryOnConnLoss || db._tx != nil || ctx.Err() != nil {
+		return false
+	}
+	if !isReadOnlyStatement(statement, db.connManager.GetFlavor()) {
+		return false
+	}
+
+	return isConnectionError(err)
+}
+
+// Whether err means the connection itself broke, rather than the statement failing (i.e. a syntax error)
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return slices.Contains(mysqlConnectionErrors, mysqlErr.Number)
+	}
+
+	// Class 08 is connection exceptions, 57P01-57P03 are the server shutting down or terminating the backend
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || slices.Contains([]string{"57P01", "57P02", "57P03"}, pgErr.Code)
+	}
+
+	return false
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Cursor over the rows of a query, read from the database one at a time as Next is called, see QueryIter
+type RowIter struct {
+	mu   sync.Mutex
+	db   *DBClient
+	ctx  context.Context
+	rows *sqlx.Rows
+	// Rows known up front (i.e. a dry run), used instead of rows when it's nil
+	buffered    []map[string]*NullString
+	scanner     *rowScanner
+	columns     []string
+	nullDisplay string
+	// Row the last call to Next moved to
+	row    map[string]*NullString
+	err    error
+	closed bool
+	// Set by Close before cancelling, so the cancellation isn't reported as an error
+	closeRequested atomic.Bool
+	// Stops a Next waiting on the database
+	cancel context.CancelFunc
+	// Hands the connection back and logs the query, run once the iterator is closed
+	finish func(err error)
+}
+
+// Run a query and iterate over its rows lazily, ex:
+//
+//	iter, err := dbClient.QueryIter("SELECT * FROM users")
+//	if err != nil {
+//		return err
+//	}
+//	defer iter.Close()
+//
+//	for iter.Next() {
+//		row, err := iter.Scan()
+//		...
+//	}
+//	return iter.Err()
+//
+// The connection stays busy until every row was read or Close is called, other queries wait until then
+func (db *DBClient) QueryIter(statement string, args ...any) (*RowIter, error) {
+	return db.QueryIterContext(db.ctx, statement, args...)
+}
+
+// Same as QueryIter, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryIterContext(ctx context.Context, statement string, args ...any) (*RowIter, error) {
+	startedAt := time.Now()
+	ctx, cancelTimeout := db.withQueryTimeout(ctx)
+	ctx, release := db.trackActiveQuery(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+
+	opts := db.scanOptions()
+	iter := &RowIter{
+		db:          db,
+		ctx:         ctx,
+		nullDisplay: DefaultNullDisplay,
+		cancel:      cancel,
+		finish: func(err error) {
+			cancel()
+			release()
+			cancelTimeout()
+			db.logQuery(statement, args, startedAt, &err)
+		},
+	}
+	if opts.nullDisplay != nil {
+		iter.nullDisplay = *opts.nullDisplay
+	}
+
+	fail := func(err error) (*RowIter, error) {
+		err = explainCancelled(ctx, db.explainQueryTimeout(ctx, err))
+		iter.finish(err)
+		return nil, err
+	}
+
+	if db.shouldDryRun(statement) {
+		rowsEstimate, err := db.dryRunStatement(ctx, statement, args)
+		if err != nil {
+			return fail(err)
+		}
+
+		dryRunResult := newDryRunQueryResult(rowsEstimate)
+		iter.columns, iter.buffered = dryRunResult.Columns, dryRunResult.Rows
+		return iter, nil
+	}
+
+	rows, err := db.queryRows(ctx, statement, args)
+	if err != nil {
+		return fail(err)
+	}
+	if rows == nil {
+		iter.columns = []string{}
+		return iter, nil
+	}
+
+	iter.scanner, err = newRowScanner(rows, opts)
+	if err != nil {
+		_ = rows.Close()
+		return fail(err)
+	}
+	iter.rows = rows
+	iter.columns = iter.scanner.columns
+
+	return iter, nil
+}
+
+// Move to the next row, returns false once there are none left, on error (see Err) or after Close
+// Reaching the end closes the iterator
+func (iter *RowIter) Next() bool {
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+
+	iter.row = nil
+	if iter.closed {
+		return false
+	}
+
+	if iter.rows == nil {
+		if len(iter.buffered) == 0 {
+			_ = iter.close()
+			return false
+		}
+		iter.row, iter.buffered = iter.buffered[0], iter.buffered[1:]
+		return true
+	}
+
+	if !iter.rows.Next() {
+		iter.err = rowsEndErr(iter.ctx, iter.rows)
+		_ = iter.close()
+		return false
+	}
+
+	iter.row, iter.err = iter.scanner.scan(iter.rows)
+	if iter.err != nil {
+		_ = iter.close()
+		return false
+	}
+
+	return true
+}
+
+// Displayable values of the current row by column name, NULL values as set by DBClient.SetNullDisplay
+func (iter *RowIter) Scan() (map[string]string, error) {
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+
+	if iter.row == nil {
+		if iter.closed {
+			return nil, errors.New("RowIter is closed")
+		}
+		return nil, errors.New("Next must be called before Scan")
+	}
+
+	row := make(map[string]string, len(iter.row))
+	for column, value := range iter.row {
+		row[column] = value.Display(iter.nullDisplay)
+	}
+
+	return row, nil
+}
+
+// Selected column names, order preserved
+func (iter *RowIter) Columns() []string {
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+
+	return iter.columns
+}
+
+// What stopped Next early, nil if every row was read or Close was called before the end
+func (iter *RowIter) Err() error {
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+
+	return iter.err
+}
+
+// Stop reading rows and free the connection for other queries, safe to call more than once
+// May be called from another goroutine to interrupt a Next waiting on the database
+func (iter *RowIter) Close() error {
+	iter.closeRequested.Store(true)
+	iter.cancel()
+
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+
+	if iter.closed {
+		return nil
+	}
+
+	return iter.close()
+}
+
+func (iter *RowIter) close() (err error) {
+	iter.closed = true
+	iter.row = nil
+
+	if iter.rows != nil {
+		if closeErr := iter.rows.Close(); closeErr != nil {
+			err = errors.Join(
+				errors.New("Failed to cleanup rows"),
+				closeErr,
+			)
+		}
+	}
+
+	if iter.closeRequested.Load() {
+		iter.err = nil
+	} else {
+		iter.err = explainCancelled(iter.ctx, iter.db.explainQueryTimeout(iter.ctx, iter.err))
+	}
+	iter.finish(errors.Join(iter.err, err))
+
+	return err
+}
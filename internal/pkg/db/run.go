@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+var (
+	returningRegExp          = regexp.MustCompile(`(?i)\bRETURNING\b`)
+	sqlServerReturningRegExp = regexp.MustCompile(`(?i)\b(RETURNING|OUTPUT)\b`)
+)
+
+// Run any statement, picking Query or Exec going by StatementType so results always come back the same way
+// Statements without a result set have NoResultSet and RowsAffected set, i.e. UPDATE or CREATE TABLE
+// INSERT/UPDATE/DELETE with RETURNING (or OUTPUT for SQL Server) are queried, to get the returned rows
+func (db *DBClient) Run(statement string, args ...any) (results *QueryResult, err error) {
+	return db.RunContext(db.ctx, statement, args...)
+}
+
+// Same as Run, but the statement can be cancelled or given a deadline through ctx
+func (db *DBClient) RunContext(ctx context.Context, statement string, args ...any) (results *QueryResult, err error) {
+	if db.producesResultSet(statement) {
+		return db.QueryContext(ctx, statement, args...)
+	}
+
+	startedAt := time.Now()
+	execResult, err := db.ExecContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if execResult.DryRun {
+		results = newDryRunQueryResult(execResult.RowsAffected)
+	} else {
+		results = newNoResultSetQueryResult()
+		results.RowsAffected = execResult.RowsAffected
+		results.Warnings = execResult.Warnings
+	}
+	results.setTiming(time.Since(startedAt), 0)
+	results.setStmtType(statement)
+
+	return results, nil
+}
+
+func (db *DBClient) producesResultSet(statement string) bool {
+	switch StatementType(statement) {
+	case Insert, Update, Delete:
+		flavor := db.connManager.GetFlavor()
+
+		clauseRegExp := returningRegExp
+		if flavor == SQLServer {
+			clauseRegExp = sqlServerReturningRegExp
+		}
+		return clauseRegExp.MatchString(topLevelSQL(statement, flavor))
+	case DDL:
+		return false
+	default:
+		// Includes Other, Query copes with statements which turn out not to return rows
+		return true
+	}
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Details about a table's column, i.e. for autocomplete
+type ColumnInfo struct {
+	Name string
+	// Type as written in the table definition (ex: varchar(255), integer)
+	Type     string
+	Nullable bool
+	// PRI, UNI or MUL like MySQL's DESCRIBE, empty if the column doesn't lead an index
+	Key string
+	// Default expression, nil when the column has no default
+	Default *string
+}
+
+// Names of the tables and views in the current database
+// For PostgreSQL, only schemas on the search_path are included
+func (db *DBClient) ListTables() ([]string, error) {
+	return db.ListTablesContext(db.ctx)
+}
+
+// Same as ListTables, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) ListTablesContext(ctx context.Context) ([]string, error) {
+	var listTablesQuery string
+	switch db.connManager.GetFlavor() {
+	case MySQL:
+		listTablesQuery = mysqlListTablesQuery
+	case PostgreSQL:
+		listTablesQuery = postgresListTablesQuery
+	case SQLite:
+		listTablesQuery = sqliteListTablesQuery
+	case SQLServer:
+		listTablesQuery = sqlServerListTablesQuery
+	default:
+		return nil, fmt.Errorf("Listing tables not supported for %s", db.connManager.GetFlavor().DisplayName())
+	}
+
+	return db.listNames(ctx, listTablesQuery, "table_name", "tables")
+}
+
+// Names of the databases on the server, i.e. to switch to with USE
+// For SQLite, these are the attached databases (main, temp and any ATTACHed files)
+func (db *DBClient) ListDatabases() ([]string, error) {
+	return db.ListDatabasesContext(db.ctx)
+}
+
+// Same as ListDatabases, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) ListDatabasesContext(ctx context.Context) ([]string, error) {
+	switch db.connManager.GetFlavor() {
+	case MySQL:
+		return db.listNames(ctx, "SHOW DATABASES", "Database", "databases")
+	case PostgreSQL:
+		return db.listNames(ctx, postgresListDatabasesQuery, "datname", "databases")
+	case SQLite:
+		return db.listNames(ctx, "PRAGMA database_list", "name", "databases")
+	case SQLServer:
+		return db.listNames(ctx, "SELECT name FROM sys.databases ORDER BY name ASC", "name", "databases")
+	default:
+		return nil, fmt.Errorf("Listing databases not supported for %s", db.connManager.GetFlavor().DisplayName())
+	}
+}
+
+// Names of the schemas in the current PostgreSQL database the user can access, i.e. for SET search_path
+// System schemas (pg_catalog, information_schema, etc.) are left out
+func (db *DBClient) ListSchemas() ([]string, error) {
+	return db.ListSchemasContext(db.ctx)
+}
+
+// Same as ListSchemas, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) ListSchemasContext(ctx context.Context) ([]string, error) {
+	if db.connManager.GetFlavor() != PostgreSQL {
+		return nil, fmt.Errorf("Listing schemas not supported for %s", db.connManager.GetFlavor().DisplayName())
+	}
+
+	return db.listNames(ctx, postgresListSchemasQuery, "nspname", "schemas")
+}
+
+// Run query and collect column from every row, what describes the names for errors (ex: tables)
+func (db *DBClient) listNames(ctx context.Context, query string, column string, what string) ([]string, error) {
+	result, err := db.query(ctx, db.lookupScanOptions(), query, nil)
+	if err != nil {
+		return nil, errors.Join(
+			fmt.Errorf("Failed to list %s", what),
+			err,
+		)
+	}
+
+	names := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		names = append(names, row[column].String)
+	}
+
+	return names, nil
+}
+
+// Columns of a table in the current database, in definition order
+// For PostgreSQL the table is resolved using the search_path, or may be qualified (ex: public.users)
+func (db *DBClient) DescribeTable(name string) ([]ColumnInfo, error) {
+	return db.DescribeTableContext(db.ctx, name)
+}
+
+// Same as DescribeTable, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) DescribeTableContext(ctx context.Context, name string) ([]ColumnInfo, error) {
+	var describeTableQuery string
+	tableParam := name
+
+	switch db.connManager.GetFlavor() {
+	case MySQL:
+		describeTableQuery = mysqlDescribeTableQuery
+	case PostgreSQL:
+		{
+			describeTableQuery = postgresDescribeTableQuery
+			tableParam = postgresQualifiedName(name)
+		}
+	case SQLite:
+		describeTableQuery = sqliteDescribeTableQuery
+	case SQLServer:
+		describeTableQuery = sqlServerDescribeTableQuery
+	default:
+		return nil, fmt.Errorf("Describing tables not supported for %s", db.connManager.GetFlavor().DisplayName())
+	}
+
+	result, err := db.query(ctx, db.lookupScanOptions(), describeTableQuery, []any{tableParam})
+	if err != nil {
+		return nil, errors.Join(
+			fmt.Errorf("Failed to describe table %s", name),
+			err,
+		)
+	}
+	if len(result.Rows) == 0 {
+		return nil, fmt.Errorf("Table %s does not exist", name)
+	}
+
+	columns := make([]ColumnInfo, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		// Drivers report booleans differently, i.e. 1 for MySQL and true for PostgreSQL
+		// The raw value, as SetBoolDisplay may have changed how it reads (i.e. yes/no)
+		nullableValue := row["nullable"].RawValue()
+		nullable, err := strconv.ParseBool(nullableValue)
+		if err != nil {
+			return nil, errors.Join(
+				fmt.Errorf("Unexpected nullable value %q for column %s", nullableValue, row["name"].String),
+				err,
+			)
+		}
+
+		column := ColumnInfo{
+			Name:     row["name"].String,
+			Type:     row["type"].String,
+			Nullable: nullable,
+			Key:      row["column_key"].String,
+		}
+		if defaultValue := row["default_value"]; !defaultValue.IsNull() {
+			column.Default = &defaultValue.String
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// Quote each part of a possibly schema qualified name, so to_regclass matches it exactly
+// Without quoting PostgreSQL would fold it to lower case
+func postgresQualifiedName(name string) string {
+	parts := strings.SplitN(name, ".", 2)
+	for i, part := range parts {
+		parts[i] = PostgreSQL.QuoteIdentifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+const mysqlListTablesQuery string = `
+SELECT table_name AS table_name
+FROM information_schema.tables
+WHERE table_schema = DATABASE()
+ORDER BY table_name ASC
+`
+
+const postgresListTablesQuery string = `
+SELECT DISTINCT table_name
+FROM information_schema.tables
+WHERE table_schema = ANY(current_schemas(false))
+ORDER BY table_name ASC
+`
+
+const postgresListDatabasesQuery string = `
+SELECT datname
+FROM pg_database
+WHERE datallowconn AND NOT datistemplate
+ORDER BY datname ASC
+`
+
+const postgresListSchemasQuery string = `
+SELECT nspname
+FROM pg_namespace
+WHERE nspname NOT LIKE 'pg\_%' AND nspname <> 'information_schema' AND has_schema_privilege(nspname, 'USAGE')
+ORDER BY nspname ASC
+`
+
+const sqliteListTablesQuery string = `
+SELECT name AS table_name
+FROM sqlite_master
+WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+ORDER BY name ASC
+`
+
+const sqlServerListTablesQuery string = `
+SELECT TABLE_NAME AS table_name
+FROM INFORMATION_SCHEMA.TABLES
+WHERE TABLE_SCHEMA = SCHEMA_NAME()
+ORDER BY TABLE_NAME ASC
+`
+
+const mysqlDescribeTableQuery string = `
+SELECT
+  column_name AS name,
+  column_type AS type,
+  is_nullable = 'YES' AS nullable,
+  column_key AS column_key,
+  column_default AS default_value
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY ordinal_position ASC
+`
+
+// Using the catalog rather than information_schema, so the table is resolved through the search_path
+// the same way an unqualified name in a query would be
+const postgresDescribeTableQuery string = `
+SELECT
+  a.attname AS name,
+  format_type(a.atttypid, a.atttypmod) AS type,
+  NOT a.attnotnull AS nullable,
+  CASE
+    WHEN EXISTS (
+      SELECT 1 FROM pg_index i WHERE i.indrelid = a.attrelid AND i.indisprimary AND a.attnum = ANY(i.indkey)
+    ) THEN 'PRI'
+    WHEN EXISTS (
+      SELECT 1 FROM pg_index i WHERE i.indrelid = a.attrelid AND i.indisunique AND i.indkey[0] = a.attnum
+    ) THEN 'UNI'
+    WHEN EXISTS (
+      SELECT 1 FROM pg_index i WHERE i.indrelid = a.attrelid AND i.indkey[0] = a.attnum
+    ) THEN 'MUL'
+    ELSE ''
+  END AS column_key,
+  pg_get_expr(d.adbin, d.adrelid) AS default_value
+FROM pg_attribute a
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum ASC
+`
+
+const sqliteDescribeTableQuery string = `
+SELECT
+  name,
+  type,
+  "notnull" = 0 AS nullable,
+  CASE WHEN pk > 0 THEN 'PRI' ELSE '' END AS column_key,
+  dflt_value AS default_value
+FROM pragma_table_info(?)
+ORDER BY cid ASC
+`
+
+const sqlServerDescribeTableQuery string = `
+SELECT
+  COLUMN_NAME AS name,
+  DATA_TYPE AS type,
+  CASE WHEN IS_NULLABLE = 'YES' THEN 1 ELSE 0 END AS nullable,
+  '' AS column_key,
+  COLUMN_DEFAULT AS default_value
+FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = SCHEMA_NAME() AND TABLE_NAME = @p1
+ORDER BY ORDINAL_POSITION ASC
+`
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Run several ; separated statements in order, returning a result for each
+// Statements that don't return rows still get a result, with QueryResult.NoResultSet set
+// Stops at the first failing statement, returning the results gathered so far
+func (db *DBClient) RunScript(script string) (results []*QueryResult, err error) {
+	statements := SplitStatements(script, db.connManager.GetFlavor())
+
+	results = make([]*QueryResult, 0, len(statements))
+	for statementIdx, statement := range statements {
+		result, err := db.Query(statement)
+		if err != nil {
+			return results, errors.Join(
+				fmt.Errorf("Script failed at statement %d of %d", statementIdx+1, len(statements)),
+				err,
+			)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Split a script into individual statements on ;
+// Semicolons within quotes, comments or (for PostgreSQL) dollar quoted strings don't end a statement
+// Statements which are empty or only comments are dropped
+func SplitStatements(script string, flavor DBFlavor) []string {
+	statements := []string{}
+	var current strings.Builder
+
+	addStatement := func() {
+		statement := strings.TrimSpace(current.String())
+		current.Reset()
+
+		if statementLeadingKeyword(statement) != "" {
+			statements = append(statements, statement)
+		}
+	}
+
+	for idx := 0; idx < len(script); idx++ {
+		char := script[idx]
+		remaining := script[idx:]
+
+		var skipTo int
+		switch {
+		case char == ';':
+			{
+				addStatement()
+				continue
+			}
+		case char == '\'' || char == '"' || char == '`':
+			skipTo = quotedEnd(script, idx, flavor == MySQL)
+		case strings.HasPrefix(remaining, "--") || (char == '#' && flavor == MySQL):
+			skipTo = lineEnd(script, idx)
+		case strings.HasPrefix(remaining, "/*"):
+			skipTo = blockCommentEnd(script, idx)
+		case char == '$' && flavor == PostgreSQL:
+			skipTo = dollarQuotedEnd(script, idx)
+		default:
+			skipTo = idx + 1
+		}
+
+		current.WriteString(script[idx:skipTo])
+		idx = skipTo - 1
+	}
+	addStatement()
+
+	return statements
+}
+
+// Index just past the closing quote matching the one at start, or the end of the script if unterminated
+// Quotes escaped by doubling them are handled naturally, as they close and immediately reopen
+func quotedEnd(script string, start int, backslashEscapes bool) int {
+	quote := script[start]
+
+	for idx := start + 1; idx < len(script); idx++ {
+		if backslashEscapes && script[idx] == '\\' {
+			idx++
+			continue
+		}
+		if script[idx] == quote {
+			return idx + 1
+		}
+	}
+
+	return len(script)
+}
+
+func lineEnd(script string, start int) int {
+	end := strings.IndexByte(script[start:], '\n')
+	if end == -1 {
+		return len(script)
+	}
+
+	return start + end + 1
+}
+
+func blockCommentEnd(script string, start int) int {
+	end := strings.Index(script[start+2:], "*/")
+	if end == -1 {
+		return len(script)
+	}
+
+	return start + 2 + end + 2
+}
+
+// PostgreSQL $$string$$ or $tag$string$tag$
+// If this isn't actually the start of a dollar quote (i.e. a $1 placeholder), just the $ is consumed
+func dollarQuotedEnd(script string, start int) int {
+	tagEnd := start + 1
+	for tagEnd < len(script) && isDollarTagChar(script[tagEnd]) {
+		tagEnd++
+	}
+
+	// Tags can't start with a digit, that's a placeholder
+	startsWithDigit := tagEnd > start+1 && script[start+1] >= '0' && script[start+1] <= '9'
+	if startsWithDigit || tagEnd >= len(script) || script[tagEnd] != '$' {
+		return start + 1
+	}
+
+	tag := script[start : tagEnd+1]
+	end := strings.Index(script[tagEnd+1:], tag)
+	if end == -1 {
+		return len(script)
+	}
+
+	return tagEnd + 1 + end + len(tag)
+}
+
+func isDollarTagChar(char byte) bool {
+	return char == '_' ||
+		(char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') ||
+		(char >= '0' && char <= '9')
+}
@@ -0,0 +1,70 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	var tests = []struct {
+		Name               string
+		Flavor             db.DBFlavor
+		Script             string
+		ExpectedStatements []string
+	}{
+		{
+			Name:               "Simple",
+			Flavor:             db.MySQL,
+			Script:             "SELECT 1; SELECT 2;\nSELECT 3",
+			ExpectedStatements: []string{"SELECT 1", "SELECT 2", "SELECT 3"},
+		},
+		{
+			Name:               "Semicolons In Strings",
+			Flavor:             db.PostgreSQL,
+			Script:             `INSERT INTO t VALUES ('a;b', 'it''s;'); SELECT "weird;column" FROM t;`,
+			ExpectedStatements: []string{`INSERT INTO t VALUES ('a;b', 'it''s;')`, `SELECT "weird;column" FROM t`},
+		},
+		{
+			Name:               "MySQL Backslash Escapes",
+			Flavor:             db.MySQL,
+			Script:             `SELECT 'don\'t;'; SELECT ` + "`a;b`",
+			ExpectedStatements: []string{`SELECT 'don\'t;'`, "SELECT `a;b`"},
+		},
+		{
+			Name:   "Comments",
+			Flavor: db.MySQL,
+			Script: "-- first; statement\nSELECT 1; /* block; comment */ SELECT 2;\n# hash; comment\nSELECT 3;\n-- trailing comment only;",
+			ExpectedStatements: []string{
+				"-- first; statement\nSELECT 1",
+				"/* block; comment */ SELECT 2",
+				"# hash; comment\nSELECT 3",
+			},
+		},
+		{
+			Name:   "PostgreSQL Dollar Quotes",
+			Flavor: db.PostgreSQL,
+			Script: "CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql; SELECT $$a;b$$, $1;",
+			ExpectedStatements: []string{
+				"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql",
+				"SELECT $$a;b$$, $1",
+			},
+		},
+		{
+			Name:               "Empty Statements",
+			Flavor:             db.SQLite,
+			Script:             " ;; SELECT 1;  ; ",
+			ExpectedStatements: []string{"SELECT 1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			test := test
+			t.Parallel()
+
+			assert.Equal(t, test.ExpectedStatements, db.SplitStatements(test.Script, test.Flavor))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func searchPathFor(dsnProducer ConnManager) []string {
+	connOptions, ok := dsnProducer.(*DBConnOptions)
+	if !ok {
+		return nil
+	}
+
+	return slices.Clone(connOptions.SearchPath)
+}
+
+// Change the schemas unqualified names are looked up in, for the current connection and every new one
+// No schemas goes back to the server's default search_path
+// Only supported for PostgreSQL
+func (db *DBClient) SetSearchPath(schemas ...string) error {
+	if db.connManager.GetFlavor() != PostgreSQL {
+		return errors.New("search_path is only supported for PostgreSQL")
+	}
+	if err := validateSearchPath(schemas); err != nil {
+		return err
+	}
+
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	// Pooled connections pick this up as they're opened, so start over with new ones
+	if db.pooled() {
+		db.updateSession(func() {
+			db.searchPath = slices.Clone(schemas)
+		})
+		db.closeIdleConns()
+		return nil
+	}
+
+	if err := db.assertNoOpenQuery(); err != nil {
+		return err
+	}
+
+	// A schema list the server rejects should fail now, rather than on some later reconnect
+	if db._conn != nil {
+		if err := db.applySearchPath(db.ctx, db._conn, schemas); err != nil {
+			return err
+		}
+	}
+
+	db.updateSession(func() {
+		db.searchPath = slices.Clone(schemas)
+	})
+	return nil
+}
+
+// Schemas set through DBConnOptions.SearchPath or SetSearchPath, empty for the server's default
+func (db *DBClient) SearchPath() []string {
+	db.sessionMu.Lock()
+	defer db.sessionMu.Unlock()
+
+	return slices.Clone(db.searchPath)
+}
+
+// ex: SET search_path TO "tenant_42", "public"
+func searchPathStatement(schemas []string) string {
+	if len(schemas) == 0 {
+		return "SET search_path TO DEFAULT"
+	}
+
+	quotedSchemas := make([]string, len(schemas))
+	for idx, schema := range schemas {
+		quotedSchemas[idx] = PostgreSQL.QuoteIdentifier(schema)
+	}
+
+	return "SET search_path TO " + strings.Join(quotedSchemas, ", ")
+}
+
+func (db *DBClient) applySearchPath(ctx context.Context, conn *sqlx.Conn, schemas []string) error {
+	statement := searchPathStatement(schemas)
+
+	startedAt := time.Now()
+	_, err := conn.ExecContext(ctx, statement)
+	db.logQuery(statement, nil, startedAt, &err)
+	if err != nil {
+		return errors.Join(
+			errors.New("Failed to set search_path"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+func validateSearchPath(schemas []string) error {
+	if slices.Contains(schemas, "") {
+		return errors.New("search_path schemas can't be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Version string reported by the database server (ex: 8.0.36 , 16.2 (Debian 16.2-1.pgdg120+2))
+// Fetched once and then cached for the lifetime of the DBClient
+func (db *DBClient) ServerVersion() (string, error) {
+	return db.ServerVersionContext(db.ctx)
+}
+
+// Same as ServerVersion, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) ServerVersionContext(ctx context.Context) (string, error) {
+	return db.cachedServerInfo(ctx, &db.serverVersion, "server version", map[DBFlavor]string{
+		MySQL:      "SELECT VERSION()",
+		PostgreSQL: "SHOW server_version",
+		SQLite:     "SELECT sqlite_version()",
+		SQLServer:  "SELECT CAST(SERVERPROPERTY('ProductVersion') AS NVARCHAR(128))",
+	})
+}
+
+// Name of the database the session is using, i.e. to confirm which one a DSN default picked
+// For SQLite, this is the path to the database file. Empty when no database is selected (MySQL)
+func (db *DBClient) CurrentDatabase() (string, error) {
+	return db.CurrentDatabaseContext(db.ctx)
+}
+
+// Same as CurrentDatabase, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) CurrentDatabaseContext(ctx context.Context) (string, error) {
+	return db.cachedServerInfo(ctx, &db.currentDatabase, "current database", map[DBFlavor]string{
+		MySQL:      "SELECT DATABASE()",
+		PostgreSQL: "SELECT current_database()",
+		SQLite:     "SELECT file FROM pragma_database_list WHERE name = 'main'",
+		SQLServer:  "SELECT DB_NAME()",
+	})
+}
+
+// User the session is authenticated as (ex: user@% for MySQL)
+func (db *DBClient) CurrentUser() (string, error) {
+	return db.CurrentUserContext(db.ctx)
+}
+
+// Same as CurrentUser, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) CurrentUserContext(ctx context.Context) (string, error) {
+	return db.cachedServerInfo(ctx, &db.currentUser, "current user", map[DBFlavor]string{
+		MySQL:      "SELECT CURRENT_USER()",
+		PostgreSQL: "SELECT current_user",
+		SQLServer:  "SELECT SUSER_SNAME()",
+	})
+}
+
+// Host name or address of the server as it reports it, which may differ from the host connected to (i.e. behind a proxy)
+// Empty for PostgreSQL connections over a Unix socket
+func (db *DBClient) ServerHost() (string, error) {
+	return db.ServerHostContext(db.ctx)
+}
+
+// Same as ServerHost, but the lookup can be cancelled or given a deadline through ctx
+func (db *DBClient) ServerHostContext(ctx context.Context) (string, error) {
+	return db.cachedServerInfo(ctx, &db.serverHost, "server host", map[DBFlavor]string{
+		MySQL:      "SELECT @@hostname",
+		PostgreSQL: "SELECT COALESCE(host(inet_server_addr()), '')",
+		SQLServer:  "SELECT CAST(@@SERVERNAME AS NVARCHAR(128))",
+	})
+}
+
+// Look up a value once using the flavor's query, then serve it from cache
+func (db *DBClient) cachedServerInfo(
+	ctx context.Context,
+	cache *string,
+	description string,
+	queries map[DBFlavor]string,
+) (string, error) {
+	db.serverInfoMu.Lock()
+	cached := *cache
+	db.serverInfoMu.Unlock()
+	if cached != "" {
+		return cached, nil
+	}
+
+	query, ok := queries[db.connManager.GetFlavor()]
+	if !ok {
+		return "", fmt.Errorf("Looking up the %s is not supported for %s", description, db.connManager.GetFlavor().DisplayName())
+	}
+
+	value, err := db.queryFirstValue(ctx, query)
+	if err != nil {
+		return "", errors.Join(
+			fmt.Errorf("Failed to determine %s", description),
+			err,
+		)
+	}
+
+	db.serverInfoMu.Lock()
+	*cache = value
+	db.serverInfoMu.Unlock()
+
+	return value, nil
+}
+
+// Switching databases with USE makes the cached name stale
+func (db *DBClient) invalidateCachedServerInfo(statement string) {
+	if statementLeadingKeyword(statement) == "USE" {
+		db.clearCachedCurrentDatabase()
+	}
+}
+
+func (db *DBClient) clearCachedCurrentDatabase() {
+	db.serverInfoMu.Lock()
+	defer db.serverInfoMu.Unlock()
+
+	db.currentDatabase = ""
+}
+
+// The first column of the first row, for queries returning a single value
+func (db *DBClient) queryFirstValue(ctx context.Context, statement string, args ...any) (string, error) {
+	row, columns, err := db.queryFirstRow(ctx, db.lookupScanOptions(), statement, args)
+	if err != nil {
+		return "", err
+	}
+	if row == nil {
+		return "", errors.New("Query returned no rows")
+	}
+	value := row[columns[0]]
+
+	// NULL comes back as empty, i.e. DATABASE() when none is selected
+	return value.String, nil
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Run statement on the current connection, and again on every new one so it survives reconnects
+// Use for session settings, ex: SET TIME ZONE 'UTC' , SET search_path TO app, public
+// Statements run in the order they were added, after the safe mode ones
+// In pool mode, idle connections are dropped so every connection from then on starts with it
+func (db *DBClient) AddSessionInit(statement string) error {
+	return db.AddSessionInitContext(db.ctx, statement)
+}
+
+// Same as AddSessionInit, but running it on the current connection can be cancelled or given a deadline through ctx
+func (db *DBClient) AddSessionInitContext(ctx context.Context, statement string) error {
+	if err := db.assertStatementAllowed(statement); err != nil {
+		return err
+	}
+
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	if db.pooled() {
+		return db.addPooledSessionInit(ctx, statement)
+	}
+
+	if err := db.assertNoOpenQuery(); err != nil {
+		return err
+	}
+
+	// Anything invalid should fail now, rather than on some later reconnect
+	if db._conn != nil {
+		startedAt := time.Now()
+		_, err := db._conn.ExecContext(ctx, statement)
+		db.logQuery(statement, nil, startedAt, &err)
+		if err != nil {
+			return errors.Join(
+				fmt.Errorf("Failed to initialize session with %q", statement),
+				err,
+			)
+		}
+	}
+
+	db.updateSession(func() {
+		db.sessionInit = append(db.sessionInit, statement)
+	})
+	return nil
+}
+
+// Checked on a connection of its own, before any other connection can pick it up
+func (db *DBClient) addPooledSessionInit(ctx context.Context, statement string) error {
+	conn, err := db.sqlDB.Connx(ctx)
+	if err != nil {
+		return errors.Join(
+			ErrConnectionFailed,
+			err,
+		)
+	}
+
+	startedAt := time.Now()
+	_, err = conn.ExecContext(ctx, statement)
+	db.logQuery(statement, nil, startedAt, &err)
+	// Dropped along with the other idle connections below either way
+	_ = conn.Close()
+	if err != nil {
+		return errors.Join(
+			fmt.Errorf("Failed to initialize session with %q", statement),
+			err,
+		)
+	}
+
+	db.updateSession(func() {
+		db.sessionInit = append(db.sessionInit, statement)
+	})
+	db.closeIdleConns()
+
+	return nil
+}
+
+// Statements run on every new connection, starting with those enabling safe mode
+func (db *DBClient) SessionInit() []string {
+	db.sessionMu.Lock()
+	defer db.sessionMu.Unlock()
+
+	return slices.Clone(db.sessionInit)
+}
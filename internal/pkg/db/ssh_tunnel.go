@@ -0,0 +1,285 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Reach the database through a bastion (jump) host, the database Host and Port are dialed from there
+// Unix sockets and SQLite aren't supported, and TLS verify-full can't match the host through the forward
+type SSHTunnelOptions struct {
+	Host string
+	// 22 when 0
+	Port uint
+	User string
+	// Path to a private key, ex: ~/.ssh/id_ed25519
+	PrivateKeyFile string
+	// Only needed if PrivateKeyFile is encrypted
+	PrivateKeyPassphrase string
+	// Tried after the private key, if any
+	Password string
+	// Known hosts file the bastion's host key is checked against, ~/.ssh/known_hosts when empty
+	KnownHostsFile string
+	// Trust whatever host key the bastion presents, only meant for testing
+	InsecureIgnoreHostKey bool
+}
+
+func (tunnelOptions *SSHTunnelOptions) validate(connOptions *DBConnOptions) error {
+	if connOptions.Flavor == SQLite {
+		return errors.New("SSH tunnels are not supported for SQLite")
+	}
+	if connOptions.Socket != "" {
+		return errors.New("SSH tunnels are not supported with a unix socket, set the database host instead")
+	}
+	if connOptions.Host == "" || connOptions.Port == 0 {
+		return errors.New("SSH tunnels need the database host and port, as reachable from the SSH host")
+	}
+	if tunnelOptions.Host == "" || tunnelOptions.User == "" {
+		return errors.New("SSH tunnels need the SSH host and user")
+	}
+	if tunnelOptions.PrivateKeyFile == "" && tunnelOptions.Password == "" {
+		return errors.New("SSH tunnels need a private key or password")
+	}
+
+	return nil
+}
+
+func (tunnelOptions *SSHTunnelOptions) clientConfig(timeout time.Duration) (*ssh.ClientConfig, error) {
+	config := &ssh.ClientConfig{
+		User:    tunnelOptions.User,
+		Timeout: timeout,
+	}
+
+	if tunnelOptions.PrivateKeyFile != "" {
+		key, err := os.ReadFile(tunnelOptions.PrivateKeyFile)
+		if err != nil {
+			return nil, errors.Join(
+				errors.New("Failed to read SSH private key"),
+				err,
+			)
+		}
+
+		var signer ssh.Signer
+		if tunnelOptions.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(tunnelOptions.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, errors.Join(
+				errors.New("Failed to parse SSH private key"),
+				err,
+			)
+		}
+
+		config.Auth = append(config.Auth, ssh.PublicKeys(signer))
+	}
+	if tunnelOptions.Password != "" {
+		config.Auth = append(config.Auth, ssh.Password(tunnelOptions.Password))
+	}
+
+	if tunnelOptions.InsecureIgnoreHostKey {
+		config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return config, nil
+	}
+
+	knownHostsFile := tunnelOptions.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Join(
+				errors.New("Failed to find the known hosts file, set KnownHostsFile"),
+				err,
+			)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, errors.Join(
+			fmt.Errorf("Failed to read known hosts from %s", knownHostsFile),
+			err,
+		)
+	}
+	config.HostKeyCallback = hostKeyCallback
+
+	return config, nil
+}
+
+// Connect to the SSH host at addr, through dial when set
+func dialSSH(dial DialFunc, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	if dial == nil {
+		return ssh.Dial("tcp", addr, config)
+	}
+
+	ctx := context.Background()
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(clientConn, channels, requests), nil
+}
+
+// Local port forward to the database over an SSH connection
+type sshTunnel struct {
+	client   *ssh.Client
+	listener net.Listener
+	// Database address, dialed from the SSH host
+	remoteAddr string
+	// Forwarded connections still being copied
+	forwards sync.WaitGroup
+}
+
+// Connect to the SSH host and start forwarding a local port to the database in connOptions
+func openSSHTunnel(connOptions *DBConnOptions, timeout time.Duration) (*sshTunnel, error) {
+	tunnelOptions := connOptions.SSHTunnel
+	if err := tunnelOptions.validate(connOptions); err != nil {
+		return nil, errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	}
+
+	config, err := tunnelOptions.clientConfig(timeout)
+	if err != nil {
+		return nil, errors.Join(
+			ErrInvalidConnOptions,
+			err,
+		)
+	}
+
+	sshPort := tunnelOptions.Port
+	if sshPort == 0 {
+		sshPort = 22
+	}
+	sshAddr := net.JoinHostPort(tunnelOptions.Host, strconv.FormatUint(uint64(sshPort), 10))
+
+	client, err := dialSSH(connOptions.Dial, sshAddr, config)
+	if err != nil {
+		return nil, errors.Join(
+			ErrConnectionFailed,
+			fmt.Errorf("Failed to connect to SSH host %s", sshAddr),
+			err,
+		)
+	}
+
+	// Only reachable from this machine
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return nil, errors.Join(
+			errors.New("Failed to listen for the SSH tunnel"),
+			err,
+		)
+	}
+
+	tunnel := &sshTunnel{
+		client:     client,
+		listener:   listener,
+		remoteAddr: net.JoinHostPort(connOptions.Host, strconv.FormatUint(uint64(connOptions.Port), 10)),
+	}
+	go tunnel.acceptForwards()
+
+	return tunnel, nil
+}
+
+func (tunnel *sshTunnel) acceptForwards() {
+	for {
+		localConn, err := tunnel.listener.Accept()
+		if err != nil {
+			// Only fails once the listener is closed
+			return
+		}
+
+		tunnel.forwards.Add(1)
+		go func() {
+			defer tunnel.forwards.Done()
+			tunnel.forward(localConn)
+		}()
+	}
+}
+
+func (tunnel *sshTunnel) forward(localConn net.Conn) {
+	defer localConn.Close()
+
+	remoteConn, err := tunnel.client.Dial("tcp", tunnel.remoteAddr)
+	if err != nil {
+		// The driver sees the connection drop and reports it
+		return
+	}
+	defer remoteConn.Close()
+
+	// Closing either side unblocks the other copy
+	copied := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(remoteConn, localConn)
+		copied <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(localConn, remoteConn)
+		copied <- struct{}{}
+	}()
+	<-copied
+}
+
+// Open a tunnel if the connection options ask for one, nil otherwise
+func openSSHTunnelFor(dsnProducer ConnManager, timeout time.Duration) (*sshTunnel, error) {
+	connOptions, ok := dsnProducer.(*DBConnOptions)
+	if !ok || connOptions.SSHTunnel == nil {
+		return nil, nil
+	}
+
+	return openSSHTunnel(connOptions, timeout)
+}
+
+// The DSN to open, pointed at the local end of the tunnel when there is one
+// The tunnel only gets opened for DBConnOptions, see openSSHTunnelFor
+func (tunnel *sshTunnel) connManager(dsnProducer ConnManager) ConnManager {
+	if tunnel == nil {
+		return dsnProducer
+	}
+
+	localAddr := tunnel.listener.Addr().(*net.TCPAddr)
+
+	tunneled := *dsnProducer.(*DBConnOptions)
+	tunneled.Host = localAddr.IP.String()
+	tunneled.Port = uint(localAddr.Port)
+	tunneled.SSHTunnel = nil
+	// Dial is used to reach the SSH host, the database is dialed from there
+	tunneled.Dial = nil
+
+	return &tunneled
+}
+
+// Stop forwarding and disconnect from the SSH host, dropping any forwarded connections
+func (tunnel *sshTunnel) Close() error {
+	err := errors.Join(tunnel.listener.Close(), tunnel.client.Close())
+	tunnel.forwards.Wait()
+
+	return err
+}
@@ -0,0 +1,235 @@
+package db_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Minimal SSH server which only allows port forwarding, reporting each forwarded destination
+type testSSHServer struct {
+	listener     net.Listener
+	hostKey      ssh.Signer
+	destinations chan string
+	disconnects  chan struct{}
+}
+
+func newTestSSHServer(t *testing.T, password string) *testSSHServer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &testSSHServer{
+		listener:     listener,
+		hostKey:      hostKey,
+		destinations: make(chan string, 10),
+		disconnects:  make(chan struct{}, 10),
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, given []byte) (*ssh.Permissions, error) {
+			if conn.User() == "tunnel" && string(given) == password {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn, config)
+		}
+	}()
+
+	return server
+}
+
+func (server *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	go func() {
+		_ = sshConn.Wait()
+		server.disconnects <- struct{}{}
+	}()
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "only port forwarding")
+			continue
+		}
+
+		var forward struct {
+			DestAddr string
+			DestPort uint32
+			OrigAddr string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &forward); err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		destination := net.JoinHostPort(forward.DestAddr, fmt.Sprint(forward.DestPort))
+		server.destinations <- destination
+
+		remoteConn, err := net.Dial("tcp", destination)
+		if err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			remoteConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+
+		go func() {
+			defer channel.Close()
+			defer remoteConn.Close()
+			go func() { _, _ = io.Copy(remoteConn, channel) }()
+			_, _ = io.Copy(channel, remoteConn)
+		}()
+	}
+}
+
+func (server *testSSHServer) port() uint {
+	return uint(server.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func writeKnownHosts(t *testing.T, port uint, key ssh.PublicKey) string {
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	address := net.JoinHostPort("127.0.0.1", fmt.Sprint(port))
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(address)}, key)
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return knownHostsFile
+}
+
+func TestDBSSHTunnel(t *testing.T) {
+	const password = "secret"
+	server := newTestSSHServer(t, password)
+
+	// Stands in for a database that's only reachable from the SSH host, accepting connections but never responding
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	go func() {
+		for {
+			conn, err := database.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	newConnOptions := func(tunnelOptions db.SSHTunnelOptions) db.DBConnOptions {
+		return db.DBConnOptions{
+			Flavor:    db.MySQL,
+			Host:      "127.0.0.1",
+			Port:      uint(database.Addr().(*net.TCPAddr).Port),
+			User:      "user",
+			SSHTunnel: &tunnelOptions,
+		}
+	}
+	clientOptions := db.DefaultDBClientOptions()
+	clientOptions.PingAttempts = 1
+	clientOptions.ConnectTimeout = 200 * time.Millisecond
+
+	t.Run("Forwards to the database", func(t *testing.T) {
+		assert := assert.New(t)
+
+		connOptions := newConnOptions(db.SSHTunnelOptions{
+			Host:           "127.0.0.1",
+			Port:           server.port(),
+			User:           "tunnel",
+			Password:       password,
+			KnownHostsFile: writeKnownHosts(t, server.port(), server.hostKey.PublicKey()),
+		})
+
+		_, err := db.CreateDBClientWithOptions(&connOptions, clientOptions)
+		assert.ErrorContains(err, "Connection timed out after 200ms")
+
+		select {
+		case destination := <-server.destinations:
+			assert.Equal(database.Addr().String(), destination)
+		case <-time.After(time.Second):
+			assert.Fail("Connection wasn't forwarded through the SSH host")
+		}
+
+		// The tunnel isn't left open after failing to connect
+		select {
+		case <-server.disconnects:
+		case <-time.After(time.Second):
+			assert.Fail("SSH connection wasn't closed")
+		}
+	})
+
+	t.Run("Unknown host key", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(err)
+		otherSigner, err := ssh.NewSignerFromKey(otherKey)
+		assert.NoError(err)
+
+		connOptions := newConnOptions(db.SSHTunnelOptions{
+			Host:           "127.0.0.1",
+			Port:           server.port(),
+			User:           "tunnel",
+			Password:       password,
+			KnownHostsFile: writeKnownHosts(t, server.port(), otherSigner.PublicKey()),
+		})
+
+		err = db.TestConnection(&connOptions, time.Second)
+		assert.ErrorIs(err, db.ErrConnectionFailed)
+		assert.ErrorContains(err, "Failed to connect to SSH host")
+		assert.ErrorContains(err, "key mismatch")
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		assert := assert.New(t)
+
+		connOptions := newConnOptions(db.SSHTunnelOptions{Host: "127.0.0.1", User: "tunnel"})
+
+		err := db.TestConnection(&connOptions, time.Second)
+		assert.ErrorIs(err, db.ErrInvalidConnOptions)
+		assert.ErrorContains(err, "SSH tunnels need a private key or password")
+	})
+}
@@ -1,11 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 type StatementWithParams struct {
@@ -15,20 +17,66 @@ type StatementWithParams struct {
 
 // For some special queries we will transform them under the hood for convinience
 // i.e. DESCRIBE for non-MySQL
-func (db *DBClient) transformStatement(statement string) (
+// Otherwise the statement is passed through untouched along with any user provided params
+func (db *DBClient) transformStatement(ctx context.Context, statement string, params []interface{}) (
 	transformedStatement *StatementWithParams,
 	err error,
 ) {
 	tableName, isDescribe := statementIsDescribe(statement)
 	if isDescribe {
-		return db.buildDescribeQuery(tableName, statement)
+		return db.buildDescribeQuery(ctx, tableName, statement)
 	}
 
 	if statementIsShowTables(statement) {
 		return db.buildShowTablesQuery(statement)
 	}
 
-	return &StatementWithParams{statement, nil}, nil
+	return &StatementWithParams{statement, params}, nil
+}
+
+// Get the first keyword of a statement, uppercased
+// Leading whitespace, comments and parentheses are skipped
+func statementLeadingKeyword(statement string) string {
+	keyword, _ := splitLeadingKeyword(statement)
+	return keyword
+}
+
+// Same as statementLeadingKeyword, along with the rest of the statement following the keyword
+func splitLeadingKeyword(statement string) (keyword string, rest string) {
+	remaining := statement
+
+	for {
+		remaining = strings.TrimLeft(remaining, " \t\r\n(")
+
+		if strings.HasPrefix(remaining, "--") || strings.HasPrefix(remaining, "#") {
+			lineEnd := strings.IndexByte(remaining, '\n')
+			if lineEnd == -1 {
+				return "", ""
+			}
+			remaining = remaining[lineEnd+1:]
+			continue
+		}
+
+		if strings.HasPrefix(remaining, "/*") {
+			commentEnd := strings.Index(remaining, "*/")
+			if commentEnd == -1 {
+				return "", ""
+			}
+			remaining = remaining[commentEnd+2:]
+			continue
+		}
+
+		break
+	}
+
+	keywordEnd := strings.IndexFunc(remaining, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if keywordEnd == -1 {
+		keywordEnd = len(remaining)
+	}
+
+	return strings.ToUpper(remaining[:keywordEnd]), remaining[keywordEnd:]
 }
 
 var describeRegExp = regexp.MustCompile(`(?i)^DESCRIBE "?(\w+)"?;?$`)
@@ -65,12 +113,12 @@ func (db *DBClient) buildShowTablesQuery(originalStatement string) (showTablesQu
 		}
 	default:
 		{
-			return nil, fmt.Errorf("SHOW TABLES not supported for %s", db.connManager.GetFlavor())
+			return nil, fmt.Errorf("SHOW TABLES not supported for %s", db.connManager.GetFlavor().DisplayName())
 		}
 	}
 }
 
-func (db *DBClient) buildDescribeQuery(tableName string, originalStatement string) (describeQuery *StatementWithParams, err error) {
+func (db *DBClient) buildDescribeQuery(ctx context.Context, tableName string, originalStatement string) (describeQuery *StatementWithParams, err error) {
 	switch db.connManager.GetFlavor() {
 	case MySQL:
 		{
@@ -78,7 +126,7 @@ func (db *DBClient) buildDescribeQuery(tableName string, originalStatement strin
 		}
 	case PostgreSQL:
 		{
-			tableExists, err := db.assertPostgresTableExists(tableName)
+			tableExists, err := db.assertPostgresTableExists(ctx, tableName)
 			if err != nil {
 				return nil, err
 			}
@@ -89,7 +137,7 @@ func (db *DBClient) buildDescribeQuery(tableName string, originalStatement strin
 		}
 	default:
 		{
-			return nil, fmt.Errorf("DESCRIBE not supported for %s", db.connManager.GetFlavor())
+			return nil, fmt.Errorf("DESCRIBE not supported for %s", db.connManager.GetFlavor().DisplayName())
 		}
 	}
 }
@@ -102,8 +150,8 @@ const postgresTableExistQuery string = `
        AND    table_name = $1
    );`
 
-func (db *DBClient) assertPostgresTableExists(tableName string) (exists bool, err error) {
-	conn, err := db.getConnection()
+func (db *DBClient) assertPostgresTableExists(ctx context.Context, tableName string) (exists bool, err error) {
+	conn, err := db.getConnection(ctx)
 	if err != nil {
 		return false, errors.Join(
 			errors.New("Failed to get connection"),
@@ -111,7 +159,7 @@ func (db *DBClient) assertPostgresTableExists(tableName string) (exists bool, er
 		)
 	}
 
-	err = conn.GetContext(db.ctx, &exists, postgresTableExistQuery, tableName)
+	err = conn.GetContext(ctx, &exists, postgresTableExistQuery, tableName)
 	if err != nil && err != sql.ErrNoRows {
 		return false, errors.Join(
 			errors.New("Unable to validate that the table exists"),
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind of statement going by its leading keyword, see StatementType
+type StmtType string
+
+const (
+	Select  StmtType = "SELECT"
+	Insert  StmtType = "INSERT"
+	Update  StmtType = "UPDATE"
+	Delete  StmtType = "DELETE"
+	DDL     StmtType = "DDL"
+	Show    StmtType = "SHOW"
+	Explain StmtType = "EXPLAIN"
+	Other   StmtType = "OTHER"
+)
+
+var stmtTypesByKeyword = map[string]StmtType{
+	"SELECT":   Select,
+	"VALUES":   Select,
+	"TABLE":    Select,
+	"INSERT":   Insert,
+	"REPLACE":  Insert,
+	"UPDATE":   Update,
+	"DELETE":   Delete,
+	"CREATE":   DDL,
+	"ALTER":    DDL,
+	"DROP":     DDL,
+	"TRUNCATE": DDL,
+	"RENAME":   DDL,
+	"COMMENT":  DDL,
+	"SHOW":     Show,
+	"DESCRIBE": Show,
+	"DESC":     Show,
+	"EXPLAIN":  Explain,
+}
+
+// Classify a statement by its leading keyword, case-insensitive and skipping leading comments and whitespace
+// For WITH, the statement following the CTEs decides, ex: WITH old AS (...) DELETE ... is a Delete
+// Anything unrecognized (i.e. BEGIN, SET, CALL) is Other
+func StatementType(sql string) StmtType {
+	keyword := statementLeadingKeyword(sql)
+	if keyword == "WITH" {
+		keyword = statementKeywordAfterCTEs(sql)
+	}
+
+	if stmtType, ok := stmtTypesByKeyword[keyword]; ok {
+		return stmtType
+	}
+
+	return Other
+}
+
+// Keywords which can start the statement a WITH clause is attached to
+var cteStatementKeywords = []string{"SELECT", "VALUES", "TABLE", "INSERT", "REPLACE", "UPDATE", "DELETE", "MERGE"}
+
+// First statement keyword outside of parentheses following WITH, skipping over each CTE's name, columns and body
+// Comments and quoted strings or identifiers are skipped, so their contents can't be mistaken for keywords
+func statementKeywordAfterCTEs(statement string) string {
+	depth := 0
+
+	for idx := 0; idx < len(statement); {
+		char := statement[idx]
+
+		switch {
+		case strings.HasPrefix(statement[idx:], "--") || char == '#':
+			lineEnd := strings.IndexByte(statement[idx:], '\n')
+			if lineEnd == -1 {
+				return ""
+			}
+			idx += lineEnd + 1
+		case strings.HasPrefix(statement[idx:], "/*"):
+			commentEnd := strings.Index(statement[idx+2:], "*/")
+			if commentEnd == -1 {
+				return ""
+			}
+			idx += commentEnd + 4
+		case char == '\'' || char == '"' || char == '`':
+			quoteEnd := strings.IndexByte(statement[idx+1:], char)
+			if quoteEnd == -1 {
+				return ""
+			}
+			idx += quoteEnd + 2
+		case char == '(':
+			depth++
+			idx++
+		case char == ')':
+			depth--
+			idx++
+		// Keywords are ASCII, anything else is skipped a byte at a time
+		case char < utf8.RuneSelf && isKeywordChar(rune(char)):
+			wordEnd := strings.IndexFunc(statement[idx:], func(r rune) bool { return !isKeywordChar(r) })
+			if wordEnd == -1 {
+				wordEnd = len(statement) - idx
+			}
+
+			word := strings.ToUpper(statement[idx : idx+wordEnd])
+			if depth == 0 && slices.Contains(cteStatementKeywords, word) {
+				return word
+			}
+			idx += wordEnd
+		default:
+			idx++
+		}
+	}
+
+	return ""
+}
+
+func isKeywordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,52 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementType(t *testing.T) {
+	t.Run("Leading keyword", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(db.Select, db.StatementType("SELECT 1"))
+		assert.Equal(db.Select, db.StatementType("  select * from users"))
+		assert.Equal(db.Select, db.StatementType("(SELECT 1) UNION (SELECT 2)"))
+		assert.Equal(db.Select, db.StatementType("VALUES (1), (2)"))
+		assert.Equal(db.Insert, db.StatementType("INSERT INTO users (name) VALUES ('alice')"))
+		assert.Equal(db.Insert, db.StatementType("REPLACE INTO users (id) VALUES (1)"))
+		assert.Equal(db.Update, db.StatementType("Update users SET name = 'bob'"))
+		assert.Equal(db.Delete, db.StatementType("DELETE FROM users"))
+		assert.Equal(db.DDL, db.StatementType("CREATE TABLE users (id INT)"))
+		assert.Equal(db.DDL, db.StatementType("drop table users"))
+		assert.Equal(db.Show, db.StatementType("SHOW TABLES"))
+		assert.Equal(db.Show, db.StatementType("DESCRIBE users"))
+		assert.Equal(db.Explain, db.StatementType("EXPLAIN SELECT 1"))
+		assert.Equal(db.Other, db.StatementType("BEGIN"))
+		assert.Equal(db.Other, db.StatementType(""))
+	})
+
+	t.Run("Leading comments", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(db.Select, db.StatementType("-- a comment\nSELECT 1"))
+		assert.Equal(db.Delete, db.StatementType("/* SELECT */ DELETE FROM users"))
+		assert.Equal(db.Other, db.StatementType("-- only a comment"))
+	})
+
+	t.Run("CTEs", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(db.Select, db.StatementType("WITH recent AS (SELECT * FROM users) SELECT * FROM recent"))
+		assert.Equal(db.Select, db.StatementType("with recursive n(i) as (select 1 union all select i + 1 from n) select i from n"))
+		assert.Equal(
+			db.Delete,
+			db.StatementType("WITH a AS (SELECT 1), b AS MATERIALIZED (SELECT ')') DELETE FROM users WHERE id IN (SELECT * FROM b)"),
+		)
+		assert.Equal(db.Insert, db.StatementType(`WITH "select" AS (SELECT 1) INSERT INTO users SELECT * FROM "select"`))
+		assert.Equal(db.Update, db.StatementType("WITH x AS (SELECT 1 /* ) SELECT */) UPDATE users SET name = 'é'"))
+		assert.Equal(db.Other, db.StatementType("WITH x AS (SELECT 1"))
+	})
+}
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// A reusable handle to a prepared statement
+// The statement is prepared again transparently if the connection was re-established or it got evicted from the cache
+type Stmt struct {
+	db    *DBClient
+	query string
+}
+
+// Prepare a statement to be run repeatedly with different args
+// Prepared statements are cached on the connection by query text, so preparing the same query again is cheap
+func (db *DBClient) Prepare(query string) (*Stmt, error) {
+	return db.PrepareContext(db.ctx, query)
+}
+
+// Same as Prepare, but preparing can be cancelled or given a deadline through ctx
+func (db *DBClient) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	if err := db.assertStatementAllowed(query); err != nil {
+		return nil, err
+	}
+
+	// Prepare upfront so invalid statements fail here rather than on first use, for drivers that validate on prepare
+	db.connMu.Lock()
+	_, err := db.preparedStatement(ctx, query)
+	db.connMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stmt{
+		db:    db,
+		query: query,
+	}, nil
+}
+
+// Run the prepared statement with args bound to its placeholders and store the output in a displayable format
+// results is never nil when err is nil, see QueryResult.NoResultSet for statements that don't return rows
+func (stmt *Stmt) Query(args ...any) (results *QueryResult, err error) {
+	return stmt.QueryContext(stmt.db.ctx, args...)
+}
+
+// Same as Query, but the query can be cancelled or given a deadline through ctx
+func (stmt *Stmt) QueryContext(ctx context.Context, args ...any) (results *QueryResult, err error) {
+	defer stmt.db.logQuery(stmt.query, args, time.Now(), &err)
+	defer func() { results.setStmtType(stmt.query) }()
+	// Read-only or safe mode may have been turned on since it was prepared
+	if err = stmt.db.assertStatementAllowed(stmt.query); err != nil {
+		return nil, err
+	}
+	ctx, cancel := stmt.db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := stmt.db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, stmt.db.explainQueryTimeout(ctx, err)) }()
+
+	if stmt.db.shouldDryRun(stmt.query) {
+		rowsEstimate, err := stmt.db.dryRunStatement(ctx, stmt.query, args)
+		if err != nil {
+			return nil, err
+		}
+		return newDryRunQueryResult(rowsEstimate), nil
+	}
+	stmt.db.resultCache.invalidateFor(stmt.query)
+
+	startedAt := time.Now()
+	sqlStmt, err := stmt.db.preparedStatement(ctx, stmt.query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlStmt.QueryxContext(ctx, args...)
+	if err != nil {
+		return nil, errors.Join(
+			ErrQueryFailed,
+			asQueryError(err),
+		)
+	} else if rows == nil {
+		return newNoResultSetQueryResult(), nil
+	}
+	queryDuration := time.Since(startedAt)
+
+	results, err = scanQueryResult(ctx, rows, stmt.db.scanOptions(), nil)
+	if err != nil {
+		return nil, err
+	}
+	results.setTiming(queryDuration, time.Since(startedAt)-queryDuration)
+
+	return results, nil
+}
+
+// Run the prepared statement with args bound to its placeholders, for statements that don't return rows
+func (stmt *Stmt) Exec(args ...any) (result *ExecResult, err error) {
+	return stmt.ExecContext(stmt.db.ctx, args...)
+}
+
+// Same as Exec, but the statement can be cancelled or given a deadline through ctx
+func (stmt *Stmt) ExecContext(ctx context.Context, args ...any) (result *ExecResult, err error) {
+	defer stmt.db.logQuery(stmt.query, args, time.Now(), &err)
+	if err = stmt.db.assertStatementAllowed(stmt.query); err != nil {
+		return nil, err
+	}
+	ctx, cancel := stmt.db.withQueryTimeout(ctx)
+	defer cancel()
+	ctx, release := stmt.db.trackActiveQuery(ctx)
+	defer release()
+	defer func() { err = explainCancelled(ctx, stmt.db.explainQueryTimeout(ctx, err)) }()
+
+	if stmt.db.shouldDryRun(stmt.query) {
+		rowsEstimate, err := stmt.db.dryRunStatement(ctx, stmt.query, args)
+		if err != nil {
+			return nil, err
+		}
+		return &ExecResult{RowsAffected: rowsEstimate, DryRun: true}, nil
+	}
+	stmt.db.resultCache.invalidateFor(stmt.query)
+
+	sqlStmt, err := stmt.db.preparedStatement(ctx, stmt.query)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlResult, err := sqlStmt.ExecContext(ctx, args...)
+	if err != nil {
+		return nil, errors.Join(
+			ErrExecFailed,
+			asQueryError(err),
+		)
+	}
+
+	return newExecResult(sqlResult)
+}
+
+// Get the statement prepared on the current connection, preparing it if it isn't cached
+// In pool mode it's prepared on the pool instead, database/sql prepares it again on each connection it runs on
+func (db *DBClient) preparedStatement(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	var preparer interface {
+		PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error)
+	} = db.sqlDB
+	if !db.pooled() {
+		// May reconnect, which clears the cache since statements belong to the old connection
+		conn, err := db.getConnection(ctx)
+		if err != nil {
+			return nil, err
+		}
+		preparer = conn
+	}
+
+	if sqlStmt := db.stmtCache.get(query); sqlStmt != nil {
+		return sqlStmt, nil
+	}
+
+	sqlStmt, err := preparer.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, errors.Join(
+			errors.New("Failed to prepare statement"),
+			asQueryError(err),
+		)
+	}
+
+	db.stmtCache.put(query, sqlStmt)
+	return sqlStmt, nil
+}
+
+// Least recently used cache of prepared statements, keyed by query text
+// Evicted statements are closed, so long sessions don't keep accumulating them on the server
+type stmtCache struct {
+	// Pooled queries use the cache concurrently
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	// Most recently used at the front
+	order *list.List
+}
+
+type stmtCacheEntry struct {
+	query   string
+	sqlStmt *sqlx.Stmt
+}
+
+func newStmtCache(capacity int) *stmtCache {
+	return &stmtCache{
+		capacity: max(capacity, 1),
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (cache *stmtCache) get(query string) *sqlx.Stmt {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.entries[query]
+	if !ok {
+		return nil
+	}
+
+	cache.order.MoveToFront(element)
+	return element.Value.(*stmtCacheEntry).sqlStmt
+}
+
+func (cache *stmtCache) put(query string, sqlStmt *sqlx.Stmt) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.entries[query]; ok {
+		entry := element.Value.(*stmtCacheEntry)
+		if entry.sqlStmt != sqlStmt {
+			_ = entry.sqlStmt.Close()
+			entry.sqlStmt = sqlStmt
+		}
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[query] = cache.order.PushFront(&stmtCacheEntry{query, sqlStmt})
+
+	for cache.order.Len() > cache.capacity {
+		oldest := cache.order.Back()
+		entry := cache.order.Remove(oldest).(*stmtCacheEntry)
+		delete(cache.entries, entry.query)
+
+		// Failing to close only leaks the statement until the connection is closed
+		_ = entry.sqlStmt.Close()
+	}
+}
+
+// Close and forget every statement, i.e. when the connection they were prepared on is gone
+func (cache *stmtCache) clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for _, element := range cache.entries {
+		_ = element.Value.(*stmtCacheEntry).sqlStmt.Close()
+	}
+
+	cache.entries = map[string]*list.Element{}
+	cache.order.Init()
+}
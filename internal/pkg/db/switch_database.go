@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Connect to another database on the same server, updating the ConnManager so reconnects land there too
+// PostgreSQL has no USE, so the pool is reopened with the new DSN for every flavor (which also checks name exists)
+// The connection is replaced, session state such as temp tables is lost, while session init statements are replayed
+// Not supported for SQLite, open a client for the other file instead
+func (db *DBClient) SwitchDatabase(name string) error {
+	return db.SwitchDatabaseContext(db.ctx, name)
+}
+
+// Same as SwitchDatabase, but connecting can be cancelled or given a deadline through ctx
+func (db *DBClient) SwitchDatabaseContext(ctx context.Context, name string) error {
+	if db.connManager.GetFlavor() == SQLite {
+		return fmt.Errorf("Switching databases not supported for %s", db.connManager.GetFlavor().DisplayName())
+	}
+	if name == "" {
+		return errors.New("Database name is required to switch databases")
+	}
+
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	if db._tx != nil {
+		return errors.New("Can't switch databases while a transaction is in progress")
+	}
+	if err := db.assertNoOpenQuery(); err != nil {
+		return err
+	}
+
+	previousName := db.connManager.GetDatabaseName()
+	db.connManager.SetDatabaseName(name)
+
+	sqlDB, pgxConnConfigName, err := db.openSQLDB(ctx, db.sshTunnel.connManager(db.connManager))
+	if err != nil {
+		// Keep using the current database, as if this was never called
+		db.connManager.SetDatabaseName(previousName)
+		return errors.Join(
+			fmt.Errorf("Failed to switch to database %q", name),
+			err,
+		)
+	}
+	// Set through SetConnMaxLifetime rather than the options
+	if db.connMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(db.connMaxLifetime)
+	}
+
+	db.stmtCache.clear()
+	db.resultCache.clear()
+	db.clearCachedCurrentDatabase()
+	if db._conn != nil {
+		_ = discardConn(db._conn)
+		db._conn = nil
+	}
+
+	if db.ownsSQLDB {
+		_ = db.sqlDB.Close()
+	}
+	if db.pgxConnConfigName != "" {
+		stdlib.UnregisterConnConfig(db.pgxConnConfigName)
+	}
+
+	db.sqlDB = sqlDB
+	db.ownsSQLDB = true
+	db.pgxConnConfigName = pgxConnConfigName
+
+	return nil
+}
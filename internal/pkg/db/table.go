@@ -0,0 +1,211 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+type TableOptions struct {
+	// Cells wider than this are truncated with an ellipsis, 0 means no limit
+	MaxColumnWidth int
+	// Draw borders with box-drawing characters instead of plain ASCII
+	Unicode bool
+}
+
+type tableBorders struct {
+	horizontal, vertical                  string
+	topLeft, topMiddle, topRight          string
+	middleLeft, middleMiddle, middleRight string
+	bottomLeft, bottomMiddle, bottomRight string
+	ellipsis                              string
+}
+
+var asciiTableBorders = tableBorders{
+	horizontal: "-", vertical: "|",
+	topLeft: "+", topMiddle: "+", topRight: "+",
+	middleLeft: "+", middleMiddle: "+", middleRight: "+",
+	bottomLeft: "+", bottomMiddle: "+", bottomRight: "+",
+	ellipsis: "...",
+}
+
+var unicodeTableBorders = tableBorders{
+	horizontal: "─", vertical: "│",
+	topLeft: "┌", topMiddle: "┬", topRight: "┐",
+	middleLeft: "├", middleMiddle: "┼", middleRight: "┤",
+	bottomLeft: "└", bottomMiddle: "┴", bottomRight: "┘",
+	ellipsis: "…",
+}
+
+// Draw the results as an aligned table with a header row, followed by the row count
+// Numeric columns (based on ColumnTypes) are right-aligned
+func (queryResult *QueryResult) RenderTable(w io.Writer, opts TableOptions) error {
+	borders := asciiTableBorders
+	if opts.Unicode {
+		borders = unicodeTableBorders
+	}
+
+	// Build every cell upfront, widths depend on the widest value in each column
+	header := make([]string, len(queryResult.Columns))
+	columnWidths := make([]int, len(queryResult.Columns))
+	for columnIdx, columnName := range queryResult.Columns {
+		header[columnIdx] = fitTableCell(columnName, opts.MaxColumnWidth, borders.ellipsis)
+		columnWidths[columnIdx] = uniseg.StringWidth(header[columnIdx])
+	}
+
+	cells := make([][]string, len(queryResult.Rows))
+	for rowIdx, row := range queryResult.Rows {
+		cells[rowIdx] = make([]string, len(queryResult.Columns))
+
+		for columnIdx, columnName := range queryResult.Columns {
+			cellValue := fitTableCell(queryResult.DisplayValue(row[columnName]), opts.MaxColumnWidth, borders.ellipsis)
+			cells[rowIdx][columnIdx] = cellValue
+			columnWidths[columnIdx] = max(columnWidths[columnIdx], uniseg.StringWidth(cellValue))
+		}
+	}
+
+	rightAligned := make([]bool, len(queryResult.Columns))
+	for columnIdx := range queryResult.Columns {
+		kind := queryResult.columnKind(columnIdx)
+		rightAligned[columnIdx] = kind == columnKindInteger || kind == columnKindDecimal
+	}
+
+	bufferedWriter := bufio.NewWriter(w)
+
+	writeSeparator := func(left, middle, right string) {
+		bufferedWriter.WriteString(left)
+		for columnIdx, width := range columnWidths {
+			if columnIdx > 0 {
+				bufferedWriter.WriteString(middle)
+			}
+			// Padding on either side of the cell
+			bufferedWriter.WriteString(strings.Repeat(borders.horizontal, width+2))
+		}
+		bufferedWriter.WriteString(right)
+		bufferedWriter.WriteByte('\n')
+	}
+
+	writeRow := func(values []string) {
+		bufferedWriter.WriteString(borders.vertical)
+		for columnIdx, value := range values {
+			padding := strings.Repeat(" ", columnWidths[columnIdx]-uniseg.StringWidth(value))
+
+			bufferedWriter.WriteByte(' ')
+			if rightAligned[columnIdx] {
+				bufferedWriter.WriteString(padding)
+				bufferedWriter.WriteString(value)
+			} else {
+				bufferedWriter.WriteString(value)
+				bufferedWriter.WriteString(padding)
+			}
+			bufferedWriter.WriteByte(' ')
+			bufferedWriter.WriteString(borders.vertical)
+		}
+		bufferedWriter.WriteByte('\n')
+	}
+
+	writeSeparator(borders.topLeft, borders.topMiddle, borders.topRight)
+	writeRow(header)
+	if len(cells) > 0 {
+		writeSeparator(borders.middleLeft, borders.middleMiddle, borders.middleRight)
+		for _, row := range cells {
+			writeRow(row)
+		}
+	}
+	writeSeparator(borders.bottomLeft, borders.bottomMiddle, borders.bottomRight)
+
+	if len(cells) == 1 {
+		bufferedWriter.WriteString("(1 row)\n")
+	} else {
+		fmt.Fprintf(bufferedWriter, "(%d rows)\n", len(cells))
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// So RenderVertical treats \r\n and \n line breaks alike
+var crlfNormalizer = strings.NewReplacer("\r\n", "\n")
+
+// Print each row as a "**** row N ****" header followed by a "column: value" line per column, like MySQL's \G
+// Column names are right-aligned so values line up, lines within a value are indented to match
+// Suited to rows with too many columns for RenderTable, followed by the row count
+func (queryResult *QueryResult) RenderVertical(w io.Writer) error {
+	nameWidth := 0
+	for _, columnName := range queryResult.Columns {
+		nameWidth = max(nameWidth, uniseg.StringWidth(columnName))
+	}
+	continuationIndent := "\n" + strings.Repeat(" ", nameWidth+2)
+
+	bufferedWriter := bufio.NewWriter(w)
+
+	for rowIdx, row := range queryResult.Rows {
+		fmt.Fprintf(bufferedWriter, "**** row %d ****\n", rowIdx+1)
+
+		for _, columnName := range queryResult.Columns {
+			value := strings.ReplaceAll(crlfNormalizer.Replace(queryResult.DisplayValue(row[columnName])), "\n", continuationIndent)
+
+			bufferedWriter.WriteString(strings.Repeat(" ", nameWidth-uniseg.StringWidth(columnName)))
+			bufferedWriter.WriteString(columnName)
+			bufferedWriter.WriteString(": ")
+			bufferedWriter.WriteString(value)
+			bufferedWriter.WriteByte('\n')
+		}
+	}
+
+	if len(queryResult.Rows) == 1 {
+		bufferedWriter.WriteString("(1 row)\n")
+	} else {
+		fmt.Fprintf(bufferedWriter, "(%d rows)\n", len(queryResult.Rows))
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// Width value takes up as a table cell, in terminal cells
+func tableCellWidth(value string) int {
+	// Skip building a copy for the common case, fitTableCell only replaces line breaks and tabs
+	if !strings.ContainsAny(value, "\r\n\t") {
+		return uniseg.StringWidth(value)
+	}
+
+	return uniseg.StringWidth(fitTableCell(value, 0, ""))
+}
+
+var tableCellEscaper = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// Make the value safe to place in a single table line, truncating it to maxWidth when set
+func fitTableCell(value string, maxWidth int, ellipsis string) string {
+	// Line breaks would throw off the alignment of every following column
+	value = tableCellEscaper.Replace(value)
+
+	if maxWidth <= 0 || uniseg.StringWidth(value) <= maxWidth {
+		return value
+	}
+
+	// Too narrow to fit the ellipsis, just cut the value
+	availableWidth := maxWidth - uniseg.StringWidth(ellipsis)
+	if availableWidth < 1 {
+		ellipsis = ""
+		availableWidth = maxWidth
+	}
+
+	// Cut on grapheme boundaries so multi-byte and wide characters aren't split
+	var truncated strings.Builder
+	truncatedWidth := 0
+
+	graphemes := uniseg.NewGraphemes(value)
+	for graphemes.Next() {
+		if truncatedWidth+graphemes.Width() > availableWidth {
+			break
+		}
+
+		truncated.WriteString(graphemes.Str())
+		truncatedWidth += graphemes.Width()
+	}
+	truncated.WriteString(ellipsis)
+
+	return truncated.String()
+}
@@ -0,0 +1,158 @@
+package db_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryResultRenderTable(t *testing.T) {
+	t.Run("ASCII", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		err := newTestQueryResult().RenderTable(&out, db.TableOptions{})
+		assert.NoError(err)
+
+		assert.Equal(
+			"+----+-------------+------+\n"+
+				"| id | name        | note |\n"+
+				"+----+-------------+------+\n"+
+				"|  1 | Smith, John | NULL |\n"+
+				"|  2 | say \"hi\"    | NULL |\n"+
+				"+----+-------------+------+\n"+
+				"(2 rows)\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Unicode With Max Width", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var out strings.Builder
+		err := newTestQueryResult().RenderTable(&out, db.TableOptions{MaxColumnWidth: 6, Unicode: true})
+		assert.NoError(err)
+
+		assert.Equal(
+			"┌────┬────────┬──────┐\n"+
+				"│ id │ name   │ note │\n"+
+				"├────┼────────┼──────┤\n"+
+				"│  1 │ Smith… │ NULL │\n"+
+				"│  2 │ say \"… │ NULL │\n"+
+				"└────┴────────┴──────┘\n"+
+				"(2 rows)\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Wide Characters And Line Breaks", func(t *testing.T) {
+		assert := assert.New(t)
+
+		queryResult := &db.QueryResult{
+			Columns: []string{"value"},
+			Rows: []map[string]*db.NullString{
+				{"value": newTestNullString("日本語テキスト")},
+				{"value": newTestNullString("line\nbreak")},
+			},
+		}
+
+		var out strings.Builder
+		err := queryResult.RenderTable(&out, db.TableOptions{MaxColumnWidth: 10})
+		assert.NoError(err)
+
+		assert.Equal(
+			"+------------+\n"+
+				"| value      |\n"+
+				"+------------+\n"+
+				"| 日本語...  |\n"+
+				"| line break |\n"+
+				"+------------+\n"+
+				"(2 rows)\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Custom NULL Display", func(t *testing.T) {
+		assert := assert.New(t)
+
+		queryResult := newTestQueryResult()
+		queryResult.SetNullDisplay("∅")
+
+		var out strings.Builder
+		err := queryResult.RenderTable(&out, db.TableOptions{})
+		assert.NoError(err)
+
+		// Only actual NULLs change, not the text 'NULL'
+		assert.Equal(
+			"+----+-------------+------+\n"+
+				"| id | name        | note |\n"+
+				"+----+-------------+------+\n"+
+				"|  1 | Smith, John | ∅    |\n"+
+				"|  2 | say \"hi\"    | NULL |\n"+
+				"+----+-------------+------+\n"+
+				"(2 rows)\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Zero Rows", func(t *testing.T) {
+		assert := assert.New(t)
+
+		queryResult := &db.QueryResult{
+			Columns:     []string{"id", "name"},
+			ColumnTypes: []string{"INTEGER", "TEXT"},
+			Rows:        []map[string]*db.NullString{},
+		}
+
+		var out strings.Builder
+		err := queryResult.RenderTable(&out, db.TableOptions{})
+		assert.NoError(err)
+
+		assert.Equal(
+			"+----+------+\n"+
+				"| id | name |\n"+
+				"+----+------+\n"+
+				"(0 rows)\n",
+			out.String(),
+		)
+	})
+}
+
+func TestQueryResultRenderVertical(t *testing.T) {
+	assert := assert.New(t)
+
+	var out strings.Builder
+	assert.NoError(newTestQueryResult().RenderVertical(&out))
+	assert.Equal(
+		"**** row 1 ****\n"+
+			"  id: 1\n"+
+			"name: Smith, John\n"+
+			"note: NULL\n"+
+			"**** row 2 ****\n"+
+			"  id: 2\n"+
+			"name: say \"hi\"\n"+
+			"note: NULL\n"+
+			"(2 rows)\n",
+		out.String(),
+	)
+
+	out.Reset()
+	queryResult := &db.QueryResult{
+		Columns: []string{"id", "description"},
+		Rows: []map[string]*db.NullString{
+			{"id": newTestNullString("1"), "description": newTestNullString("first line\r\nsecond line\nthird line")},
+		},
+	}
+	assert.NoError(queryResult.RenderVertical(&out))
+	assert.Equal(
+		"**** row 1 ****\n"+
+			"         id: 1\n"+
+			"description: first line\n"+
+			"             second line\n"+
+			"             third line\n"+
+			"(1 row)\n",
+		out.String(),
+	)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"text/template"
+)
+
+// Run a statement with identifiers (i.e. a table name, which can't be a bind parameter) filled into {{.name}} placeholders, ex:
+//
+//	dbClient.ExecTemplate("DELETE FROM {{.table}} WHERE id = ?", map[string]string{"table": tableName}, 42)
+//
+// Each identifier is quoted with QuoteIdentifier, so it can't break out of the statement whatever it contains
+// A qualified name needs a placeholder per part, ex: {{.schema}}.{{.table}}
+// args are bound as usual, only the template itself should come from code rather than user input
+func (db *DBClient) ExecTemplate(tmpl string, idents map[string]string, args ...any) (*ExecResult, error) {
+	return db.ExecTemplateContext(db.ctx, tmpl, idents, args...)
+}
+
+// Same as ExecTemplate, but the statement can be cancelled or given a deadline through ctx
+func (db *DBClient) ExecTemplateContext(ctx context.Context, tmpl string, idents map[string]string, args ...any) (*ExecResult, error) {
+	statement, err := db.renderTemplate(tmpl, idents)
+	if err != nil {
+		return nil, errors.Join(
+			ErrExecFailed,
+			err,
+		)
+	}
+
+	return db.ExecContext(ctx, statement, args...)
+}
+
+func (db *DBClient) renderTemplate(tmpl string, idents map[string]string) (string, error) {
+	parsedTemplate, err := template.New("statement").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", errors.Join(
+			errors.New("Invalid statement template"),
+			err,
+		)
+	}
+
+	quotedIdents := maps.Clone(idents)
+	for name, ident := range quotedIdents {
+		quotedIdents[name] = db.QuoteIdentifier(ident)
+	}
+
+	var statement strings.Builder
+	if err := parsedTemplate.Execute(&statement, quotedIdents); err != nil {
+		return "", errors.Join(
+			errors.New("Failed to fill in statement template"),
+			err,
+		)
+	}
+
+	return statement.String(), nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Default timeout applied to each Query/Exec call, a zero duration means no timeout
+// The context is passed down to the driver, so the statement is cancelled server side as well
+func (db *DBClient) SetQueryTimeout(timeout time.Duration) {
+	db.queryTimeout = timeout
+}
+
+func (db *DBClient) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeoutCause(ctx, db.queryTimeout, ErrQueryTimeout)
+}
+
+// If the query failed because of our timeout (rather than the caller's context), make that clear
+func (db *DBClient) explainQueryTimeout(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(context.Cause(ctx), ErrQueryTimeout) {
+		return err
+	}
+
+	return errors.Join(
+		fmt.Errorf("%w of %s", ErrQueryTimeout, db.queryTimeout),
+		err,
+	)
+}
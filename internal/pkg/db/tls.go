@@ -0,0 +1,206 @@
+package db
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// How strictly the connection should be encrypted, mirrors PostgreSQL's sslmode
+type TLSMode string
+
+const (
+	TLSDisable TLSMode = "disable"
+	// Encrypt the connection without verifying the server certificate
+	TLSRequire TLSMode = "require"
+	// Verify the server certificate is signed by the provided CA
+	TLSVerifyCA TLSMode = "verify-ca"
+	// Same as verify-ca, and also verify the certificate matches the host
+	TLSVerifyFull TLSMode = "verify-full"
+)
+
+// TLS settings for MySQL and PostgreSQL connections
+// An empty Mode leaves the driver's default behaviour
+type TLSOptions struct {
+	Mode TLSMode
+	// Paths to PEM encoded files
+	CACert     string
+	ClientCert string
+	ClientKey  string
+}
+
+func (tlsOptions *TLSOptions) isEmpty() bool {
+	return *tlsOptions == TLSOptions{}
+}
+
+// Catch combinations that would otherwise silently connect less securely than intended
+func (tlsOptions *TLSOptions) validate() error {
+	switch tlsOptions.Mode {
+	case "", TLSDisable, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+		break
+	default:
+		return fmt.Errorf(
+			"Unknown TLS mode %q, expected one of disable, require, verify-ca, verify-full",
+			tlsOptions.Mode,
+		)
+	}
+
+	hasCerts := tlsOptions.CACert != "" || tlsOptions.ClientCert != "" || tlsOptions.ClientKey != ""
+	if (tlsOptions.Mode == "" || tlsOptions.Mode == TLSDisable) && hasCerts {
+		return errors.New("TLS certificates provided but TLS mode is not enabled")
+	}
+
+	if (tlsOptions.Mode == TLSVerifyCA || tlsOptions.Mode == TLSVerifyFull) && tlsOptions.CACert == "" {
+		return fmt.Errorf("TLS mode %s requires a CA certificate", tlsOptions.Mode)
+	}
+
+	if (tlsOptions.ClientCert == "") != (tlsOptions.ClientKey == "") {
+		return errors.New("TLS client certificate and key must be provided together")
+	}
+
+	return nil
+}
+
+// Parameters for the pgx connection string
+func (tlsOptions *TLSOptions) postgresParams() map[string]string {
+	params := map[string]string{}
+
+	params["sslmode"] = string(tlsOptions.Mode)
+	params["sslrootcert"] = tlsOptions.CACert
+	params["sslcert"] = tlsOptions.ClientCert
+	params["sslkey"] = tlsOptions.ClientKey
+
+	return params
+}
+
+// Value for the MySQL tls parameter
+// The driver only accepts certificates through a registered config, so one is registered when needed
+func (tlsOptions *TLSOptions) mysqlTLSConfigName(serverName string) (string, error) {
+	switch tlsOptions.Mode {
+	case TLSDisable:
+		return "false", nil
+	case TLSRequire:
+		if tlsOptions.ClientCert == "" {
+			return "skip-verify", nil
+		}
+	}
+
+	tlsConfig, err := tlsOptions.buildTLSConfig(serverName)
+	if err != nil {
+		return "", err
+	}
+
+	// Deterministic name so repeated calls with the same options don't pile up registrations
+	nameHash := sha256.Sum256([]byte(strings.Join(
+		[]string{string(tlsOptions.Mode), tlsOptions.CACert, tlsOptions.ClientCert, tlsOptions.ClientKey, serverName},
+		"\x00",
+	)))
+	configName := fmt.Sprint("custom-", hex.EncodeToString(nameHash[:8]))
+
+	if err = mysql.RegisterTLSConfig(configName, tlsConfig); err != nil {
+		return "", errors.Join(
+			errors.New("Failed to register TLS config"),
+			err,
+		)
+	}
+
+	return configName, nil
+}
+
+func (tlsOptions *TLSOptions) buildTLSConfig(serverName string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: serverName,
+	}
+
+	if tlsOptions.ClientCert != "" {
+		clientCert, err := tls.LoadX509KeyPair(tlsOptions.ClientCert, tlsOptions.ClientKey)
+		if err != nil {
+			return nil, errors.Join(
+				errors.New("Failed to load TLS client certificate"),
+				err,
+			)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	switch tlsOptions.Mode {
+	case TLSRequire:
+		{
+			tlsConfig.InsecureSkipVerify = true
+		}
+	case TLSVerifyCA:
+		{
+			rootCAs, err := loadCertPool(tlsOptions.CACert)
+			if err != nil {
+				return nil, err
+			}
+
+			// Standard verification always checks the host name, so verify the chain ourselves
+			tlsConfig.InsecureSkipVerify = true
+			tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				return verifyCertChain(rawCerts, rootCAs)
+			}
+		}
+	case TLSVerifyFull:
+		{
+			rootCAs, err := loadCertPool(tlsOptions.CACert)
+			if err != nil {
+				return nil, err
+			}
+
+			tlsConfig.RootCAs = rootCAs
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCertPool(caCertPath string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, errors.Join(
+			errors.New("Failed to read TLS CA certificate"),
+			err,
+		)
+	}
+
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("No valid certificates found in %s", caCertPath)
+	}
+
+	return certPool, nil
+}
+
+func verifyCertChain(rawCerts [][]byte, rootCAs *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("Server did not provide a TLS certificate")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, rawCert := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         rootCAs,
+		Intermediates: intermediates,
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// Appended to values cut short by SetMaxColumnBytes
+const truncatedSuffix = "…"
+
+// Limit how much of each value is kept in results, longer values are cut and end with …
+// Keeps huge TEXT/JSON/BLOB values from bloating memory when only displaying them, 0 means no limit
+// Truncated values have NullString.Truncated set, use QueryUntruncated to get them in full
+func (db *DBClient) SetMaxColumnBytes(maxBytes int) {
+	db.maxColumnBytes = max(maxBytes, 0)
+}
+
+// Same as Query, ignoring any SetMaxColumnBytes limit, i.e. to inspect a single value in full
+func (db *DBClient) QueryUntruncated(statement string, args ...any) (results *QueryResult, err error) {
+	return db.QueryUntruncatedContext(db.ctx, statement, args...)
+}
+
+// Same as QueryUntruncated, but the query can be cancelled or given a deadline through ctx
+func (db *DBClient) QueryUntruncatedContext(ctx context.Context, statement string, args ...any) (results *QueryResult, err error) {
+	opts := db.scanOptions()
+	opts.maxColumnBytes = 0
+
+	return db.query(ctx, opts, statement, args)
+}
+
+// Cut the value to maxBytes including the suffix, without splitting a UTF-8 character
+func truncateValue(value *NullString, maxBytes int) {
+	if maxBytes <= 0 || !value.Valid || len(value.String) <= maxBytes {
+		return
+	}
+
+	cutAt := max(maxBytes-len(truncatedSuffix), 0)
+	for cutAt > 0 && !utf8.RuneStart(value.String[cutAt]) {
+		cutAt--
+	}
+
+	// Copy, a substring would keep the whole original value alive
+	value.String = strings.Clone(value.String[:cutAt]) + truncatedSuffix
+	value.Truncated = true
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// A transaction on the DBClient's connection
+// Make sure to either Commit or Rollback once done, otherwise it will be rolled back on Destroy
+type Tx struct {
+	ctx   context.Context
+	sqlTx *sqlx.Tx
+	db    *DBClient
+	// In pool mode the transaction has a connection to itself, returned to the pool once it's done
+	conn *sqlx.Conn
+	// DBClient.sessionGeneration when conn was checked out
+	sessionGeneration int
+	// Serializes statements on conn in pool mode, see lock
+	mu sync.Mutex
+}
+
+// Start a transaction, only one may be in progress at a time since we use a single connection
+// In pool mode the transaction gets its own connection, other queries carry on alongside it
+// If ctx is cancelled before Commit, the transaction is rolled back
+func (db *DBClient) BeginTx(ctx context.Context) (*Tx, error) {
+	db.connMu.Lock()
+	defer db.connMu.Unlock()
+
+	if db._tx != nil {
+		return nil, errors.New("A transaction is already in progress")
+	}
+
+	tx := &Tx{
+		ctx: ctx,
+		db:  db,
+	}
+
+	var conn *sqlx.Conn
+	var err error
+	if db.pooled() {
+		db.sessionMu.Lock()
+		tx.sessionGeneration = db.sessionGeneration
+		db.sessionMu.Unlock()
+
+		conn, err = db.sqlDB.Connx(ctx)
+		if err != nil {
+			return nil, errors.Join(
+				ErrConnectionFailed,
+				err,
+			)
+		}
+		tx.conn = conn
+	} else {
+		conn, err = db.getConnection(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx.sqlTx, err = conn.BeginTxx(ctx, nil)
+	if err != nil {
+		if tx.conn != nil {
+			_ = tx.conn.Close()
+		}
+		return nil, errors.Join(
+			errors.New("Failed to start transaction"),
+			err,
+		)
+	}
+
+	db._tx = tx
+	db.resultCache.beginTx()
+
+	return tx, nil
+}
+
+// Run a query within the transaction and store the output in a displayable format
+// results is never nil when err is nil, see QueryResult.NoResultSet for statements that don't return rows
+func (tx *Tx) Query(statement string, args ...any) (results *QueryResult, err error) {
+	defer tx.db.logQuery(statement, args, time.Now(), &err)
+	defer func() { results.setStmtType(statement) }()
+	if err = tx.db.assertStatementAllowed(statement); err != nil {
+		return nil, err
+	}
+	unlock := tx.lock()
+	defer unlock()
+
+	if tx.db.shouldDryRun(statement) {
+		rowsEstimate, err := tx.db.dryRunStatementOn(tx.ctx, tx.sqlTx, statement, args)
+		if err != nil {
+			return nil, err
+		}
+		return newDryRunQueryResult(rowsEstimate), nil
+	}
+	tx.db.resultCache.invalidateFor(statement)
+
+	startedAt := time.Now()
+	rows, err := tx.sqlTx.QueryxContext(tx.ctx, statement, args...)
+	if err != nil {
+		return nil, errors.Join(
+			ErrQueryFailed,
+			asQueryError(err),
+		)
+	} else if rows == nil {
+		return newNoResultSetQueryResult(), nil
+	}
+	queryDuration := time.Since(startedAt)
+
+	results, err = scanQueryResult(tx.ctx, rows, tx.db.scanOptions(), nil)
+	if err != nil {
+		return nil, err
+	}
+	results.setTiming(queryDuration, time.Since(startedAt)-queryDuration)
+
+	return results, nil
+}
+
+// Run a statement that doesn't return rows within the transaction
+func (tx *Tx) Exec(statement string, args ...any) (result *ExecResult, err error) {
+	defer tx.db.logQuery(statement, args, time.Now(), &err)
+	if err = tx.db.assertStatementAllowed(statement); err != nil {
+		return nil, err
+	}
+	unlock := tx.lock()
+	defer unlock()
+
+	if tx.db.shouldDryRun(statement) {
+		rowsEstimate, err := tx.db.dryRunStatementOn(tx.ctx, tx.sqlTx, statement, args)
+		if err != nil {
+			return nil, err
+		}
+		return &ExecResult{RowsAffected: rowsEstimate, DryRun: true}, nil
+	}
+	tx.db.resultCache.invalidateFor(statement)
+
+	sqlResult, err := tx.sqlTx.ExecContext(tx.ctx, statement, args...)
+	if err != nil {
+		return nil, errors.Join(
+			ErrExecFailed,
+			asQueryError(err),
+		)
+	}
+
+	return newExecResult(sqlResult)
+}
+
+func (tx *Tx) Commit() error {
+	defer tx.release()
+
+	unlock := tx.lock()
+	err := tx.sqlTx.Commit()
+	unlock()
+	if err != nil {
+		return errors.Join(
+			errors.New("Failed to commit transaction"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (tx *Tx) Rollback() error {
+	defer tx.release()
+
+	unlock := tx.lock()
+	err := tx.sqlTx.Rollback()
+	unlock()
+	if err != nil {
+		return errors.Join(
+			errors.New("Failed to rollback transaction"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Hold the transaction's connection for a single statement, the driver can't interleave them
+// Outside pool mode that's the DBClient's connection, so other queries wait as well
+func (tx *Tx) lock() (unlock func()) {
+	if !tx.db.pooled() {
+		tx.db.connMu.Lock()
+		return tx.db.connMu.Unlock
+	}
+
+	tx.mu.Lock()
+	return tx.mu.Unlock
+}
+
+// Allow the DBClient to start a new transaction
+func (tx *Tx) release() {
+	defer tx.db.resetIdleClose()
+
+	tx.db.connMu.Lock()
+	defer tx.db.connMu.Unlock()
+
+	if tx.db._tx == tx {
+		tx.db._tx = nil
+		tx.db.resultCache.endTx()
+	}
+
+	if tx.conn == nil {
+		return
+	}
+
+	tx.db.sessionMu.Lock()
+	stale := tx.sessionGeneration != tx.db.sessionGeneration
+	tx.db.sessionMu.Unlock()
+
+	// Session settings changed while the transaction ran, the pool would hand this connection out without them
+	if stale {
+		_ = discardConn(tx.conn)
+	} else {
+		_ = tx.conn.Close()
+	}
+	tx.conn = nil
+}
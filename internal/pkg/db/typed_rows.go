@@ -0,0 +1,99 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How drivers render dates and timestamps as text
+// database/sql formats time.Time values as RFC 3339, MySQL (without parseTime) sends them as is
+var typedTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Rows with values converted to native Go types going by ColumnTypes, for programmatic use rather than display
+// Values are int64, float64, bool, time.Time (UTC unless the value has a zone), []byte or nil for NULL
+// Anything else, including columns without a known type, stays a string
+// Fails if a value doesn't fit its column's type, or was cut short by DBClient.SetMaxColumnBytes
+func (queryResult *QueryResult) TypedRows() ([]map[string]any, error) {
+	typedRows := make([]map[string]any, len(queryResult.Rows))
+
+	for rowIdx, row := range queryResult.Rows {
+		typedRow := make(map[string]any, len(queryResult.Columns))
+
+		for columnIdx, columnName := range queryResult.Columns {
+			value, err := queryResult.typedValue(row[columnName], columnIdx)
+			if err != nil {
+				return nil, errors.Join(
+					fmt.Errorf("Failed to convert column %q of row %d", columnName, rowIdx+1),
+					err,
+				)
+			}
+
+			typedRow[columnName] = value
+		}
+
+		typedRows[rowIdx] = typedRow
+	}
+
+	return typedRows, nil
+}
+
+func (queryResult *QueryResult) typedValue(cellValue *NullString, columnIdx int) (any, error) {
+	if cellValue == nil || cellValue.IsNull() {
+		return nil, nil
+	}
+
+	kind := queryResult.columnKind(columnIdx)
+	if kind == columnKindText {
+		return cellValue.String, nil
+	}
+	if cellValue.Truncated {
+		return nil, errors.New("Value was truncated")
+	}
+
+	switch kind {
+	case columnKindInteger:
+		return strconv.ParseInt(cellValue.RawValue(), 10, 64)
+	case columnKindDecimal:
+		return strconv.ParseFloat(cellValue.RawValue(), 64)
+	case columnKindBool:
+		return strconv.ParseBool(cellValue.RawValue())
+	case columnKindBinary:
+		return queryResult.binaryFormat.decode(cellValue.String)
+	case columnKindTime:
+		return parseTypedTime(cellValue.String)
+	default:
+		return cellValue.String, nil
+	}
+}
+
+func parseTypedTime(value string) (time.Time, error) {
+	for _, layout := range typedTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Unrecognized date or timestamp %q", value)
+}
+
+// Reverse of encode
+func (format BinaryFormat) decode(value string) ([]byte, error) {
+	switch format {
+	case BinaryHex:
+		return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	case BinaryBase64:
+		return base64.StdEncoding.DecodeString(value)
+	default:
+		return []byte(value), nil
+	}
+}
@@ -0,0 +1,91 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/azvaliev/sql/internal/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryResultTypedRows(t *testing.T) {
+	t.Run("Converts by column type", func(t *testing.T) {
+		assert := assert.New(t)
+
+		result := &db.QueryResult{
+			Columns:     []string{"id", "price", "active", "created_at", "born_on", "label", "untyped"},
+			ColumnTypes: []string{"BIGINT", "NUMERIC", "BOOL", "TIMESTAMPTZ", "DATE", "VARCHAR"},
+			Rows: []map[string]*db.NullString{
+				{
+					"id":         newTestNullString("-42"),
+					"price":      newTestNullString("9.5"),
+					"active":     newTestNullString("t"),
+					"created_at": newTestNullString("2024-03-01T12:30:00.5+02:00"),
+					"born_on":    newTestNullString("1990-07-15"),
+					"label":      newTestNullString("42"),
+					"untyped":    newTestNullString("1"),
+				},
+				{
+					"id":         newTestNull(),
+					"price":      newTestNull(),
+					"active":     newTestNullString("0"),
+					"created_at": newTestNullString("2024-03-01 12:30:00"),
+					"born_on":    newTestNull(),
+					"label":      newTestNull(),
+					"untyped":    newTestNull(),
+				},
+			},
+		}
+
+		typedRows, err := result.TypedRows()
+		assert.NoError(err)
+		if !assert.Len(typedRows, 2) {
+			return
+		}
+
+		assert.Equal(int64(-42), typedRows[0]["id"])
+		assert.Equal(9.5, typedRows[0]["price"])
+		assert.Equal(true, typedRows[0]["active"])
+		assert.True(
+			time.Date(2024, 3, 1, 10, 30, 0, 500_000_000, time.UTC).Equal(typedRows[0]["created_at"].(time.Time)),
+		)
+		assert.Equal(time.Date(1990, 7, 15, 0, 0, 0, 0, time.UTC), typedRows[0]["born_on"])
+		assert.Equal("42", typedRows[0]["label"])
+		assert.Equal("1", typedRows[0]["untyped"])
+
+		assert.Nil(typedRows[1]["id"])
+		assert.Nil(typedRows[1]["label"])
+		assert.Equal(false, typedRows[1]["active"])
+		assert.Equal(time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), typedRows[1]["created_at"])
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		assert := assert.New(t)
+
+		result := &db.QueryResult{
+			Columns:     []string{"id", "count"},
+			ColumnTypes: []string{"INT", "INT"},
+			Rows: []map[string]*db.NullString{
+				{"id": newTestNullString("1"), "count": newTestNullString("many")},
+			},
+		}
+
+		_, err := result.TypedRows()
+		assert.ErrorContains(err, `Failed to convert column "count" of row 1`)
+	})
+
+	t.Run("Truncated value", func(t *testing.T) {
+		assert := assert.New(t)
+
+		truncated := newTestNullString("12")
+		truncated.Truncated = true
+		result := &db.QueryResult{
+			Columns:     []string{"id"},
+			ColumnTypes: []string{"INT"},
+			Rows:        []map[string]*db.NullString{{"id": truncated}},
+		}
+
+		_, err := result.TypedRows()
+		assert.ErrorContains(err, "Value was truncated")
+	})
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// Attach warnings raised by each Query and Exec to the result, off by default
+// For MySQL this runs SHOW WARNINGS after the statement, for PostgreSQL NOTICE messages are collected
+// SQLite and SQL Server results never have warnings
+func (db *DBClient) SetCollectWarnings(collect bool) {
+	db.collectWarnings = collect
+	db.notices.setEnabled(collect)
+}
+
+// NOTICE (and other non-error) messages PostgreSQL sent since they were last drained
+type noticeBuffer struct {
+	mu      sync.Mutex
+	enabled bool
+	notices []string
+}
+
+func (buffer *noticeBuffer) setEnabled(enabled bool) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	buffer.enabled = enabled
+	buffer.notices = nil
+}
+
+func (buffer *noticeBuffer) add(notice string) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	// Nothing would drain them, don't let them pile up
+	if buffer.enabled {
+		buffer.notices = append(buffer.notices, notice)
+	}
+}
+
+func (buffer *noticeBuffer) drain() []string {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	notices := buffer.notices
+	buffer.notices = nil
+	return notices
+}
+
+// database/sql has no way to reach the driver's notice handler, so the config is registered with it instead
+// The custom dialer, if any, goes on the same config
+// Returns the name to open the database with, which must be unregistered once the database is closed
+func registerPostgresNoticeHandler(dataSourceName string, notices *noticeBuffer, dial DialFunc) (string, error) {
+	connConfig, err := pgx.ParseConfig(dataSourceName)
+	if err != nil {
+		return "", err
+	}
+	setPostgresDial(&connConfig.Config, dial)
+
+	connConfig.OnNotice = func(_ *pgconn.PgConn, notice *pgconn.Notice) {
+		notices.add(fmt.Sprintf("%s: %s", notice.Severity, notice.Message))
+	}
+
+	return stdlib.RegisterConnConfig(connConfig), nil
+}
+
+// Forget notices from previous statements, call before running the statement warnings are collected for
+func (db *DBClient) prepareWarnings() {
+	if db.collectWarnings {
+		db.notices.drain()
+	}
+}
+
+// Warnings raised by the statement that just ran on conn, nil if there were none or they aren't being collected
+func (db *DBClient) statementWarnings(ctx context.Context, conn *sqlx.Conn) []string {
+	if !db.collectWarnings || conn == nil {
+		return nil
+	}
+
+	switch db.connManager.GetFlavor() {
+	case MySQL:
+		{
+			warnings, err := mysqlWarnings(ctx, conn)
+			if err != nil {
+				// The statement itself succeeded, so don't fail it over this
+				return []string{fmt.Sprint("Could not retrieve warnings: ", err)}
+			}
+			return warnings
+		}
+	case PostgreSQL:
+		return db.notices.drain()
+	default:
+		return nil
+	}
+}
+
+// Run on the same connection as the statement, warnings are per session
+func mysqlWarnings(ctx context.Context, conn *sqlx.Conn) ([]string, error) {
+	rows, err := conn.QueryxContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	_, _, err = scanRows(ctx, rows, scanOptions{}, func(row map[string]*NullString) error {
+		// ex: Warning 1265: Data truncated for column 'name' at row 1
+		warnings = append(warnings, fmt.Sprintf(
+			"%s %s: %s",
+			row["Level"].String,
+			row["Code"].String,
+			row["Message"].String,
+		))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(
+			errors.New("Failed to read SHOW WARNINGS"),
+			err,
+		)
+	}
+
+	return warnings, nil
+}
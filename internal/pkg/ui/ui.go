@@ -19,7 +19,7 @@ type App struct {
 	tviewApp        *tview.Application
 	resultContainer *components.ScrollBox
 	queryTextArea   *tview.TextArea
-	db              *db.DBClient
+	db              db.Querier
 	queryHistory    *QueryHistory
 }
 
@@ -34,7 +34,7 @@ func MustGetScreenDimensions() (width, height int) {
 }
 
 // Setup initial layout and application structure
-func Init(db *db.DBClient) *App {
+func Init(db db.Querier) *App {
 	tviewApp := tview.NewApplication().EnableMouse(true)
 
 	queryTextArea := NewTextArea()
@@ -95,7 +95,7 @@ func (app *App) commitQuery(query string) {
 	if err != nil {
 		resultItem, height = app.createErrorView(err)
 		queryAction = QueryNoResultsErrorAction
-	} else if results != nil && len(results.Columns) > 0 {
+	} else if !results.NoResultSet {
 		resultItem, height = app.createResultView(results)
 		queryAction = QueryWithResultsActions
 	} else {
@@ -319,7 +319,7 @@ func (app *App) createResultView(result *db.QueryResult) (view *tview.Table, lin
 			resultTable.SetCell(
 				rowIdx,
 				columnIdx,
-				createResultCell(cellValue.ToString()),
+				createResultCell(result.DisplayValue(cellValue)),
 			)
 		}
 	}